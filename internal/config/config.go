@@ -0,0 +1,180 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dailymotion-oss/octopilot/repository"
+	"github.com/dailymotion-oss/octopilot/update"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the content of a configuration file.
+// It maps onto the same options as the CLI flags - the flags taking precedence over the values defined in the file.
+type Config struct {
+	repository.UpdateOptions `yaml:",inline"`
+
+	Updates            []Update `yaml:"updates"`
+	Repos              []Repo   `yaml:"repos"`
+	LogLevel           string   `yaml:"logLevel"`
+	FailOnError        bool     `yaml:"failOnError"`
+	MaxConcurrentRepos int      `yaml:"maxConcurrentRepos"`
+	OutputResults      string   `yaml:"outputResults"`
+}
+
+// allowedValues lists the options which only accept a limited set of values, indexed by their path in the configuration file.
+var allowedValues = []struct {
+	path   []string
+	values []string
+}{
+	{
+		path:   []string{"strategy"},
+		values: []string{"reset", "append", "recreate"},
+	},
+	{
+		path:   []string{"github", "authMethod"},
+		values: []string{"token", "app"},
+	},
+	{
+		path:   []string{"github", "pullRequest", "titleUpdateOperation"},
+		values: []string{repository.IgnoreUpdateOperation, repository.ReplaceUpdateOperation, repository.PrependUpdateOperation, repository.AppendUpdateOperation},
+	},
+	{
+		path:   []string{"github", "pullRequest", "bodyUpdateOperation"},
+		values: []string{repository.IgnoreUpdateOperation, repository.ReplaceUpdateOperation, repository.PrependUpdateOperation, repository.AppendUpdateOperation},
+	},
+	{
+		path:   []string{"github", "pullRequest", "merge", "method"},
+		values: []string{"merge", "squash", "rebase"},
+	},
+}
+
+// Load reads the configuration file located at the given path, and decodes it on top of the given config:
+// the values which are not defined in the file are kept as-is, so that the config can be pre-filled with default values.
+func Load(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration file %s: %w", path, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err = decoder.Decode(config)
+	if errors.Is(err, io.EOF) {
+		// empty file
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	var rootNode yaml.Node
+	if err = yaml.Unmarshal(data, &rootNode); err != nil {
+		return fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	if err = validate(&rootNode); err != nil {
+		return fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Updaters returns the updaters defined in the configuration file.
+func (c *Config) Updaters() []update.Updater {
+	updaters := make([]update.Updater, 0, len(c.Updates))
+	for _, u := range c.Updates {
+		updaters = append(updaters, u.updater)
+	}
+	return updaters
+}
+
+// Repositories returns the repositories defined in the configuration file, including the ones that are dynamically discovered.
+func (c *Config) Repositories(ctx context.Context, githubOpts repository.GitHubOptions) ([]repository.Repository, error) {
+	var (
+		repositories []repository.Repository
+		seen         = make(map[string]bool)
+	)
+	for _, repo := range c.Repos {
+		var repos []repository.Repository
+		if len(repo.DiscoverFrom) > 0 {
+			discoveredRepos, err := repository.Discover(ctx, repo.discoveryParams(), githubOpts)
+			if err != nil {
+				return nil, fmt.Errorf("line %d, column %d: failed to discover repositories: %w", repo.line, repo.column, err)
+			}
+			repos = discoveredRepos
+		} else {
+			staticRepo, err := repository.New(repo.Name, repo.Params)
+			if err != nil {
+				return nil, fmt.Errorf("line %d, column %d: %w", repo.line, repo.column, err)
+			}
+			repos = []repository.Repository{staticRepo}
+		}
+
+		for _, r := range repos {
+			if seen[r.FullName()] {
+				continue
+			}
+			seen[r.FullName()] = true
+			repositories = append(repositories, r)
+		}
+	}
+	return repositories, nil
+}
+
+func validate(rootNode *yaml.Node) error {
+	for _, allowed := range allowedValues {
+		node := lookup(rootNode, allowed.path...)
+		if node == nil || len(node.Value) == 0 {
+			continue
+		}
+		if !slices.Contains(allowed.values, node.Value) {
+			return errorf(node, "invalid value %q for %s: expected one of %s", node.Value, strings.Join(allowed.path, "."), strings.Join(allowed.values, ", "))
+		}
+	}
+	return nil
+}
+
+// lookup returns the node located at the given path, or nil if there is no such node.
+func lookup(node *yaml.Node, path ...string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if len(path) == 0 {
+		return node
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == path[0] {
+			return lookup(node.Content[i+1], path[1:]...)
+		}
+	}
+	return nil
+}
+
+// decodeParams decodes a mapping of scalar values - as used for the updaters/valuers/repositories parameters.
+func decodeParams(node *yaml.Node) (map[string]string, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, errorf(node, "expected a mapping of parameters")
+	}
+	params := make(map[string]string, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		if valueNode.Kind != yaml.ScalarNode {
+			return nil, errorf(valueNode, "invalid value for parameter %s: expected a scalar value", keyNode.Value)
+		}
+		params[keyNode.Value] = valueNode.Value
+	}
+	return params, nil
+}
+
+func errorf(node *yaml.Node, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d, column %d: %s", node.Line, node.Column, fmt.Sprintf(format, args...))
+}
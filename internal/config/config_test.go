@@ -0,0 +1,191 @@
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/dailymotion-oss/octopilot/repository"
+	"github.com/dailymotion-oss/octopilot/update"
+	"github.com/dailymotion-oss/octopilot/update/exec"
+	"github.com/dailymotion-oss/octopilot/update/regex"
+	"github.com/dailymotion-oss/octopilot/update/value"
+	"github.com/dailymotion-oss/octopilot/update/yaml"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		file             string
+		expectedErrorMsg string
+	}{
+		{
+			name: "empty file",
+			file: "empty.yaml",
+		},
+		{
+			name: "valid file",
+			file: "full.yaml",
+		},
+		{
+			name:             "file does not exist",
+			file:             "does-not-exist.yaml",
+			expectedErrorMsg: "failed to read configuration file testdata/does-not-exist.yaml: open testdata/does-not-exist.yaml: no such file or directory",
+		},
+		{
+			name:             "unknown field",
+			file:             "unknown-field.yaml",
+			expectedErrorMsg: "invalid configuration file testdata/unknown-field.yaml: yaml: unmarshal errors:\n  line 4: field whatever not found in type repository.GitOptions",
+		},
+		{
+			name:             "invalid strategy",
+			file:             "invalid-strategy.yaml",
+			expectedErrorMsg: `invalid configuration file testdata/invalid-strategy.yaml: line 2, column 11: invalid value "whatever" for strategy: expected one of reset, append, recreate`,
+		},
+		{
+			name:             "invalid branch protection",
+			file:             "invalid-branch-protection.yaml",
+			expectedErrorMsg: `invalid configuration file testdata/invalid-branch-protection.yaml: line 4, column 25: invalid branch protection kind "whatever", expected one of statusChecks|all|bypass`,
+		},
+		{
+			name:             "invalid updater",
+			file:             "invalid-updater.yaml",
+			expectedErrorMsg: "invalid configuration file testdata/invalid-updater.yaml: line 2, column 5: failed to create an updater instance for regex: missing pattern parameter",
+		},
+		{
+			name:             "invalid updater params",
+			file:             "invalid-updater-params.yaml",
+			expectedErrorMsg: "invalid configuration file testdata/invalid-updater-params.yaml: line 4, column 9: invalid value for parameter file: expected a scalar value",
+		},
+		{
+			name:             "multiple updaters",
+			file:             "multiple-updaters.yaml",
+			expectedErrorMsg: "invalid configuration file testdata/multiple-updaters.yaml: line 5, column 5: invalid update: found multiple updaters regex and yaml, expected a single one",
+		},
+		{
+			name:             "invalid valuer",
+			file:             "invalid-valuer.yaml",
+			expectedErrorMsg: "invalid configuration file testdata/invalid-valuer.yaml: line 6, column 7: failed to create valuer: unknown valuer whatever",
+		},
+		{
+			name:             "invalid repository",
+			file:             "invalid-repo.yaml",
+			expectedErrorMsg: "invalid configuration file testdata/invalid-repo.yaml: line 3, column 5: invalid repository name my-org: expected owner/name",
+		},
+		{
+			name:             "invalid repository discovery",
+			file:             "invalid-repo-discovery.yaml",
+			expectedErrorMsg: "invalid configuration file testdata/invalid-repo-discovery.yaml: line 2, column 5: invalid repository: discoverFrom requires either a query or an env parameter",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var cfg Config
+			err := Load(filepath.Join("testdata", test.file), &cfg)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoadOnTopOfDefaultValues(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{
+		UpdateOptions: repository.UpdateOptions{
+			Strategy: "reset",
+			Git: repository.GitOptions{
+				BranchPrefix: "octopilot-",
+				CommitTitle:  "default title",
+			},
+			GitHub: repository.GitHubOptions{
+				AuthMethod: "token",
+				PullRequest: repository.PullRequestOptions{
+					Labels: []string{"octopilot-update"},
+					Merge: repository.PullRequestMergeOptions{
+						Method:      "merge",
+						PollTimeout: 10 * time.Minute,
+					},
+				},
+			},
+		},
+		LogLevel: "info",
+	}
+	err := Load(filepath.Join("testdata", "full.yaml"), &cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, repository.UpdateOptions{
+		DryRun:   true,
+		Strategy: "append",
+		Git: repository.GitOptions{
+			BranchPrefix:  "octopilot-",
+			CommitTitle:   "Update the version",
+			StagePatterns: []string{"vendor"},
+		},
+		GitHub: repository.GitHubOptions{
+			AuthMethod: "token",
+			PullRequest: repository.PullRequestOptions{
+				Labels: []string{"promotion"},
+				Merge: repository.PullRequestMergeOptions{
+					Enabled:          true,
+					Method:           "merge",
+					PollTimeout:      5 * time.Minute,
+					BranchProtection: repository.BranchProtectionKindAll,
+				},
+			},
+		},
+	}, cfg.UpdateOptions)
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, 5, cfg.MaxConcurrentRepos)
+	assert.False(t, cfg.FailOnError)
+
+	assert.Equal(t, []update.Updater{
+		&yaml.YamlUpdater{
+			FilePath: "config.yaml",
+			Path:     "version",
+			Indent:   2,
+			Valuer: &value.FileValuer{
+				Path: "VERSION",
+			},
+		},
+		&regex.RegexUpdater{
+			FilePath: "some-file.txt",
+			Pattern:  `version: "(.*)"`,
+			Regexp:   regexp.MustCompile(`version: "(.*)"`),
+			Valuer:   value.StringValuer("1.2.3"),
+		},
+		&exec.ExecUpdater{
+			Command: "make",
+			Args:    []string{"generate"},
+		},
+	}, cfg.Updaters())
+
+	repos, err := cfg.Repositories(context.Background(), cfg.GitHub)
+	require.NoError(t, err)
+	assert.Equal(t, []repository.Repository{
+		{
+			Owner:  "my-org",
+			Name:   "some-repo",
+			Params: map[string]string{},
+		},
+		{
+			Owner: "my-org",
+			Name:  "another-repo",
+			Params: map[string]string{
+				"merge": "true",
+			},
+		},
+	}, repos)
+}
@@ -0,0 +1,2 @@
+// Package config provides the definition of the YAML configuration file, which can be used as an alternative to the CLI flags.
+package config
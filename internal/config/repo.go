@@ -0,0 +1,93 @@
+package config
+
+import (
+	"github.com/dailymotion-oss/octopilot/repository"
+	"gopkg.in/yaml.v3"
+)
+
+// Repo is the definition of a repository in a configuration file. It is either:
+//   - a static repository, defined by its full name - such as "owner/name" - and optional params
+//   - a set of repositories dynamically discovered - using the same params as the "discover-from" syntax -
+//     with optional params that will be applied to each discovered repository
+//
+// For example:
+//
+//	repos:
+//	- my-org/some-repo
+//	- name: my-org/another-repo
+//	  params:
+//	    merge: true
+//	- discoverFrom:
+//	    query: org:my-org topic:my-topic
+type Repo struct {
+	Name         string
+	Params       map[string]string
+	DiscoverFrom map[string]string
+
+	line, column int
+}
+
+// UnmarshalYAML decodes and validates a repository definition.
+func (r *Repo) UnmarshalYAML(node *yaml.Node) error {
+	r.line, r.column = node.Line, node.Column
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		r.Name = node.Value
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			var err error
+			switch keyNode.Value {
+			case "name":
+				if valueNode.Kind != yaml.ScalarNode {
+					return errorf(valueNode, "invalid repository name: expected a string")
+				}
+				r.Name = valueNode.Value
+			case "params":
+				r.Params, err = decodeParams(valueNode)
+			case "discoverFrom":
+				r.DiscoverFrom, err = decodeParams(valueNode)
+			default:
+				return errorf(keyNode, "invalid repository: unknown field %s, expected one of name, params or discoverFrom", keyNode.Value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return errorf(node, "invalid repository: expected either a string or a mapping")
+	}
+
+	switch {
+	case len(r.Name) > 0 && len(r.DiscoverFrom) > 0:
+		return errorf(node, "invalid repository: name and discoverFrom are mutually exclusive")
+	case len(r.Name) > 0:
+		if _, err := repository.New(r.Name, r.Params); err != nil {
+			return errorf(node, "%s", err)
+		}
+	case len(r.DiscoverFrom) > 0:
+		_, hasQuery := r.DiscoverFrom["query"]
+		_, hasEnv := r.DiscoverFrom["env"]
+		if !hasQuery && !hasEnv {
+			return errorf(node, "invalid repository: discoverFrom requires either a query or an env parameter")
+		}
+	default:
+		return errorf(node, "invalid repository: missing either name or discoverFrom")
+	}
+
+	return nil
+}
+
+// discoveryParams returns a new map with the params used to discover repositories,
+// merged with the params that will be applied to each discovered repository.
+func (r Repo) discoveryParams() map[string]string {
+	params := make(map[string]string, len(r.DiscoverFrom)+len(r.Params))
+	for k, v := range r.Params {
+		params[k] = v
+	}
+	for k, v := range r.DiscoverFrom {
+		params[k] = v
+	}
+	return params
+}
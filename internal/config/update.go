@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/dailymotion-oss/octopilot/update"
+	"github.com/dailymotion-oss/octopilot/update/value"
+	"gopkg.in/yaml.v3"
+)
+
+// Update is the definition of an updater in a configuration file.
+// It is a mapping with a single key - the name of the updater - whose value is the mapping of the updater's parameters,
+// and an optional value - either a raw string, or a valuer definition. For example:
+//
+//	updates:
+//	- yaml:
+//	    file: config.yaml
+//	    path: version
+//	  value:
+//	    file:
+//	      path: VERSION
+type Update struct {
+	Name   string
+	Params map[string]string
+	Value  Value
+
+	updater update.Updater
+}
+
+// Value is the definition of a value in a configuration file: either a raw string,
+// or a mapping with a single key - the name of the valuer - whose value is the mapping of the valuer's parameters.
+type Value struct {
+	Raw    string
+	Name   string
+	Params map[string]string
+}
+
+// UnmarshalYAML decodes and validates an update definition, and builds the corresponding updater.
+func (u *Update) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return errorf(node, "invalid update: expected a mapping with the name of the updater as key")
+	}
+
+	var nameNode, valueNode *yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Value == "value" {
+			valueNode = node.Content[i+1]
+			if err := u.Value.decode(valueNode); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if nameNode != nil {
+			return errorf(keyNode, "invalid update: found multiple updaters %s and %s, expected a single one", nameNode.Value, keyNode.Value)
+		}
+		nameNode = keyNode
+
+		params, err := decodeParams(node.Content[i+1])
+		if err != nil {
+			return err
+		}
+		u.Name = keyNode.Value
+		u.Params = params
+	}
+	if nameNode == nil {
+		return errorf(node, "invalid update: missing updater name")
+	}
+
+	valuer, err := u.Value.valuer()
+	if err != nil {
+		return errorf(valueNode, "%s", err)
+	}
+	u.updater, err = update.New(u.Name, u.Params, valuer)
+	if err != nil {
+		return errorf(nameNode, "%s", err)
+	}
+
+	return nil
+}
+
+func (v *Value) decode(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		v.Raw = node.Value
+	case yaml.MappingNode:
+		if len(node.Content) != 2 {
+			return errorf(node, "invalid value: expected a mapping with the name of the valuer as single key")
+		}
+		params, err := decodeParams(node.Content[1])
+		if err != nil {
+			return err
+		}
+		v.Name = node.Content[0].Value
+		v.Params = params
+	default:
+		return errorf(node, "invalid value: expected either a string or a valuer definition")
+	}
+	return nil
+}
+
+func (v Value) valuer() (value.Valuer, error) {
+	if len(v.Name) == 0 {
+		return value.StringValuer(v.Raw), nil
+	}
+	valuer, err := value.New(v.Name, v.Params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create valuer: %w", err)
+	}
+	return valuer, nil
+}
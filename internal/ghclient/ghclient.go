@@ -0,0 +1,46 @@
+// Package ghclient provides a way to share the authenticated GitHub client - and the git credentials - with the updaters and the valuers, through the context.
+package ghclient
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/go-github/v57/github"
+)
+
+type contextKey struct{}
+
+// NewContext returns a new context holding the given GitHub client.
+func NewContext(ctx context.Context, client *github.Client) context.Context {
+	return context.WithValue(ctx, contextKey{}, client)
+}
+
+// FromContext returns the GitHub client held by the given context - or an error if there is none.
+func FromContext(ctx context.Context) (*github.Client, error) {
+	client, ok := ctx.Value(contextKey{}).(*github.Client)
+	if !ok || client == nil {
+		return nil, errors.New("no GitHub client available")
+	}
+	return client, nil
+}
+
+// Credentials holds what is needed to access the git repositories hosted on GitHub, over HTTPS.
+type Credentials struct {
+	// URL is the base URL of the GitHub instance - such as https://github.com
+	URL string
+	// Token is the token used to authenticate the git operations
+	Token string
+}
+
+type credentialsContextKey struct{}
+
+// NewCredentialsContext returns a new context holding the given git credentials.
+func NewCredentialsContext(ctx context.Context, credentials Credentials) context.Context {
+	return context.WithValue(ctx, credentialsContextKey{}, credentials)
+}
+
+// CredentialsFromContext returns the git credentials held by the given context - and false if there are none.
+func CredentialsFromContext(ctx context.Context) (Credentials, bool) {
+	credentials, ok := ctx.Value(credentialsContextKey{}).(Credentials)
+	return credentials, ok
+}
@@ -0,0 +1,34 @@
+package ghclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	_, err := FromContext(context.Background())
+	require.EqualError(t, err, "no GitHub client available")
+
+	client := github.NewClient(nil)
+	actual, err := FromContext(NewContext(context.Background(), client))
+	require.NoError(t, err)
+	assert.Same(t, client, actual)
+}
+
+func TestCredentialsFromContext(t *testing.T) {
+	t.Parallel()
+
+	_, ok := CredentialsFromContext(context.Background())
+	assert.False(t, ok)
+
+	credentials := Credentials{URL: "https://github.com", Token: "my-token"}
+	actual, ok := CredentialsFromContext(NewCredentialsContext(context.Background(), credentials))
+	assert.True(t, ok)
+	assert.Equal(t, credentials, actual)
+}
@@ -0,0 +1,63 @@
+// Package image provides functions to work with container image references.
+package image
+
+import (
+	"strings"
+)
+
+// ParseReference splits an image reference - such as registry/org/image:tag@sha256:... - into its name, tag and digest.
+func ParseReference(ref string) (name, tag, digest string) {
+	name, digest, _ = strings.Cut(ref, "@")
+	if i := strings.LastIndexByte(name, ':'); i > strings.LastIndexByte(name, '/') {
+		name, tag = name[:i], name[i+1:]
+	}
+	return name, tag, digest
+}
+
+// FormatReference returns the image reference made of the given name, tag and digest - the last 2 being optional.
+func FormatReference(name, tag, digest string) string {
+	ref := name
+	if len(tag) > 0 {
+		ref += ":" + tag
+	}
+	if len(digest) > 0 {
+		ref += "@" + digest
+	}
+	return ref
+}
+
+// ParseVersion parses the version of an image, which can be either a tag, a digest, or both - such as tag@sha256:...
+func ParseVersion(version string) (tag, digest string) {
+	version = strings.TrimSpace(version)
+	if strings.HasPrefix(version, "sha256:") {
+		return "", version
+	}
+	tag, digest, _ = strings.Cut(version, "@")
+	return tag, digest
+}
+
+// FormatVersion returns the version made of the given tag and digest - any of them being optional.
+func FormatVersion(tag, digest string) string {
+	switch {
+	case len(tag) > 0 && len(digest) > 0:
+		return tag + "@" + digest
+	case len(digest) > 0:
+		return digest
+	default:
+		return tag
+	}
+}
+
+// NormalizeName removes the implicit parts of the images hosted on the Docker Hub,
+// so that "nginx" is the same as "docker.io/library/nginx".
+func NormalizeName(name string) string {
+	for _, prefix := range []string{"docker.io/", "index.docker.io/", "registry-1.docker.io/"} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	return strings.TrimPrefix(name, "library/")
+}
+
+// SameName returns true if both image names - without tag or digest - reference the same image.
+func SameName(name, other string) bool {
+	return NormalizeName(name) == NormalizeName(other)
+}
@@ -0,0 +1,91 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		ref            string
+		expectedName   string
+		expectedTag    string
+		expectedDigest string
+	}{
+		{
+			ref:          "nginx",
+			expectedName: "nginx",
+		},
+		{
+			ref:          "registry.example.com/org/app:1.0.0",
+			expectedName: "registry.example.com/org/app",
+			expectedTag:  "1.0.0",
+		},
+		{
+			ref:            "localhost:5000/app@sha256:0123456789abcdef",
+			expectedName:   "localhost:5000/app",
+			expectedDigest: "sha256:0123456789abcdef",
+		},
+		{
+			ref:            "localhost:5000/app:1.0@sha256:0123456789abcdef",
+			expectedName:   "localhost:5000/app",
+			expectedTag:    "1.0",
+			expectedDigest: "sha256:0123456789abcdef",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.ref, func(t *testing.T) {
+			t.Parallel()
+			name, tag, digest := ParseReference(test.ref)
+			assert.Equal(t, test.expectedName, name)
+			assert.Equal(t, test.expectedTag, tag)
+			assert.Equal(t, test.expectedDigest, digest)
+			assert.Equal(t, test.ref, FormatReference(name, tag, digest))
+		})
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		version        string
+		expectedTag    string
+		expectedDigest string
+	}{
+		{
+			version:     "1.0.0",
+			expectedTag: "1.0.0",
+		},
+		{
+			version:        "sha256:0123456789abcdef",
+			expectedDigest: "sha256:0123456789abcdef",
+		},
+		{
+			version:        "1.0.0@sha256:0123456789abcdef",
+			expectedTag:    "1.0.0",
+			expectedDigest: "sha256:0123456789abcdef",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.version, func(t *testing.T) {
+			t.Parallel()
+			tag, digest := ParseVersion(test.version)
+			assert.Equal(t, test.expectedTag, tag)
+			assert.Equal(t, test.expectedDigest, digest)
+			assert.Equal(t, test.version, FormatVersion(tag, digest))
+		})
+	}
+}
+
+func TestSameName(t *testing.T) {
+	t.Parallel()
+	assert.True(t, SameName("nginx", "docker.io/library/nginx"))
+	assert.True(t, SameName("index.docker.io/org/app", "org/app"))
+	assert.False(t, SameName("registry.example.com/nginx", "nginx"))
+}
@@ -0,0 +1,548 @@
+// Package json provides functions to work with JSON content, while preserving the formatting.
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kind is the kind of a JSON value.
+type Kind int
+
+// definition of the different kinds of JSON values
+const (
+	String Kind = iota
+	Number
+	Bool
+	Null
+	Object
+	Array
+)
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case String:
+		return "string"
+	case Number:
+		return "number"
+	case Bool:
+		return "bool"
+	case Null:
+		return "null"
+	case Object:
+		return "object"
+	case Array:
+		return "array"
+	default:
+		return "unknown"
+	}
+}
+
+// Value is a value defined in a JSON document, with its position in the document's content.
+type Value struct {
+	Kind  Kind
+	Start int
+	End   int
+
+	// Key is the key of the value, if its parent is an object - with the position of the quoted key in the document.
+	Key      string
+	KeyStart int
+	KeyEnd   int
+	// Index is the index of the value, if its parent is an array.
+	Index int
+
+	// Members are the members of an object, or the elements of an array - in the order in which they are defined.
+	Members []*Value
+	Parent  *Value
+}
+
+// Member returns the member of an object with the given key.
+func (v *Value) Member(key string) (*Value, bool) {
+	if v.Kind != Object {
+		return nil, false
+	}
+	for i := len(v.Members) - 1; i >= 0; i-- {
+		if v.Members[i].Key == key {
+			return v.Members[i], true
+		}
+	}
+	return nil, false
+}
+
+// Path returns the location of the value in the document, such as `$.dependencies.lodash` or `$.files[0]`.
+func (v *Value) Path() string {
+	if v.Parent == nil {
+		return "$"
+	}
+	if v.Parent.Kind == Array {
+		return fmt.Sprintf("%s[%d]", v.Parent.Path(), v.Index)
+	}
+	if isSimpleKey(v.Key) {
+		return v.Parent.Path() + "." + v.Key
+	}
+	return fmt.Sprintf("%s[%s]", v.Parent.Path(), Quote(v.Key))
+}
+
+// Document is a parsed JSON document, which keeps track of the position of each value,
+// so that they can be updated in place - without changing anything else in the document.
+type Document struct {
+	data []byte
+	root *Value
+}
+
+// Parse parses the given JSON content.
+func Parse(data []byte) (*Document, error) {
+	p := &parser{data: data}
+	p.skipWhitespaces()
+	root, err := p.parseValue(nil)
+	if err == nil {
+		p.skipWhitespaces()
+		if !p.eof() {
+			err = fmt.Errorf("unexpected character %q after the end of the document", p.data[p.pos])
+		}
+	}
+	if err != nil {
+		line, column := p.location()
+		return nil, fmt.Errorf("line %d, column %d: %w", line, column, err)
+	}
+	return &Document{data: data, root: root}, nil
+}
+
+// Bytes returns the content of the document.
+func (d *Document) Bytes() []byte {
+	return d.data
+}
+
+// Root returns the root value of the document.
+func (d *Document) Root() *Value {
+	return d.root
+}
+
+// Raw returns the raw representation of the given value, as written in the document.
+func (d *Document) Raw(v *Value) string {
+	return string(d.data[v.Start:v.End])
+}
+
+// String returns the content of the given value: unquoted for the strings, raw for the other kinds of values.
+func (d *Document) String(v *Value) (string, error) {
+	if v.Kind != String {
+		return d.Raw(v), nil
+	}
+	var str string
+	if err := json.Unmarshal(d.data[v.Start:v.End], &str); err != nil {
+		return "", fmt.Errorf("invalid string %s: %w", d.Raw(v), err)
+	}
+	return str, nil
+}
+
+// Replacement is the new - already encoded - content of a value.
+type Replacement struct {
+	Value   *Value
+	Content string
+}
+
+// Replace returns the content of the document, with the given values replaced.
+// The replacements must not overlap.
+func (d *Document) Replace(replacements ...Replacement) []byte {
+	var (
+		result   bytes.Buffer
+		position int
+	)
+	for _, r := range sortReplacements(replacements) {
+		result.Write(d.data[position:r.Value.Start])
+		result.WriteString(r.Content)
+		position = r.Value.End
+	}
+	result.Write(d.data[position:])
+	return result.Bytes()
+}
+
+// Set sets the values matching the given selector, and returns the updated content - and whether it has been changed or not.
+// The new value is encoded according to the given kind - or if nil, according to the kind of the existing value,
+// as long as the new value is compatible with it - such as a number.
+// If no value matches the selector, it is created only if create is true and the selector is a simple path.
+func (d *Document) Set(selector Selector, value string, kind *Kind, create bool) ([]byte, bool, error) {
+	matches := selector.Select(d)
+	if len(matches) == 0 {
+		if !create {
+			return d.data, false, nil
+		}
+		return d.create(selector, value, kind)
+	}
+
+	var replacements []Replacement
+	for _, match := range matches {
+		targetKind := match.Kind
+		if kind != nil {
+			targetKind = *kind
+		}
+		if match.Kind == targetKind {
+			if current, err := d.String(match); err == nil && current == value {
+				continue
+			}
+		}
+		encoded, err := Encode(value, targetKind, kind == nil)
+		if err != nil {
+			return nil, false, fmt.Errorf("can't set value at path %s: %w", match.Path(), err)
+		}
+		if d.Raw(match) == encoded {
+			continue
+		}
+		replacements = append(replacements, Replacement{Value: match, Content: encoded})
+	}
+	if len(replacements) == 0 {
+		return d.data, false, nil
+	}
+	return d.Replace(replacements...), true, nil
+}
+
+// create inserts a new member in the closest existing parent object of the path defined by the given selector.
+func (d *Document) create(selector Selector, value string, kind *Kind) ([]byte, bool, error) {
+	keys, ok := selector.keys()
+	if !ok {
+		return nil, false, fmt.Errorf("can't create value for selector %s: only paths made of object keys are supported", selector)
+	}
+
+	parent := d.root
+	for len(keys) > 0 {
+		member, found := parent.Member(keys[0])
+		if !found {
+			break
+		}
+		parent, keys = member, keys[1:]
+	}
+	if parent.Kind != Object {
+		return nil, false, fmt.Errorf("can't create value for selector %s: %s is not an object but a %s", selector, parent.Path(), parent.Kind)
+	}
+
+	targetKind := String
+	if kind != nil {
+		targetKind = *kind
+	}
+	encoded, err := Encode(value, targetKind, false)
+	if err != nil {
+		return nil, false, fmt.Errorf("can't create value for selector %s: %w", selector, err)
+	}
+
+	f := d.formatting(parent)
+	for i := len(keys) - 1; i > 0; i-- {
+		encoded = f.object(keys[i], encoded, i)
+	}
+	member := Quote(keys[0]) + f.separator + encoded
+
+	if len(parent.Members) == 0 {
+		return d.Replace(Replacement{Value: parent, Content: f.object(keys[0], encoded, 0)}), true, nil
+	}
+	last := parent.Members[len(parent.Members)-1]
+	insertion := &Value{Start: last.End, End: last.End}
+	if f.multiLine {
+		return d.Replace(Replacement{Value: insertion, Content: ",\n" + f.indent + member}), true, nil
+	}
+	return d.Replace(Replacement{Value: insertion, Content: ", " + member}), true, nil
+}
+
+// formatting defines how new members should be formatted in an object.
+type formatting struct {
+	multiLine bool
+	indent    string
+	unit      string
+	separator string
+	parent    string
+}
+
+// formatting detects how the members of the given object are formatted - or should be formatted if it's empty.
+func (d *Document) formatting(object *Value) formatting {
+	f := formatting{
+		separator: ": ",
+		unit:      d.indentationUnit(),
+		parent:    d.lineIndentation(object.Start),
+	}
+	if len(object.Members) > 0 {
+		last := object.Members[len(object.Members)-1]
+		f.separator = string(d.data[last.KeyEnd:last.Start])
+		lineStart := bytes.LastIndexByte(d.data[:last.KeyStart], '\n') + 1
+		if prefix := d.data[lineStart:last.KeyStart]; len(bytes.TrimSpace(prefix)) == 0 {
+			f.multiLine = true
+			f.indent = string(prefix)
+		}
+	} else if len(f.unit) > 0 {
+		f.multiLine = true
+		f.indent = f.parent + f.unit
+	}
+	return f
+}
+
+// object returns the encoded representation of an object with a single member, at the given nested level.
+func (f formatting) object(key, encodedValue string, level int) string {
+	if !f.multiLine {
+		return "{" + Quote(key) + f.separator + encodedValue + "}"
+	}
+	indent := f.indent + strings.Repeat(f.unit, level)
+	closingIndent := f.parent
+	if level > 0 {
+		closingIndent = f.indent + strings.Repeat(f.unit, level-1)
+	}
+	return "{\n" + indent + Quote(key) + f.separator + encodedValue + "\n" + closingIndent + "}"
+}
+
+// indentationUnit returns the whitespaces used to indent the first indented line of the document.
+func (d *Document) indentationUnit() string {
+	for _, line := range bytes.Split(d.data, []byte("\n")) {
+		trimmed := bytes.TrimLeft(line, " \t")
+		if len(trimmed) > 0 && len(trimmed) < len(line) {
+			return string(line[:len(line)-len(trimmed)])
+		}
+	}
+	return ""
+}
+
+// lineIndentation returns the whitespaces at the beginning of the line containing the given position.
+func (d *Document) lineIndentation(pos int) string {
+	lineStart := bytes.LastIndexByte(d.data[:pos], '\n') + 1
+	line := d.data[lineStart:pos]
+	return string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+}
+
+// Encode returns the JSON representation of the given value for a specific kind.
+// If lenient is true and the value is not compatible with the kind - such as a non-numeric value for a number -
+// it is encoded as a string instead of returning an error.
+func Encode(value string, kind Kind, lenient bool) (string, error) {
+	var valid bool
+	switch kind {
+	case String:
+		return Quote(value), nil
+	case Number:
+		valid = json.Valid([]byte(value)) && isNumber(value)
+	case Bool:
+		valid = value == "true" || value == "false"
+	case Null:
+		valid = value == "null"
+	case Object, Array:
+		return "", fmt.Errorf("replacing an %s is not supported", kind)
+	}
+	if valid {
+		return value, nil
+	}
+	if lenient {
+		return Quote(value), nil
+	}
+	return "", fmt.Errorf("invalid %s value %q", kind, value)
+}
+
+// ParseKind parses the name of a kind of value, as returned by Kind.String.
+func ParseKind(name string) (Kind, error) {
+	for _, kind := range []Kind{String, Number, Bool, Null} {
+		if kind.String() == name {
+			return kind, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid type %q: expected one of string, number, bool or null", name)
+}
+
+// Quote returns the given value as a JSON string, without escaping the HTML characters.
+func Quote(value string) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value) // encoding a string never fails
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+func isNumber(value string) bool {
+	return len(value) > 0 && (value[0] == '-' || (value[0] >= '0' && value[0] <= '9'))
+}
+
+func isSimpleKey(key string) bool {
+	if len(key) == 0 {
+		return false
+	}
+	for i, c := range key {
+		if !(c == '_' || c == '-' || c == '$' || c == '@' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortReplacements(replacements []Replacement) []Replacement {
+	sorted := make([]Replacement, len(replacements))
+	copy(sorted, replacements)
+	for i := 1; i < len(sorted); i++ {
+		for j := i; j > 0 && sorted[j].Value.Start < sorted[j-1].Value.Start; j-- {
+			sorted[j], sorted[j-1] = sorted[j-1], sorted[j]
+		}
+	}
+	return sorted
+}
+
+type parser struct {
+	data []byte
+	pos  int
+}
+
+func (p *parser) parseValue(parent *Value) (*Value, error) {
+	if p.eof() {
+		return nil, errors.New("unexpected end of document, expected a value")
+	}
+	v := &Value{Start: p.pos, Parent: parent}
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		v.Kind = Object
+		if err := p.parseObject(v); err != nil {
+			return nil, err
+		}
+	case c == '[':
+		v.Kind = Array
+		if err := p.parseArray(v); err != nil {
+			return nil, err
+		}
+	case c == '"':
+		v.Kind = String
+		if err := p.skipString(); err != nil {
+			return nil, err
+		}
+	case c == '-' || (c >= '0' && c <= '9'):
+		v.Kind = Number
+		for !p.eof() && strings.IndexByte("+-.eE0123456789", p.data[p.pos]) >= 0 {
+			p.pos++
+		}
+		if number := p.data[v.Start:p.pos]; !json.Valid(number) {
+			p.pos = v.Start
+			return nil, fmt.Errorf("invalid number %s", number)
+		}
+	case bytes.HasPrefix(p.data[p.pos:], []byte("true")):
+		v.Kind = Bool
+		p.pos += len("true")
+	case bytes.HasPrefix(p.data[p.pos:], []byte("false")):
+		v.Kind = Bool
+		p.pos += len("false")
+	case bytes.HasPrefix(p.data[p.pos:], []byte("null")):
+		v.Kind = Null
+		p.pos += len("null")
+	default:
+		return nil, fmt.Errorf("unexpected character %q, expected a value", c)
+	}
+	v.End = p.pos
+	return v, nil
+}
+
+func (p *parser) parseObject(object *Value) error {
+	p.pos++
+	p.skipWhitespaces()
+	if !p.eof() && p.data[p.pos] == '}' {
+		p.pos++
+		return nil
+	}
+	for {
+		p.skipWhitespaces()
+		if p.eof() || p.data[p.pos] != '"' {
+			return errors.New("expected a quoted key")
+		}
+		keyStart := p.pos
+		if err := p.skipString(); err != nil {
+			return err
+		}
+		keyEnd := p.pos
+		var key string
+		if err := json.Unmarshal(p.data[keyStart:keyEnd], &key); err != nil {
+			return fmt.Errorf("invalid key %s: %w", p.data[keyStart:keyEnd], err)
+		}
+		p.skipWhitespaces()
+		if p.eof() || p.data[p.pos] != ':' {
+			return fmt.Errorf("expected ':' after key %s", p.data[keyStart:keyEnd])
+		}
+		p.pos++
+		p.skipWhitespaces()
+		member, err := p.parseValue(object)
+		if err != nil {
+			return err
+		}
+		member.Key, member.KeyStart, member.KeyEnd = key, keyStart, keyEnd
+		object.Members = append(object.Members, member)
+
+		p.skipWhitespaces()
+		if p.eof() {
+			return errors.New("unexpected end of document, expected ',' or '}'")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return nil
+		default:
+			return fmt.Errorf("unexpected character %q, expected ',' or '}'", p.data[p.pos])
+		}
+	}
+}
+
+func (p *parser) parseArray(array *Value) error {
+	p.pos++
+	p.skipWhitespaces()
+	if !p.eof() && p.data[p.pos] == ']' {
+		p.pos++
+		return nil
+	}
+	for {
+		p.skipWhitespaces()
+		element, err := p.parseValue(array)
+		if err != nil {
+			return err
+		}
+		element.Index = len(array.Members)
+		array.Members = append(array.Members, element)
+
+		p.skipWhitespaces()
+		if p.eof() {
+			return errors.New("unexpected end of document, expected ',' or ']'")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return nil
+		default:
+			return fmt.Errorf("unexpected character %q, expected ',' or ']'", p.data[p.pos])
+		}
+	}
+}
+
+func (p *parser) skipString() error {
+	for i := p.pos + 1; i < len(p.data); i++ {
+		switch p.data[i] {
+		case '\\':
+			i++
+		case '"':
+			p.pos = i + 1
+			return nil
+		case '\n':
+			return errors.New("unterminated string")
+		}
+	}
+	return errors.New("unterminated string")
+}
+
+func (p *parser) skipWhitespaces() {
+	for !p.eof() && strings.IndexByte(" \t\r\n", p.data[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *parser) location() (line, column int) {
+	pos := p.pos
+	if pos > len(p.data) {
+		pos = len(p.data)
+	}
+	line = bytes.Count(p.data[:pos], []byte("\n")) + 1
+	column = pos - bytes.LastIndexByte(p.data[:pos], '\n')
+	return line, column
+}
@@ -0,0 +1,362 @@
+package json
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const packageJSON = `{
+  "name": "my-app",
+  "version": "1.0.0",
+  "private": true,
+  "dependencies": {
+    "@org/ui": "^2.1.0",
+    "lodash": "4.17.21"
+  },
+  "packages": [
+    { "name": "first", "version": 1 },
+    { "name": "second", "version": 2, "tags": ["a.b", "c"] }
+  ]
+}
+`
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		selector         string
+		expectedPaths    []string
+		expectedRaws     []string
+		expectedErrorMsg string
+	}{
+		{
+			name:          "relative path",
+			selector:      "dependencies.lodash",
+			expectedPaths: []string{"$.dependencies.lodash"},
+			expectedRaws:  []string{`"4.17.21"`},
+		},
+		{
+			name:          "absolute path with quoted key",
+			selector:      "$.dependencies['@org/ui']",
+			expectedPaths: []string{`$.dependencies["@org/ui"]`},
+			expectedRaws:  []string{`"^2.1.0"`},
+		},
+		{
+			name:          "array index",
+			selector:      "$.packages[1].tags[0]",
+			expectedPaths: []string{"$.packages[1].tags[0]"},
+			expectedRaws:  []string{`"a.b"`},
+		},
+		{
+			name:          "negative array index",
+			selector:      "$.packages[-1].name",
+			expectedPaths: []string{"$.packages[1].name"},
+			expectedRaws:  []string{`"second"`},
+		},
+		{
+			name:          "wildcard",
+			selector:      "$.packages[*].version",
+			expectedPaths: []string{"$.packages[0].version", "$.packages[1].version"},
+			expectedRaws:  []string{"1", "2"},
+		},
+		{
+			name:          "object wildcard",
+			selector:      "dependencies.*",
+			expectedPaths: []string{`$.dependencies["@org/ui"]`, "$.dependencies.lodash"},
+			expectedRaws:  []string{`"^2.1.0"`, `"4.17.21"`},
+		},
+		{
+			name:          "recursive descent",
+			selector:      "$..version",
+			expectedPaths: []string{"$.version", "$.packages[0].version", "$.packages[1].version"},
+			expectedRaws:  []string{`"1.0.0"`, "1", "2"},
+		},
+		{
+			name:          "filter with string literal",
+			selector:      "$.packages[?(@.name == 'second')].version",
+			expectedPaths: []string{"$.packages[1].version"},
+			expectedRaws:  []string{"2"},
+		},
+		{
+			name:          "filter with number literal",
+			selector:      "$.packages[?(@.version!=2)].name",
+			expectedPaths: []string{"$.packages[0].name"},
+			expectedRaws:  []string{`"first"`},
+		},
+		{
+			name:          "existence filter",
+			selector:      "$.packages[?(@.tags)].name",
+			expectedPaths: []string{"$.packages[1].name"},
+			expectedRaws:  []string{`"second"`},
+		},
+		{
+			name:     "no match",
+			selector: "$.devDependencies.lodash",
+		},
+		{
+			name:             "invalid array index",
+			selector:         "$.packages[first]",
+			expectedErrorMsg: `invalid selector "$.packages[first]": invalid array index "first"`,
+		},
+		{
+			name:             "unterminated bracket",
+			selector:         "$.dependencies['lodash'",
+			expectedErrorMsg: `invalid selector "$.dependencies['lodash'": expected ']' at position 23`,
+		},
+		{
+			name:             "empty selector",
+			selector:         "$",
+			expectedErrorMsg: `invalid selector "$": empty selector`,
+		},
+	}
+
+	doc, err := Parse([]byte(packageJSON))
+	require.NoError(t, err)
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			selector, err := ParseSelector(test.selector)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+
+			var actualPaths, actualRaws []string
+			for _, v := range selector.Select(doc) {
+				actualPaths = append(actualPaths, v.Path())
+				actualRaws = append(actualRaws, doc.Raw(v))
+			}
+			assert.Equal(t, test.expectedPaths, actualPaths)
+			assert.Equal(t, test.expectedRaws, actualRaws)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		input            string
+		expectedErrorMsg string
+	}{
+		{
+			name:  "valid document",
+			input: packageJSON,
+		},
+		{
+			name:  "scalar document",
+			input: ` "value" `,
+		},
+		{
+			name:             "empty document",
+			input:            "",
+			expectedErrorMsg: "line 1, column 1: unexpected end of document, expected a value",
+		},
+		{
+			name:             "missing comma",
+			input:            "{\n  \"a\": 1\n  \"b\": 2\n}",
+			expectedErrorMsg: `line 3, column 3: unexpected character '"', expected ',' or '}'`,
+		},
+		{
+			name:             "trailing comma",
+			input:            "[1, 2,]",
+			expectedErrorMsg: `line 1, column 7: unexpected character ']', expected a value`,
+		},
+		{
+			name:             "invalid number",
+			input:            `{"a": 1.2.3}`,
+			expectedErrorMsg: "line 1, column 7: invalid number 1.2.3",
+		},
+		{
+			name:             "trailing content",
+			input:            `{} {}`,
+			expectedErrorMsg: `line 1, column 4: unexpected character '{' after the end of the document`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Parse([]byte(test.input))
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+	number, boolean := Number, Bool
+	tests := []struct {
+		name             string
+		input            string
+		selector         string
+		value            string
+		kind             *Kind
+		create           bool
+		expected         string
+		expectedUpdated  bool
+		expectedErrorMsg string
+	}{
+		{
+			name:            "update string and keep formatting",
+			input:           "{\n    \"name\" : \"app\",\n\t\"version\":\"1.0.0\"\n}",
+			selector:        "version",
+			value:           "1.1.0",
+			expected:        "{\n    \"name\" : \"app\",\n\t\"version\":\"1.1.0\"\n}",
+			expectedUpdated: true,
+		},
+		{
+			name:            "update multiple values",
+			input:           `[{"version": 1}, {"name": "x", "version": 2}]`,
+			selector:        "$[*].version",
+			value:           "3",
+			expected:        `[{"version": 3}, {"name": "x", "version": 3}]`,
+			expectedUpdated: true,
+		},
+		{
+			name:            "keep number and bool kinds",
+			input:           `{"replicas": 1, "enabled": true}`,
+			selector:        "$.replicas",
+			value:           "3",
+			expected:        `{"replicas": 3, "enabled": true}`,
+			expectedUpdated: true,
+		},
+		{
+			name:            "non-numeric value for a number",
+			input:           `{"replicas": 1}`,
+			selector:        "replicas",
+			value:           "three",
+			expected:        `{"replicas": "three"}`,
+			expectedUpdated: true,
+		},
+		{
+			name:            "forced kind",
+			input:           `{"enabled": "false"}`,
+			selector:        "enabled",
+			value:           "true",
+			kind:            &boolean,
+			expected:        `{"enabled": true}`,
+			expectedUpdated: true,
+		},
+		{
+			name:             "invalid value for forced kind",
+			input:            `{"replicas": 1}`,
+			selector:         "replicas",
+			value:            "three",
+			kind:             &number,
+			expectedErrorMsg: `can't set value at path $.replicas: invalid number value "three"`,
+		},
+		{
+			name:            "escaped characters",
+			input:           `{"url": "http://example.com"}`,
+			selector:        "url",
+			value:           `https://example.com/?a=1&b="2"`,
+			expected:        `{"url": "https://example.com/?a=1&b=\"2\""}`,
+			expectedUpdated: true,
+		},
+		{
+			name:     "same value",
+			input:    `{"name": "café", "count": 1}`,
+			selector: "name",
+			value:    "café",
+			expected: `{"name": "café", "count": 1}`,
+		},
+		{
+			name:     "missing value without create",
+			input:    `{"name": "app"}`,
+			selector: "version",
+			value:    "1.0.0",
+			expected: `{"name": "app"}`,
+		},
+		{
+			name:             "replace an object",
+			input:            `{"dependencies": {}}`,
+			selector:         "dependencies",
+			value:            "1.0.0",
+			expectedErrorMsg: "can't set value at path $.dependencies: replacing an object is not supported",
+		},
+		{
+			name:            "create value in multi-line object",
+			input:           "{\n  \"name\": \"app\",\n  \"dependencies\": {\n    \"lodash\": \"4.17.21\"\n  }\n}\n",
+			selector:        "dependencies.react",
+			value:           "18.2.0",
+			create:          true,
+			expected:        "{\n  \"name\": \"app\",\n  \"dependencies\": {\n    \"lodash\": \"4.17.21\",\n    \"react\": \"18.2.0\"\n  }\n}\n",
+			expectedUpdated: true,
+		},
+		{
+			name:            "create nested values in empty multi-line object",
+			input:           "{\n  \"name\": \"app\",\n  \"config\": {}\n}\n",
+			selector:        "config.server.port",
+			value:           "8080",
+			kind:            &number,
+			create:          true,
+			expected:        "{\n  \"name\": \"app\",\n  \"config\": {\n    \"server\": {\n      \"port\": 8080\n    }\n  }\n}\n",
+			expectedUpdated: true,
+		},
+		{
+			name:            "create nested values in multi-line object",
+			input:           "{\n\t\"name\": \"app\"\n}",
+			selector:        "config.server.port",
+			value:           "8080",
+			create:          true,
+			expected:        "{\n\t\"name\": \"app\",\n\t\"config\": {\n\t\t\"server\": {\n\t\t\t\"port\": \"8080\"\n\t\t}\n\t}\n}",
+			expectedUpdated: true,
+		},
+		{
+			name:            "create value in single-line object",
+			input:           `{"name":"app"}`,
+			selector:        "version",
+			value:           "1.0.0",
+			create:          true,
+			expected:        `{"name":"app", "version":"1.0.0"}`,
+			expectedUpdated: true,
+		},
+		{
+			name:             "create value with wildcard",
+			input:            `{"name": "app"}`,
+			selector:         "$.*.version",
+			value:            "1.0.0",
+			create:           true,
+			expectedErrorMsg: "can't create value for selector $.*.version: only paths made of object keys are supported",
+		},
+		{
+			name:             "create value in a string",
+			input:            `{"name": "app"}`,
+			selector:         "name.first",
+			value:            "1.0.0",
+			create:           true,
+			expectedErrorMsg: "can't create value for selector name.first: $.name is not an object but a string",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			doc, err := Parse([]byte(test.input))
+			require.NoError(t, err)
+			selector, err := ParseSelector(test.selector)
+			require.NoError(t, err)
+
+			actual, updated, err := doc.Set(selector, test.value, test.kind, test.create)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedUpdated, updated)
+			assert.Equal(t, test.expected, string(actual))
+		})
+	}
+}
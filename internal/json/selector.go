@@ -0,0 +1,362 @@
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	keyStep stepKind = iota
+	indexStep
+	wildcardStep
+	descendantsStep
+	filterStep
+)
+
+type step struct {
+	kind   stepKind
+	key    string
+	index  int
+	filter *filter
+}
+
+// filter is a JSONPath filter expression, such as `?(@.name == 'value')` - or `?(@.name)` to test for existence.
+type filter struct {
+	path     Selector
+	operator string
+	literal  string
+}
+
+// Selector is a JSONPath-style expression used to select values in a JSON document. It supports:
+//   - object keys, such as `$.dependencies.lodash`, or `$['my.key']` for keys with special characters
+//   - array indexes, such as `$.files[0]` - or `$.files[-1]` for the last element
+//   - wildcards, such as `$.dependencies.*` or `$.files[*]`
+//   - recursive descent, such as `$..version`
+//   - filters, such as `$.packages[?(@.name == 'my-package')].version`
+//
+// The leading `$` is optional: `dependencies.lodash` is the same as `$.dependencies.lodash`.
+type Selector struct {
+	raw   string
+	steps []step
+}
+
+// ParseSelector parses the given JSONPath-style expression.
+func ParseSelector(str string) (Selector, error) {
+	s := &selectorParser{str: strings.TrimSpace(str)}
+	steps, err := s.parse()
+	if err != nil {
+		return Selector{}, fmt.Errorf("invalid selector %q: %w", str, err)
+	}
+	return Selector{raw: str, steps: steps}, nil
+}
+
+// String returns the string representation of the selector.
+func (s Selector) String() string {
+	return s.raw
+}
+
+// Select returns the values of the given document matching the selector - in the order in which they are defined.
+func (s Selector) Select(d *Document) []*Value {
+	return s.selectFrom(d, d.root)
+}
+
+func (s Selector) selectFrom(d *Document, root *Value) []*Value {
+	values := []*Value{root}
+	for _, st := range s.steps {
+		var (
+			next []*Value
+			seen = make(map[*Value]bool)
+		)
+		for _, v := range values {
+			for _, selected := range st.apply(d, v) {
+				if !seen[selected] {
+					seen[selected] = true
+					next = append(next, selected)
+				}
+			}
+		}
+		values = next
+	}
+	return sortValues(values)
+}
+
+// keys returns the list of keys of the selector, if it's only made of object keys.
+func (s Selector) keys() ([]string, bool) {
+	keys := make([]string, 0, len(s.steps))
+	for _, st := range s.steps {
+		if st.kind != keyStep {
+			return nil, false
+		}
+		keys = append(keys, st.key)
+	}
+	return keys, len(keys) > 0
+}
+
+func (st step) apply(d *Document, v *Value) []*Value {
+	switch st.kind {
+	case keyStep:
+		if member, found := v.Member(st.key); found {
+			return []*Value{member}
+		}
+	case indexStep:
+		if v.Kind != Array {
+			return nil
+		}
+		index := st.index
+		if index < 0 {
+			index += len(v.Members)
+		}
+		if index >= 0 && index < len(v.Members) {
+			return []*Value{v.Members[index]}
+		}
+	case wildcardStep:
+		return v.Members
+	case descendantsStep:
+		values := []*Value{v}
+		for _, member := range v.Members {
+			values = append(values, st.apply(d, member)...)
+		}
+		return values
+	case filterStep:
+		var values []*Value
+		for _, member := range v.Members {
+			if st.filter.match(d, member) {
+				values = append(values, member)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func (f *filter) match(d *Document, v *Value) bool {
+	for _, selected := range f.path.selectFrom(d, v) {
+		if len(f.operator) == 0 {
+			return true
+		}
+		str, err := d.String(selected)
+		if err != nil {
+			continue
+		}
+		if (str == f.literal) == (f.operator == "==") {
+			return true
+		}
+	}
+	return false
+}
+
+func sortValues(values []*Value) []*Value {
+	for i := 1; i < len(values); i++ {
+		for j := i; j > 0 && values[j].Start < values[j-1].Start; j-- {
+			values[j], values[j-1] = values[j-1], values[j]
+		}
+	}
+	return values
+}
+
+type selectorParser struct {
+	str string
+	pos int
+}
+
+func (p *selectorParser) parse() ([]step, error) {
+	var steps []step
+	if strings.HasPrefix(p.str, "$") {
+		p.pos++
+	} else if len(p.str) > 0 && p.str[0] != '.' && p.str[0] != '[' {
+		// a relative path such as `dependencies.lodash`
+		key := p.readKey()
+		if len(key) == 0 {
+			return nil, fmt.Errorf("unexpected character %q at position %d", p.str[p.pos], p.pos)
+		}
+		steps = append(steps, p.keyOrWildcard(key))
+	}
+
+	for p.pos < len(p.str) {
+		switch {
+		case strings.HasPrefix(p.str[p.pos:], ".."):
+			p.pos += 2
+			steps = append(steps, step{kind: descendantsStep})
+			if p.pos < len(p.str) && p.str[p.pos] == '[' {
+				continue
+			}
+			key := p.readKey()
+			if len(key) == 0 {
+				return nil, fmt.Errorf("missing key after '..' at position %d", p.pos)
+			}
+			steps = append(steps, p.keyOrWildcard(key))
+		case p.str[p.pos] == '.':
+			p.pos++
+			key := p.readKey()
+			if len(key) == 0 {
+				return nil, fmt.Errorf("missing key after '.' at position %d", p.pos)
+			}
+			steps = append(steps, p.keyOrWildcard(key))
+		case p.str[p.pos] == '[':
+			st, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, st)
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", p.str[p.pos], p.pos)
+		}
+	}
+
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("empty selector")
+	}
+	return steps, nil
+}
+
+func (p *selectorParser) keyOrWildcard(key string) step {
+	if key == "*" {
+		return step{kind: wildcardStep}
+	}
+	return step{kind: keyStep, key: key}
+}
+
+func (p *selectorParser) readKey() string {
+	start := p.pos
+	for p.pos < len(p.str) && !strings.ContainsRune(".[]()=!<> ", rune(p.str[p.pos])) {
+		p.pos++
+	}
+	return p.str[start:p.pos]
+}
+
+func (p *selectorParser) parseBracket() (step, error) {
+	p.pos++
+	p.skipSpaces()
+	if p.pos >= len(p.str) {
+		return step{}, fmt.Errorf("unterminated bracket")
+	}
+
+	var st step
+	switch c := p.str[p.pos]; {
+	case c == '*':
+		p.pos++
+		st = step{kind: wildcardStep}
+	case c == '\'' || c == '"':
+		key, err := p.readQuoted()
+		if err != nil {
+			return step{}, err
+		}
+		st = step{kind: keyStep, key: key}
+	case c == '?':
+		f, err := p.parseFilter()
+		if err != nil {
+			return step{}, err
+		}
+		st = step{kind: filterStep, filter: f}
+	default:
+		end := strings.IndexByte(p.str[p.pos:], ']')
+		if end < 0 {
+			return step{}, fmt.Errorf("unterminated bracket")
+		}
+		index, err := strconv.Atoi(strings.TrimSpace(p.str[p.pos : p.pos+end]))
+		if err != nil {
+			return step{}, fmt.Errorf("invalid array index %q", p.str[p.pos:p.pos+end])
+		}
+		p.pos += end
+		st = step{kind: indexStep, index: index}
+	}
+
+	p.skipSpaces()
+	if p.pos >= len(p.str) || p.str[p.pos] != ']' {
+		return step{}, fmt.Errorf("expected ']' at position %d", p.pos)
+	}
+	p.pos++
+	return st, nil
+}
+
+func (p *selectorParser) parseFilter() (*filter, error) {
+	p.pos++ // skip the '?'
+	if !strings.HasPrefix(p.str[p.pos:], "(") {
+		return nil, fmt.Errorf("expected '(' at position %d", p.pos)
+	}
+	p.pos++
+	p.skipSpaces()
+	if !strings.HasPrefix(p.str[p.pos:], "@") {
+		return nil, fmt.Errorf("expected '@' at position %d", p.pos)
+	}
+	p.pos++
+
+	// the relative path ends with the operator or the closing parenthesis
+	start := p.pos
+	for p.pos < len(p.str) && !strings.ContainsRune("=!) ", rune(p.str[p.pos])) {
+		if p.str[p.pos] == '[' {
+			end := strings.IndexByte(p.str[p.pos:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated bracket")
+			}
+			p.pos += end
+		}
+		p.pos++
+	}
+	f := &filter{path: Selector{raw: "@" + p.str[start:p.pos]}}
+	if start < p.pos {
+		var err error
+		sub := &selectorParser{str: p.str[start:p.pos]}
+		if f.path.steps, err = sub.parse(); err != nil {
+			return nil, err
+		}
+	}
+
+	p.skipSpaces()
+	for _, operator := range []string{"==", "!="} {
+		if strings.HasPrefix(p.str[p.pos:], operator) {
+			f.operator = operator
+			p.pos += len(operator)
+			p.skipSpaces()
+			literal, err := p.readLiteral()
+			if err != nil {
+				return nil, err
+			}
+			f.literal = literal
+			break
+		}
+	}
+
+	p.skipSpaces()
+	if !strings.HasPrefix(p.str[p.pos:], ")") {
+		return nil, fmt.Errorf("expected ')' at position %d", p.pos)
+	}
+	p.pos++
+	return f, nil
+}
+
+func (p *selectorParser) readLiteral() (string, error) {
+	if p.pos < len(p.str) && (p.str[p.pos] == '\'' || p.str[p.pos] == '"') {
+		return p.readQuoted()
+	}
+	start := p.pos
+	for p.pos < len(p.str) && !strings.ContainsRune(") ", rune(p.str[p.pos])) {
+		p.pos++
+	}
+	literal := p.str[start:p.pos]
+	if !json.Valid([]byte(literal)) {
+		return "", fmt.Errorf("invalid literal %q", literal)
+	}
+	return literal, nil
+}
+
+func (p *selectorParser) readQuoted() (string, error) {
+	quote := p.str[p.pos]
+	end := strings.IndexByte(p.str[p.pos+1:], quote)
+	if end < 0 {
+		return "", fmt.Errorf("unterminated quoted string at position %d", p.pos)
+	}
+	value := p.str[p.pos+1 : p.pos+1+end]
+	p.pos += end + 2
+	return value, nil
+}
+
+func (p *selectorParser) skipSpaces() {
+	for p.pos < len(p.str) && p.str[p.pos] == ' ' {
+		p.pos++
+	}
+}
@@ -0,0 +1,335 @@
+// Package registry provides a minimal client for the OCI distribution API,
+// to list the tags and resolve the digests of the artifacts - such as container images or Helm charts - hosted in registries.
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/ybbus/httpretry"
+)
+
+const (
+	dockerHubHost    = "docker.io"
+	dockerHubAPIHost = "registry-1.docker.io"
+	// dockerHubConfigKey is the key used by docker to store the Docker Hub credentials
+	dockerHubConfigKey = "https://index.docker.io/v1/"
+)
+
+var (
+	// manifestMediaTypes are the media types of the manifests that can be retrieved - the indexes first, so that multi-platform images get the digest of their index
+	manifestMediaTypes = []string{
+		"application/vnd.oci.image.index.v1+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json",
+		"application/vnd.oci.image.manifest.v1+json",
+		"application/vnd.docker.distribution.manifest.v2+json",
+	}
+
+	// Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+	challengeParamRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+	// </v2/org/app/tags/list?last=1.2.3&n=1000>; rel="next"
+	nextLinkRegexp = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+)
+
+// CredentialsFunc returns the username and password to access the given registry host - or empty strings if there are none.
+type CredentialsFunc func(host string) (username, password string)
+
+// Client is a client for the OCI distribution API. It handles both the basic and the token authentication.
+// It can be safely used by multiple goroutines at the same time.
+type Client struct {
+	HTTPClient  *http.Client
+	Credentials CredentialsFunc
+
+	// bearer tokens, by registry host and scope
+	tokensMutex sync.Mutex
+	tokens      map[string]string
+}
+
+// NewClient returns a new client, which uses the credentials defined in the docker config file.
+func NewClient() *Client {
+	return &Client{
+		HTTPClient:  httpretry.NewDefaultClient(),
+		Credentials: DockerConfigCredentials,
+	}
+}
+
+// ParseRepository splits the name of an artifact - such as registry.example.com/org/app - into the registry host and the repository.
+// Names without a registry host are hosted on the Docker Hub - such as nginx for docker.io/library/nginx.
+func ParseRepository(name string) (host, repository string) {
+	name = strings.TrimPrefix(name, "oci://")
+	host, repository, found := strings.Cut(name, "/")
+	if !found || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		host, repository = dockerHubHost, name
+	}
+	if host == dockerHubHost && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+	return host, repository
+}
+
+// Tags returns all the tags of the given repository.
+func (c *Client) Tags(ctx context.Context, host, repository string) ([]string, error) {
+	var (
+		tags []string
+		path = fmt.Sprintf("/v2/%s/tags/list?n=1000", repository)
+	)
+	for len(path) > 0 {
+		resp, err := c.do(ctx, http.MethodGet, host, repository, path, nil)
+		if err != nil {
+			return nil, err
+		}
+		var list struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the tags of %s/%s: %w", host, repository, err)
+		}
+		tags = append(tags, list.Tags...)
+
+		path = ""
+		if matches := nextLinkRegexp.FindStringSubmatch(resp.Header.Get("Link")); matches != nil {
+			next, err := url.Parse(matches[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid link to the next tags of %s/%s: %w", host, repository, err)
+			}
+			path = next.RequestURI()
+		}
+	}
+	return tags, nil
+}
+
+// Digest returns the digest of the manifest referenced by the given tag - such as sha256:...
+func (c *Client) Digest(ctx context.Context, host, repository, tag string) (string, error) {
+	header := http.Header{"Accept": []string{strings.Join(manifestMediaTypes, ", ")}}
+	path := fmt.Sprintf("/v2/%s/manifests/%s", repository, tag)
+
+	resp, err := c.do(ctx, http.MethodHead, host, repository, path, header)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if digest := resp.Header.Get("Docker-Content-Digest"); len(digest) > 0 {
+		return digest, nil
+	}
+
+	// the digest header is optional: let's compute the digest from the manifest itself
+	resp, err = c.do(ctx, http.MethodGet, host, repository, path, header)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	hash := sha256.New()
+	if _, err = io.Copy(hash, resp.Body); err != nil {
+		return "", fmt.Errorf("failed to read the manifest of %s/%s:%s: %w", host, repository, tag, err)
+	}
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
+
+// do sends a request to the registry - authenticating it if the registry requires it - and returns the successful response.
+func (c *Client) do(ctx context.Context, method, host, repository, path string, header http.Header) (*http.Response, error) {
+	scope := fmt.Sprintf("repository:%s:pull", repository)
+	tokenKey := host + " " + scope
+
+	c.tokensMutex.Lock()
+	authorization := c.tokens[tokenKey]
+	c.tokensMutex.Unlock()
+
+	resp, err := c.send(ctx, method, host, path, header, authorization)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		authorization, err = c.authorize(ctx, host, scope, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to authenticate to %s: %w", host, err)
+		}
+
+		c.tokensMutex.Lock()
+		if c.tokens == nil {
+			c.tokens = make(map[string]string)
+		}
+		c.tokens[tokenKey] = authorization
+		c.tokensMutex.Unlock()
+
+		resp, err = c.send(ctx, method, host, path, header, authorization)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to %s %s%s: %s", method, host, path, resp.Status)
+	}
+	return resp, nil
+}
+
+func (c *Client) send(ctx context.Context, method, host, path string, header http.Header, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, baseURL(host)+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %s%s: %w", host, path, err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if len(authorization) > 0 {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s %s%s: %w", method, host, path, err)
+	}
+	return resp, nil
+}
+
+// authorize returns the value of the authorization header for the given challenge - returned by the registry in the WWW-Authenticate header.
+func (c *Client) authorize(ctx context.Context, host, scope, challenge string) (string, error) {
+	var username, password string
+	if c.Credentials != nil {
+		username, password = c.Credentials(host)
+	}
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+
+	scheme, _, _ := strings.Cut(challenge, " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if len(username) == 0 && len(password) == 0 {
+			return "", errors.New("no credentials available")
+		}
+		return basic, nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+
+	params := make(map[string]string)
+	for _, matches := range challengeParamRegexp.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(matches[1])] = matches[2]
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || len(params["realm"]) == 0 {
+		return "", fmt.Errorf("invalid authentication challenge %q: missing or invalid realm", challenge)
+	}
+	query := realm.Query()
+	if service := params["service"]; len(service) > 0 {
+		query.Set("service", service)
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	if len(username) > 0 || len(password) > 0 {
+		req.Header.Set("Authorization", basic)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to request token: %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode token: %w", err)
+	}
+	if len(token.Token) == 0 {
+		token.Token = token.AccessToken
+	}
+	if len(token.Token) == 0 {
+		return "", errors.New("failed to request token: empty token")
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// baseURL returns the base URL of the API of the given registry host.
+// Local registries - such as localhost:5000 - are accessed over plain HTTP, like docker does.
+func baseURL(host string) string {
+	if host == dockerHubHost {
+		host = dockerHubAPIHost
+	}
+	hostname := host
+	if h, _, found := strings.Cut(host, ":"); found && !strings.HasPrefix(host, "[") {
+		hostname = h
+	}
+	if hostname == "localhost" || hostname == "127.0.0.1" || strings.HasPrefix(host, "[::1]") {
+		return "http://" + host
+	}
+	return "https://" + host
+}
+
+// DockerConfigCredentials returns the credentials for the given registry host, defined in the docker config file:
+// $DOCKER_CONFIG/config.json or ~/.docker/config.json. Credentials helpers are not supported.
+func DockerConfigCredentials(host string) (username, password string) {
+	configDir := os.Getenv("DOCKER_CONFIG")
+	if len(configDir) == 0 {
+		homeDir, err := homedir.Dir()
+		if err != nil {
+			return "", ""
+		}
+		configDir = filepath.Join(homeDir, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, "config.json"))
+	if err != nil {
+		return "", ""
+	}
+	return credentialsFromDockerConfig(data, host)
+}
+
+func credentialsFromDockerConfig(data []byte, host string) (username, password string) {
+	var config struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return "", ""
+	}
+
+	keys := []string{host, "https://" + host, "http://" + host}
+	if host == dockerHubHost {
+		keys = append([]string{dockerHubConfigKey}, keys...)
+	}
+	for _, key := range keys {
+		auth, found := config.Auths[key]
+		if !found {
+			continue
+		}
+		if len(auth.Auth) > 0 {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return "", ""
+			}
+			username, password, _ = strings.Cut(string(decoded), ":")
+			return username, password
+		}
+		return auth.Username, auth.Password
+	}
+	return "", ""
+}
@@ -0,0 +1,220 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dailymotion-oss/octopilot/internal/registry/registrytest"
+)
+
+func TestParseRepository(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name               string
+		expectedHost       string
+		expectedRepository string
+	}{
+		{
+			name:               "nginx",
+			expectedHost:       "docker.io",
+			expectedRepository: "library/nginx",
+		},
+		{
+			name:               "org/app",
+			expectedHost:       "docker.io",
+			expectedRepository: "org/app",
+		},
+		{
+			name:               "docker.io/nginx",
+			expectedHost:       "docker.io",
+			expectedRepository: "library/nginx",
+		},
+		{
+			name:               "ghcr.io/org/app",
+			expectedHost:       "ghcr.io",
+			expectedRepository: "org/app",
+		},
+		{
+			name:               "localhost/app",
+			expectedHost:       "localhost",
+			expectedRepository: "app",
+		},
+		{
+			name:               "oci://registry.example.com:5000/org/charts/app",
+			expectedHost:       "registry.example.com:5000",
+			expectedRepository: "org/charts/app",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			host, repository := ParseRepository(test.name)
+			assert.Equal(t, test.expectedHost, host)
+			assert.Equal(t, test.expectedRepository, repository)
+		})
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "https://registry-1.docker.io", baseURL("docker.io"))
+	assert.Equal(t, "https://ghcr.io", baseURL("ghcr.io"))
+	assert.Equal(t, "http://localhost:5000", baseURL("localhost:5000"))
+	assert.Equal(t, "http://127.0.0.1:5000", baseURL("127.0.0.1:5000"))
+	assert.Equal(t, "http://[::1]:5000", baseURL("[::1]:5000"))
+}
+
+func TestClientTags(t *testing.T) {
+	t.Parallel()
+
+	server := registrytest.NewServer(map[string]map[string]string{
+		"org/app": {
+			"1.0.0": "{}",
+			"1.1.0": "{}",
+			"2.0.0": "{}",
+		},
+	}, "user", "secret", 2)
+	t.Cleanup(server.Close)
+
+	client := &Client{
+		HTTPClient:  http.DefaultClient,
+		Credentials: func(_ string) (string, string) { return "user", "secret" },
+	}
+	tags, err := client.Tags(context.Background(), server.Host, "org/app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.0.0", "1.1.0", "2.0.0"}, tags)
+	// 1 unauthorized request, then 2 pages
+	assert.Equal(t, 3, server.Requests())
+
+	// the token is reused
+	_, err = client.Tags(context.Background(), server.Host, "org/app")
+	require.NoError(t, err)
+	assert.Equal(t, 5, server.Requests())
+}
+
+func TestClientDigest(t *testing.T) {
+	t.Parallel()
+
+	const manifest = `{"schemaVersion": 2}`
+	server := registrytest.NewServer(map[string]map[string]string{
+		"org/app": {"1.0.0": manifest},
+	}, "", "", 0)
+	t.Cleanup(server.Close)
+
+	client := &Client{HTTPClient: http.DefaultClient}
+	digest, err := client.Digest(context.Background(), server.Host, "org/app", "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, registrytest.Digest(manifest), digest)
+
+	_, err = client.Digest(context.Background(), server.Host, "org/app", "2.0.0")
+	require.EqualError(t, err, fmt.Sprintf("failed to HEAD %s/v2/org/app/manifests/2.0.0: 404 Not Found", server.Host))
+}
+
+func TestClientDigestWithoutHeaderAndBasicAuth(t *testing.T) {
+	t.Parallel()
+
+	const manifest = `{"schemaVersion": 2}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/v2/org/app/manifests/1.0.0" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, manifest)
+		}
+	}))
+	t.Cleanup(server.Close)
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	client := &Client{
+		HTTPClient:  http.DefaultClient,
+		Credentials: func(_ string) (string, string) { return "user", "secret" },
+	}
+	digest, err := client.Digest(context.Background(), host, "org/app", "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, registrytest.Digest(manifest), digest)
+
+	client = &Client{HTTPClient: http.DefaultClient}
+	_, err = client.Digest(context.Background(), host, "org/app", "1.0.0")
+	require.EqualError(t, err, fmt.Sprintf("failed to authenticate to %s: no credentials available", host))
+}
+
+func TestCredentialsFromDockerConfig(t *testing.T) {
+	t.Parallel()
+
+	auth := base64.StdEncoding.EncodeToString([]byte("hub-user:hub-secret"))
+	config := fmt.Sprintf(`{
+		"auths": {
+			"https://index.docker.io/v1/": {"auth": %q},
+			"ghcr.io": {"username": "gh-user", "password": "gh-secret"},
+			"https://registry.example.com": {"auth": "invalid base64"}
+		}
+	}`, auth)
+
+	tests := []struct {
+		host             string
+		expectedUsername string
+		expectedPassword string
+	}{
+		{
+			host:             "docker.io",
+			expectedUsername: "hub-user",
+			expectedPassword: "hub-secret",
+		},
+		{
+			host:             "ghcr.io",
+			expectedUsername: "gh-user",
+			expectedPassword: "gh-secret",
+		},
+		{
+			host: "registry.example.com",
+		},
+		{
+			host: "quay.io",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.host, func(t *testing.T) {
+			t.Parallel()
+			username, password := credentialsFromDockerConfig([]byte(config), test.host)
+			assert.Equal(t, test.expectedUsername, username)
+			assert.Equal(t, test.expectedPassword, password)
+		})
+	}
+}
+
+func TestDockerConfigCredentials(t *testing.T) {
+	server := registrytest.NewServer(nil, "user", "secret", 0)
+	t.Cleanup(server.Close)
+
+	configDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.json"), []byte(server.DockerConfig()), 0600))
+	t.Setenv("DOCKER_CONFIG", configDir)
+
+	username, password := DockerConfigCredentials(server.Host)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "secret", password)
+
+	username, password = DockerConfigCredentials("ghcr.io")
+	assert.Empty(t, username)
+	assert.Empty(t, password)
+}
@@ -0,0 +1,137 @@
+// Package registrytest provides an in-process registry stand-in for the tests,
+// which implements the tags and manifests endpoints of the OCI distribution API - with token authentication.
+package registrytest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const token = "registrytest-token"
+
+// Server is a registry stand-in, serving the manifests of its repositories - by tag - to the clients authenticated with a bearer token.
+// The token is delivered by its /token endpoint - to the clients using the right credentials, if any.
+type Server struct {
+	*httptest.Server
+	// Host is the host of the registry - such as 127.0.0.1:12345
+	Host string
+
+	username     string
+	password     string
+	repositories map[string]map[string]string
+	pageSize     int
+	requests     atomic.Int32
+}
+
+// NewServer starts a new registry stand-in serving the given manifests - by repository and tag.
+// If the username and password are not empty, they are required to get a token.
+// If the page size is positive, the tags are paginated.
+func NewServer(repositories map[string]map[string]string, username, password string, pageSize int) *Server {
+	s := &Server{
+		username:     username,
+		password:     password,
+		repositories: repositories,
+		pageSize:     pageSize,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.Host = strings.TrimPrefix(s.Server.URL, "http://")
+	return s
+}
+
+// Requests returns the number of requests received by the API - excluding the token requests.
+func (s *Server) Requests() int {
+	return int(s.requests.Load())
+}
+
+// DockerConfig returns the content of a docker config file holding the credentials of the registry.
+func (s *Server) DockerConfig() string {
+	auth := base64.StdEncoding.EncodeToString([]byte(s.username + ":" + s.password))
+	return fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, s.Host, auth)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		s.handleToken(w, r)
+		return
+	}
+
+	s.requests.Add(1)
+	if r.Header.Get("Authorization") != "Bearer "+token {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registrytest"`, s.Server.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+	switch {
+	case strings.HasSuffix(path, "/tags/list"):
+		s.handleTags(w, r, strings.TrimSuffix(path, "/tags/list"))
+	case strings.Contains(path, "/manifests/"):
+		i := strings.LastIndex(path, "/manifests/")
+		s.handleManifest(w, r, path[:i], path[i+len("/manifests/"):])
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if len(s.username) > 0 || len(s.password) > 0 {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != s.username || password != s.password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{"token": token})
+}
+
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request, repository string) {
+	manifests, found := s.repositories[repository]
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	tags := make([]string, 0, len(manifests))
+	for tag := range manifests {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	if last := r.URL.Query().Get("last"); len(last) > 0 {
+		tags = tags[sort.SearchStrings(tags, last)+1:]
+	}
+	if s.pageSize > 0 && len(tags) > s.pageSize {
+		tags = tags[:s.pageSize]
+		next := url.Values{"n": []string{strconv.Itoa(s.pageSize)}, "last": []string{tags[len(tags)-1]}}
+		w.Header().Set("Link", fmt.Sprintf(`</v2/%s/tags/list?%s>; rel="next"`, repository, next.Encode()))
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": repository, "tags": tags})
+}
+
+func (s *Server) handleManifest(w http.ResponseWriter, r *http.Request, repository, reference string) {
+	manifest, found := s.repositories[repository][reference]
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+	w.Header().Set("Docker-Content-Digest", Digest(manifest))
+	if r.Method == http.MethodHead {
+		return
+	}
+	fmt.Fprint(w, manifest)
+}
+
+// Digest returns the digest of the given manifest.
+func Digest(manifest string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(manifest)))
+}
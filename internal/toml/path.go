@@ -0,0 +1,135 @@
+package toml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Segment is a single element of a path: either a key in a table, or an index in an array.
+type Segment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// Path is the location of a value in a TOML document, such as `package.version` or `bin[0].name`.
+type Path []Segment
+
+// ParsePath parses the string representation of a path: a dot-separated list of keys - which can be quoted -
+// each one optionally followed by one or more array indexes. For example: `tool.poetry.dependencies."my.lib".version` or `bin[0].name`.
+func ParsePath(str string) (Path, error) {
+	var (
+		path Path
+		pos  int
+	)
+	str = strings.TrimPrefix(strings.TrimSpace(str), ".")
+	if len(str) == 0 {
+		return nil, fmt.Errorf("invalid path %q: empty path", str)
+	}
+	for pos < len(str) {
+		var key string
+		switch str[pos] {
+		case '"', '\'':
+			end := strings.IndexByte(str[pos+1:], str[pos])
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unterminated quoted key", str)
+			}
+			key = str[pos+1 : pos+1+end]
+			pos += end + 2
+		default:
+			end := strings.IndexAny(str[pos:], ".[")
+			if end < 0 {
+				end = len(str) - pos
+			}
+			key = str[pos : pos+end]
+			if len(key) == 0 {
+				return nil, fmt.Errorf("invalid path %q: empty key at position %d", str, pos)
+			}
+			pos += end
+		}
+		path = append(path, Segment{Key: key})
+
+		for pos < len(str) && str[pos] == '[' {
+			end := strings.IndexByte(str[pos:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unterminated array index", str)
+			}
+			index, err := strconv.Atoi(str[pos+1 : pos+end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid path %q: invalid array index %q", str, str[pos+1:pos+end])
+			}
+			path = append(path, Segment{Index: index, IsIndex: true})
+			pos += end + 1
+		}
+
+		if pos < len(str) {
+			if str[pos] != '.' {
+				return nil, fmt.Errorf("invalid path %q: unexpected character %q at position %d", str, str[pos], pos)
+			}
+			pos++
+			if pos == len(str) {
+				return nil, fmt.Errorf("invalid path %q: trailing dot", str)
+			}
+		}
+	}
+	return path, nil
+}
+
+// String returns the string representation of the path.
+func (p Path) String() string {
+	var sb strings.Builder
+	for i, segment := range p {
+		if segment.IsIndex {
+			fmt.Fprintf(&sb, "[%d]", segment.Index)
+			continue
+		}
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(formatKey(segment.Key))
+	}
+	return sb.String()
+}
+
+// Equal returns true if both paths are the same.
+func (p Path) Equal(other Path) bool {
+	if len(p) != len(other) {
+		return false
+	}
+	for i := range p {
+		if p[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// HasPrefix returns true if the path starts with the given prefix.
+func (p Path) HasPrefix(prefix Path) bool {
+	return len(p) >= len(prefix) && p[:len(prefix)].Equal(prefix)
+}
+
+// child returns a new path, with the given segment appended.
+func (p Path) child(segment Segment) Path {
+	child := make(Path, len(p), len(p)+1)
+	copy(child, p)
+	return append(child, segment)
+}
+
+// formatKey returns the representation of a key, quoted if needed.
+func formatKey(key string) string {
+	if len(key) == 0 {
+		return `""`
+	}
+	for _, c := range key {
+		if !isBareKeyChar(byte(c)) || c > 127 {
+			return quoteString(key)
+		}
+	}
+	return key
+}
+
+func isBareKeyChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '-'
+}
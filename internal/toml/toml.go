@@ -0,0 +1,704 @@
+// Package toml provides functions to work with TOML content, while preserving the formatting and the comments.
+package toml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind is the kind of a TOML value.
+type Kind int
+
+// definition of the different kinds of TOML values
+const (
+	BasicString Kind = iota
+	LiteralString
+	MultiLineBasicString
+	MultiLineLiteralString
+	Integer
+	Float
+	Bool
+	DateTime
+	Array
+	InlineTable
+)
+
+var (
+	integerRegexp             = regexp.MustCompile(`^([+-]?(0|[1-9](_?[0-9])*)|0x[0-9A-Fa-f](_?[0-9A-Fa-f])*|0o[0-7](_?[0-7])*|0b[01](_?[01])*)$`)
+	floatRegexp               = regexp.MustCompile(`^([+-]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?|[+-]?(inf|nan))$`)
+	lineEndingBackslashRegexp = regexp.MustCompile(`\\[ \t]*\r?\n\s*`)
+	dateTimeRegexp            = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}([Tt ]\d{2}:\d{2}(:\d{2})?(\.\d+)?([Zz]|[+-]\d{2}:\d{2})?)?|\d{2}:\d{2}(:\d{2})?(\.\d+)?)$`)
+)
+
+// Value is a value defined in a TOML document, with its position in the document's content.
+type Value struct {
+	Path  Path
+	Kind  Kind
+	Start int
+	End   int
+}
+
+// table is a table defined by a header - or the root table - with the position of the end of its last line.
+type table struct {
+	path    Path
+	lastEnd int
+	indent  string
+}
+
+// Document is a parsed TOML document, which keeps track of the position of each value,
+// so that they can be updated in place - without changing anything else in the document.
+type Document struct {
+	data   []byte
+	values []Value
+	tables []*table
+}
+
+// Parse parses the given TOML content.
+func Parse(data []byte) (*Document, error) {
+	p := &parser{
+		data:        data,
+		doc:         &Document{data: data},
+		arrayTables: make(map[string]int),
+	}
+	if err := p.parse(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", p.line(), err)
+	}
+	return p.doc, nil
+}
+
+// Bytes returns the content of the document.
+func (d *Document) Bytes() []byte {
+	return d.data
+}
+
+// Values returns all the values defined in the document, in the order in which they are defined.
+func (d *Document) Values() []Value {
+	return d.values
+}
+
+// Find returns the value located at the given path.
+func (d *Document) Find(path Path) (Value, bool) {
+	for _, v := range d.values {
+		if v.Path.Equal(path) {
+			return v, true
+		}
+	}
+	return Value{}, false
+}
+
+// Raw returns the raw representation of the given value, as written in the document.
+func (d *Document) Raw(v Value) string {
+	return string(d.data[v.Start:v.End])
+}
+
+// String returns the content of the given value: unquoted for the strings, raw for the other kinds of values.
+func (d *Document) String(v Value) (string, error) {
+	raw := d.Raw(v)
+	switch v.Kind {
+	case BasicString:
+		return unquoteBasicString(raw)
+	case MultiLineBasicString:
+		content := strings.TrimPrefix(strings.TrimPrefix(raw[3:len(raw)-3], "\r"), "\n")
+		return unescape(lineEndingBackslashRegexp.ReplaceAllString(content, ""))
+	case LiteralString:
+		return raw[1 : len(raw)-1], nil
+	case MultiLineLiteralString:
+		return strings.TrimPrefix(raw[3:len(raw)-3], "\n"), nil
+	default:
+		return raw, nil
+	}
+}
+
+// Set sets the value located at the given path, and returns the updated content - and whether it has been changed or not.
+// An existing value keeps its kind - such as a literal string or an integer - as long as the new value is compatible with it.
+// If there is no value at the given path, it is created as a string only if create is true.
+func (d *Document) Set(path Path, value string, create bool) ([]byte, bool, error) {
+	if v, found := d.Find(path); found {
+		encoded, err := encodeValue(v.Kind, value)
+		if err != nil {
+			return nil, false, fmt.Errorf("can't set value at path %s: %w", path, err)
+		}
+		if d.Raw(v) == encoded {
+			return d.data, false, nil
+		}
+		return splice(d.data, v.Start, v.End, encoded), true, nil
+	}
+
+	if !create {
+		return d.data, false, nil
+	}
+	return d.create(path, value)
+}
+
+// create inserts a new key/value in the table which is the closest parent of the given path.
+func (d *Document) create(path Path, value string) ([]byte, bool, error) {
+	var (
+		parentTable  *table
+		parentInline *Value
+		parentLen    = -1
+	)
+	for _, t := range d.tables {
+		if path.HasPrefix(t.path) && len(t.path) > parentLen && len(t.path) < len(path) {
+			parentTable, parentLen = t, len(t.path)
+		}
+	}
+	for i, v := range d.values {
+		if v.Kind == InlineTable && path.HasPrefix(v.Path) && len(v.Path) > parentLen && len(v.Path) < len(path) {
+			parentInline, parentLen = &d.values[i], len(v.Path)
+		}
+	}
+
+	keys := path[parentLen:]
+	for _, segment := range keys {
+		if segment.IsIndex {
+			return nil, false, fmt.Errorf("can't create value at path %s: creating array elements is not supported", path)
+		}
+	}
+	keyValue := fmt.Sprintf("%s = %s", keys.String(), quoteString(value))
+
+	if parentInline != nil {
+		pos := parentInline.End - 1
+		for pos > parentInline.Start && (d.data[pos-1] == ' ' || d.data[pos-1] == '\t') {
+			pos--
+		}
+		if d.data[pos-1] == '{' {
+			return splice(d.data, parentInline.Start, parentInline.End, fmt.Sprintf("{ %s }", keyValue)), true, nil
+		}
+		return splice(d.data, pos, pos, ", "+keyValue), true, nil
+	}
+
+	if parentTable.lastEnd < 0 {
+		// the root table has no keys: the new key is inserted before the first table header - but after the comments heading the document
+		pos := leadingCommentsEnd(d.data)
+		if pos > 0 && d.data[pos-1] != '\n' {
+			return splice(d.data, pos, pos, "\n"+keyValue+"\n"), true, nil
+		}
+		return splice(d.data, pos, pos, keyValue+"\n"), true, nil
+	}
+	return splice(d.data, parentTable.lastEnd, parentTable.lastEnd, "\n"+parentTable.indent+keyValue), true, nil
+}
+
+// leadingCommentsEnd returns the position of the end of the block of comments heading the given content - up to the first blank line,
+// or 0 if the content doesn't start with comments. It must only be used if the root table has no keys.
+func leadingCommentsEnd(data []byte) int {
+	var end, pos int
+	for pos < len(data) {
+		lineEnd := len(data)
+		if i := bytes.IndexByte(data[pos:], '\n'); i >= 0 {
+			lineEnd = pos + i + 1
+		}
+		line := strings.TrimSpace(string(data[pos:lineEnd]))
+		switch {
+		case strings.HasPrefix(line, "#"):
+			end = lineEnd
+		case len(line) > 0 || end > 0:
+			return end
+		}
+		pos = lineEnd
+	}
+	return end
+}
+
+type parser struct {
+	data         []byte
+	pos          int
+	doc          *Document
+	currentTable *table
+	arrayTables  map[string]int
+}
+
+func (p *parser) parse() error {
+	p.currentTable = &table{path: Path{}, lastEnd: -1}
+	p.doc.tables = append(p.doc.tables, p.currentTable)
+
+	for {
+		p.skipSpaces()
+		if p.eof() {
+			return nil
+		}
+		switch c := p.data[p.pos]; {
+		case c == '\n' || c == '\r' || c == '#':
+			if _, err := p.expectEndOfLine(); err != nil {
+				return err
+			}
+		case c == '[':
+			if err := p.parseTableHeader(); err != nil {
+				return err
+			}
+		default:
+			lineStart := bytes.LastIndexByte(p.data[:p.pos], '\n') + 1
+			indent := string(p.data[lineStart:p.pos])
+			keys, err := p.parseKey()
+			if err != nil {
+				return err
+			}
+			p.skipSpaces()
+			if p.eof() || p.data[p.pos] != '=' {
+				return fmt.Errorf("expected '=' after key %s", keys)
+			}
+			p.pos++
+			p.skipSpaces()
+			if err = p.parseValue(append(p.currentTable.path[:len(p.currentTable.path):len(p.currentTable.path)], keys...)); err != nil {
+				return err
+			}
+			lineEnd, err := p.expectEndOfLine()
+			if err != nil {
+				return err
+			}
+			p.currentTable.lastEnd = lineEnd
+			p.currentTable.indent = indent
+		}
+	}
+}
+
+func (p *parser) parseTableHeader() error {
+	isArray := strings.HasPrefix(string(p.data[p.pos:]), "[[")
+	if isArray {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+	p.skipSpaces()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpaces()
+	closing := "]"
+	if isArray {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(string(p.data[p.pos:]), closing) {
+		return fmt.Errorf("expected %q to close table header %s", closing, keys)
+	}
+	p.pos += len(closing)
+
+	// resolve the path of the table, taking into account the current index of the arrays of tables
+	path := Path{}
+	for i, key := range keys {
+		path = path.child(key)
+		count, isArrayTable := p.arrayTables[path.String()]
+		switch {
+		case i == len(keys)-1 && isArray:
+			p.arrayTables[path.String()] = count + 1
+			path = path.child(Segment{Index: count, IsIndex: true})
+		case isArrayTable:
+			path = path.child(Segment{Index: count - 1, IsIndex: true})
+		}
+	}
+
+	lineEnd, err := p.expectEndOfLine()
+	if err != nil {
+		return err
+	}
+	p.currentTable = &table{path: path, lastEnd: lineEnd}
+	p.doc.tables = append(p.doc.tables, p.currentTable)
+	return nil
+}
+
+// parseKey parses a - possibly dotted - key.
+func (p *parser) parseKey() (Path, error) {
+	var keys Path
+	for {
+		key, err := p.parseSimpleKey()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, Segment{Key: key})
+		p.skipSpaces()
+		if p.eof() || p.data[p.pos] != '.' {
+			return keys, nil
+		}
+		p.pos++
+		p.skipSpaces()
+	}
+}
+
+func (p *parser) parseSimpleKey() (string, error) {
+	if p.eof() {
+		return "", errors.New("unexpected end of file, expected a key")
+	}
+	start := p.pos
+	switch p.data[p.pos] {
+	case '"':
+		end, err := p.findBasicStringEnd(start)
+		if err != nil {
+			return "", err
+		}
+		p.pos = end
+		return unquoteBasicString(string(p.data[start:end]))
+	case '\'':
+		end := bytes.IndexAny(p.data[start+1:], "'\n")
+		if end < 0 || p.data[start+1+end] != '\'' {
+			return "", errors.New("unterminated literal key")
+		}
+		p.pos = start + end + 2
+		return string(p.data[start+1 : start+1+end]), nil
+	default:
+		for !p.eof() && isBareKeyChar(p.data[p.pos]) {
+			p.pos++
+		}
+		if p.pos == start {
+			return "", fmt.Errorf("invalid character %q, expected a key", p.data[p.pos])
+		}
+		return string(p.data[start:p.pos]), nil
+	}
+}
+
+func (p *parser) parseValue(path Path) error {
+	if p.eof() {
+		return fmt.Errorf("missing value for key %s", path)
+	}
+	start := p.pos
+	switch p.data[p.pos] {
+	case '"':
+		kind := BasicString
+		var (
+			end int
+			err error
+		)
+		if bytes.HasPrefix(p.data[p.pos:], []byte(`"""`)) {
+			kind = MultiLineBasicString
+			end, err = p.findMultiLineStringEnd(start, `"""`)
+		} else {
+			end, err = p.findBasicStringEnd(start)
+		}
+		if err != nil {
+			return err
+		}
+		p.pos = end
+		p.addValue(path, kind, start)
+	case '\'':
+		if bytes.HasPrefix(p.data[p.pos:], []byte(`'''`)) {
+			end, err := p.findMultiLineStringEnd(start, `'''`)
+			if err != nil {
+				return err
+			}
+			p.pos = end
+			p.addValue(path, MultiLineLiteralString, start)
+			return nil
+		}
+		end := bytes.IndexAny(p.data[start+1:], "'\n")
+		if end < 0 || p.data[start+1+end] != '\'' {
+			return errors.New("unterminated literal string")
+		}
+		p.pos = start + end + 2
+		p.addValue(path, LiteralString, start)
+	case '[':
+		return p.parseArray(path)
+	case '{':
+		return p.parseInlineTable(path)
+	default:
+		end := p.pos
+		for end < len(p.data) && !strings.ContainsRune(",]}#\r\n", rune(p.data[end])) {
+			end++
+		}
+		raw := strings.TrimRight(string(p.data[start:end]), " \t")
+		kind, ok := scalarKind(raw)
+		if !ok {
+			return fmt.Errorf("invalid value %q for key %s", raw, path)
+		}
+		p.pos = start + len(raw)
+		p.addValue(path, kind, start)
+	}
+	return nil
+}
+
+func (p *parser) parseArray(path Path) error {
+	index := p.addValue(path, Array, p.pos)
+	p.pos++
+	for i := 0; ; i++ {
+		if err := p.skipBlankLines(); err != nil {
+			return err
+		}
+		if p.data[p.pos] == ']' {
+			break
+		}
+		if err := p.parseValue(path.child(Segment{Index: i, IsIndex: true})); err != nil {
+			return err
+		}
+		if err := p.skipBlankLines(); err != nil {
+			return err
+		}
+		if p.data[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		if p.data[p.pos] != ']' {
+			return fmt.Errorf("invalid character %q in array %s, expected ',' or ']'", p.data[p.pos], path)
+		}
+		break
+	}
+	p.pos++
+	p.doc.values[index].End = p.pos
+	return nil
+}
+
+func (p *parser) parseInlineTable(path Path) error {
+	index := p.addValue(path, InlineTable, p.pos)
+	p.pos++
+	for {
+		if err := p.skipBlankLines(); err != nil {
+			return err
+		}
+		if p.data[p.pos] == '}' {
+			break
+		}
+		keys, err := p.parseKey()
+		if err != nil {
+			return err
+		}
+		p.skipSpaces()
+		if p.eof() || p.data[p.pos] != '=' {
+			return fmt.Errorf("expected '=' after key %s", keys)
+		}
+		p.pos++
+		p.skipSpaces()
+		if err = p.parseValue(append(path[:len(path):len(path)], keys...)); err != nil {
+			return err
+		}
+		if err = p.skipBlankLines(); err != nil {
+			return err
+		}
+		if p.data[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		if p.data[p.pos] != '}' {
+			return fmt.Errorf("invalid character %q in inline table %s, expected ',' or '}'", p.data[p.pos], path)
+		}
+		break
+	}
+	p.pos++
+	p.doc.values[index].End = p.pos
+	return nil
+}
+
+// addValue records a new value, ending at the current position, and returns its index.
+func (p *parser) addValue(path Path, kind Kind, start int) int {
+	p.doc.values = append(p.doc.values, Value{
+		Path:  path,
+		Kind:  kind,
+		Start: start,
+		End:   p.pos,
+	})
+	return len(p.doc.values) - 1
+}
+
+func (p *parser) findBasicStringEnd(start int) (int, error) {
+	for i := start + 1; i < len(p.data); i++ {
+		switch p.data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		case '\n':
+			return 0, errors.New("unterminated string")
+		}
+	}
+	return 0, errors.New("unterminated string")
+}
+
+func (p *parser) findMultiLineStringEnd(start int, delimiter string) (int, error) {
+	for i := start + len(delimiter); i < len(p.data); i++ {
+		if delimiter[0] == '"' && p.data[i] == '\\' {
+			i++
+			continue
+		}
+		if bytes.HasPrefix(p.data[i:], []byte(delimiter)) {
+			end := i + len(delimiter)
+			// up to 2 additional quotes are allowed right before the closing delimiter
+			for extra := 0; extra < 2 && end < len(p.data) && p.data[end] == delimiter[0]; extra++ {
+				end++
+			}
+			return end, nil
+		}
+	}
+	return 0, errors.New("unterminated multi-line string")
+}
+
+func (p *parser) skipSpaces() {
+	for !p.eof() && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlankLines skips the whitespaces, line breaks and comments - as allowed in multi-line arrays.
+func (p *parser) skipBlankLines() error {
+	for !p.eof() {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		case '#':
+			for !p.eof() && p.data[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return nil
+		}
+	}
+	return errors.New("unexpected end of file")
+}
+
+// expectEndOfLine skips the trailing whitespaces and comment until the end of the line,
+// and returns the position of the end of the line - before the line break.
+func (p *parser) expectEndOfLine() (int, error) {
+	p.skipSpaces()
+	if !p.eof() && p.data[p.pos] == '#' {
+		for !p.eof() && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+			p.pos++
+		}
+	}
+	end := p.pos
+	switch {
+	case p.eof():
+	case p.data[p.pos] == '\n':
+		p.pos++
+	case p.data[p.pos] == '\r' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '\n':
+		p.pos += 2
+	default:
+		return 0, fmt.Errorf("unexpected character %q, expected the end of the line", p.data[p.pos])
+	}
+	return end, nil
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *parser) line() int {
+	pos := p.pos
+	if pos > len(p.data) {
+		pos = len(p.data)
+	}
+	return bytes.Count(p.data[:pos], []byte("\n")) + 1
+}
+
+func scalarKind(raw string) (Kind, bool) {
+	switch {
+	case raw == "true" || raw == "false":
+		return Bool, true
+	case integerRegexp.MatchString(raw):
+		return Integer, true
+	case floatRegexp.MatchString(raw):
+		return Float, true
+	case dateTimeRegexp.MatchString(raw):
+		return DateTime, true
+	default:
+		return 0, false
+	}
+}
+
+// encodeValue returns the representation of the given value for a specific kind.
+// If the value is not compatible with the kind - such as a non-numeric value for an integer - it is encoded as a string.
+func encodeValue(kind Kind, value string) (string, error) {
+	switch kind {
+	case Array, InlineTable:
+		return "", errors.New("replacing an array or an inline table is not supported")
+	case LiteralString:
+		if !strings.ContainsAny(value, "'\r\n") {
+			return "'" + value + "'", nil
+		}
+	case Integer, Float, Bool, DateTime:
+		if valueKind, ok := scalarKind(value); ok && (valueKind == kind || (kind == Float && valueKind == Integer)) {
+			return value, nil
+		}
+	case BasicString, MultiLineBasicString, MultiLineLiteralString:
+	}
+	return quoteString(value), nil
+}
+
+// quoteString returns the given value as a TOML basic string.
+func quoteString(value string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, c := range value {
+		switch c {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, c)
+			} else {
+				sb.WriteRune(c)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+func unquoteBasicString(raw string) (string, error) {
+	return unescape(raw[1 : len(raw)-1])
+}
+
+// unescape returns the content of a basic string, with its escape sequences replaced.
+func unescape(content string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(content); i++ {
+		if content[i] != '\\' {
+			sb.WriteByte(content[i])
+			continue
+		}
+		if i+1 >= len(content) {
+			return "", fmt.Errorf("invalid escape sequence at the end of string %q", content)
+		}
+		i++
+		switch c := content[i]; c {
+		case 'b':
+			sb.WriteByte('\b')
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'e':
+			sb.WriteByte(0x1b)
+		case '"', '\\':
+			sb.WriteByte(c)
+		case 'u', 'U':
+			size := 4
+			if c == 'U' {
+				size = 8
+			}
+			if i+size >= len(content) {
+				return "", fmt.Errorf("invalid unicode escape sequence in string %q", content)
+			}
+			code, err := strconv.ParseUint(content[i+1:i+1+size], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape sequence in string %q: %w", content, err)
+			}
+			sb.WriteRune(rune(code))
+			i += size
+		default:
+			return "", fmt.Errorf("invalid escape sequence \\%c in string %q", c, content)
+		}
+	}
+	return sb.String(), nil
+}
+
+func splice(data []byte, start, end int, replacement string) []byte {
+	result := make([]byte, 0, len(data)-(end-start)+len(replacement))
+	result = append(result, data[:start]...)
+	result = append(result, replacement...)
+	return append(result, data[end:]...)
+}
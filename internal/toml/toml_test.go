@@ -0,0 +1,419 @@
+package toml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePath(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		input            string
+		expected         Path
+		expectedErrorMsg string
+	}{
+		{
+			name:     "single key",
+			input:    "version",
+			expected: Path{{Key: "version"}},
+		},
+		{
+			name:     "dotted keys with leading dot",
+			input:    ".package.version",
+			expected: Path{{Key: "package"}, {Key: "version"}},
+		},
+		{
+			name:     "quoted keys",
+			input:    `tool.poetry.dependencies."my.lib".'version'`,
+			expected: Path{{Key: "tool"}, {Key: "poetry"}, {Key: "dependencies"}, {Key: "my.lib"}, {Key: "version"}},
+		},
+		{
+			name:     "array indexes",
+			input:    "bin[0].name",
+			expected: Path{{Key: "bin"}, {Index: 0, IsIndex: true}, {Key: "name"}},
+		},
+		{
+			name:     "nested array indexes",
+			input:    "matrix[1][2]",
+			expected: Path{{Key: "matrix"}, {Index: 1, IsIndex: true}, {Index: 2, IsIndex: true}},
+		},
+		{
+			name:             "empty path",
+			input:            "",
+			expectedErrorMsg: `invalid path "": empty path`,
+		},
+		{
+			name:             "trailing dot",
+			input:            "package.",
+			expectedErrorMsg: `invalid path "package.": trailing dot`,
+		},
+		{
+			name:             "invalid index",
+			input:            "bin[first]",
+			expectedErrorMsg: `invalid path "bin[first]": invalid array index "first"`,
+		},
+		{
+			name:             "unterminated quoted key",
+			input:            `tool."my.lib`,
+			expectedErrorMsg: `invalid path "tool.\"my.lib": unterminated quoted key`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := ParsePath(test.input)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		input            string
+		path             string
+		expectedKind     Kind
+		expectedRaw      string
+		expectedString   string
+		expectedErrorMsg string
+	}{
+		{
+			name:           "root basic string",
+			input:          `version = "1.2.3" # the version`,
+			path:           "version",
+			expectedKind:   BasicString,
+			expectedRaw:    `"1.2.3"`,
+			expectedString: "1.2.3",
+		},
+		{
+			name:           "escaped basic string",
+			input:          `msg = "a \"quoted\"\tvalue \u00e9"`,
+			path:           "msg",
+			expectedKind:   BasicString,
+			expectedRaw:    `"a \"quoted\"\tvalue \u00e9"`,
+			expectedString: "a \"quoted\"\tvalue é",
+		},
+		{
+			name:           "literal string in table",
+			input:          "[package]\nname = 'my-app'\nversion = '1.0.0'\n",
+			path:           "package.version",
+			expectedKind:   LiteralString,
+			expectedRaw:    `'1.0.0'`,
+			expectedString: "1.0.0",
+		},
+		{
+			name:           "multi-line basic string",
+			input:          "text = \"\"\"\nfirst \\\n  second \"quoted\"\"\"\"\n",
+			path:           "text",
+			expectedKind:   MultiLineBasicString,
+			expectedRaw:    "\"\"\"\nfirst \\\n  second \"quoted\"\"\"\"",
+			expectedString: `first second "quoted"`,
+		},
+		{
+			name:           "multi-line literal string",
+			input:          "text = '''\nC:\\path\n'''",
+			path:           "text",
+			expectedKind:   MultiLineLiteralString,
+			expectedRaw:    "'''\nC:\\path\n'''",
+			expectedString: "C:\\path\n",
+		},
+		{
+			name:         "integer with dotted key",
+			input:        "[server]\nhttp.port = 8_080",
+			path:         "server.http.port",
+			expectedKind: Integer,
+			expectedRaw:  "8_080",
+		},
+		{
+			name:         "date time",
+			input:        "released = 1979-05-27 07:32:00Z\n",
+			path:         "released",
+			expectedKind: DateTime,
+			expectedRaw:  "1979-05-27 07:32:00Z",
+		},
+		{
+			name:         "quoted table header and key",
+			input:        "[dependencies.\"my.lib\"]\n\"the version\" = true",
+			path:         `dependencies."my.lib"."the version"`,
+			expectedKind: Bool,
+			expectedRaw:  "true",
+		},
+		{
+			name:           "value in multi-line array",
+			input:          "tags = [\n  \"a\", # first\n  \"b\",\n]\n",
+			path:           "tags[1]",
+			expectedKind:   BasicString,
+			expectedRaw:    `"b"`,
+			expectedString: "b",
+		},
+		{
+			name:           "value in inline table",
+			input:          `serde = { version = "1.0", features = ["derive"] }`,
+			path:           "serde.version",
+			expectedKind:   BasicString,
+			expectedRaw:    `"1.0"`,
+			expectedString: "1.0",
+		},
+		{
+			name:         "array in inline table",
+			input:        `serde = { version = "1.0", features = ["derive"] }`,
+			path:         "serde.features",
+			expectedKind: Array,
+			expectedRaw:  `["derive"]`,
+		},
+		{
+			name:           "array of tables",
+			input:          "[[bin]]\nname = \"first\"\n\n[[bin]]\nname = \"second\"\n[bin.meta]\nkey = 1.5",
+			path:           "bin[1].name",
+			expectedKind:   BasicString,
+			expectedRaw:    `"second"`,
+			expectedString: "second",
+		},
+		{
+			name:         "sub-table of array of tables",
+			input:        "[[bin]]\nname = \"first\"\n\n[[bin]]\nname = \"second\"\n[bin.meta]\nkey = 1.5",
+			path:         "bin[1].meta.key",
+			expectedKind: Float,
+			expectedRaw:  "1.5",
+		},
+		{
+			name:             "missing equal sign",
+			input:            "[package]\nversion \"1.0.0\"",
+			expectedErrorMsg: "line 2: expected '=' after key version",
+		},
+		{
+			name:             "invalid value",
+			input:            "version = 1.0.0",
+			expectedErrorMsg: `line 1: invalid value "1.0.0" for key version`,
+		},
+		{
+			name:             "unterminated string",
+			input:            "version = \"1.0.0\nname = \"app\"",
+			expectedErrorMsg: "line 1: unterminated string",
+		},
+		{
+			name:             "unterminated table header",
+			input:            "[package\nversion = \"1.0.0\"",
+			expectedErrorMsg: `line 1: expected "]" to close table header package`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			doc, err := Parse([]byte(test.input))
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+
+			path, err := ParsePath(test.path)
+			require.NoError(t, err)
+			value, found := doc.Find(path)
+			require.True(t, found, "value not found at path %s", path)
+			assert.Equal(t, test.expectedKind, value.Kind)
+			assert.Equal(t, test.expectedRaw, doc.Raw(value))
+			if len(test.expectedString) > 0 {
+				actual, err := doc.String(value)
+				require.NoError(t, err)
+				assert.Equal(t, test.expectedString, actual)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		input            string
+		path             string
+		value            string
+		create           bool
+		expected         string
+		expectedUpdated  bool
+		expectedErrorMsg string
+	}{
+		{
+			name:            "update basic string and keep comments",
+			input:           "# the package\n[package]\nname = \"app\"\nversion = \"1.0.0\" # current version\n",
+			path:            "package.version",
+			value:           "1.1.0",
+			expected:        "# the package\n[package]\nname = \"app\"\nversion = \"1.1.0\" # current version\n",
+			expectedUpdated: true,
+		},
+		{
+			name:            "keep literal string",
+			input:           "version = '1.0.0'",
+			path:            "version",
+			value:           "1.1.0",
+			expected:        "version = '1.1.0'",
+			expectedUpdated: true,
+		},
+		{
+			name:            "literal string with quote is converted to basic string",
+			input:           "name = 'app'",
+			path:            "name",
+			value:           "it's",
+			expected:        `name = "it's"`,
+			expectedUpdated: true,
+		},
+		{
+			name:            "keep integer",
+			input:           "replicas = 1",
+			path:            "replicas",
+			value:           "3",
+			expected:        "replicas = 3",
+			expectedUpdated: true,
+		},
+		{
+			name:            "non-numeric value for integer",
+			input:           "replicas = 1",
+			path:            "replicas",
+			value:           "three",
+			expected:        `replicas = "three"`,
+			expectedUpdated: true,
+		},
+		{
+			name:            "value in inline table",
+			input:           `serde = { version = "1.0", features = ["derive"] }`,
+			path:            "serde.version",
+			value:           "1.1",
+			expected:        `serde = { version = "1.1", features = ["derive"] }`,
+			expectedUpdated: true,
+		},
+		{
+			name:            "value in array of tables",
+			input:           "[[bin]]\nname = \"first\"\n\n[[bin]]\nname = \"second\"\n",
+			path:            "bin[1].name",
+			value:           "third",
+			expected:        "[[bin]]\nname = \"first\"\n\n[[bin]]\nname = \"third\"\n",
+			expectedUpdated: true,
+		},
+		{
+			name:     "same value",
+			input:    `version = "1.0.0"`,
+			path:     "version",
+			value:    "1.0.0",
+			expected: `version = "1.0.0"`,
+		},
+		{
+			name:     "missing value without create",
+			input:    `name = "app"`,
+			path:     "version",
+			value:    "1.0.0",
+			expected: `name = "app"`,
+		},
+		{
+			name:            "create value at the end of the table",
+			input:           "[package]\n  name = \"app\" # the name\n\n[dependencies]\nserde = \"1.0\"\n",
+			path:            "package.version",
+			value:           "1.0.0",
+			create:          true,
+			expected:        "[package]\n  name = \"app\" # the name\n  version = \"1.0.0\"\n\n[dependencies]\nserde = \"1.0\"\n",
+			expectedUpdated: true,
+		},
+		{
+			name:            "create value with dotted key",
+			input:           "[tool]\nname = \"app\"\n",
+			path:            "tool.poetry.version",
+			value:           "1.0.0",
+			create:          true,
+			expected:        "[tool]\nname = \"app\"\npoetry.version = \"1.0.0\"\n",
+			expectedUpdated: true,
+		},
+		{
+			name:            "create value in empty root table",
+			input:           "[package]\nname = \"app\"\n",
+			path:            "version",
+			value:           "1.0.0",
+			create:          true,
+			expected:        "version = \"1.0.0\"\n[package]\nname = \"app\"\n",
+			expectedUpdated: true,
+		},
+		{
+			name:            "create value in empty root table after the leading comments",
+			input:           "# the app\n# see https://example.com\n\n# the package\n[package]\nname = \"app\"\n",
+			path:            "version",
+			value:           "1.0.0",
+			create:          true,
+			expected:        "# the app\n# see https://example.com\nversion = \"1.0.0\"\n\n# the package\n[package]\nname = \"app\"\n",
+			expectedUpdated: true,
+		},
+		{
+			name:            "create value in document holding only comments",
+			input:           "# the app",
+			path:            "version",
+			value:           "1.0.0",
+			create:          true,
+			expected:        "# the app\nversion = \"1.0.0\"\n",
+			expectedUpdated: true,
+		},
+		{
+			name:            "create value in inline table",
+			input:           `serde = { features = ["derive"] }`,
+			path:            "serde.version",
+			value:           "1.0",
+			create:          true,
+			expected:        `serde = { features = ["derive"], version = "1.0" }`,
+			expectedUpdated: true,
+		},
+		{
+			name:            "create value in empty inline table",
+			input:           `serde = {}`,
+			path:            "serde.version",
+			value:           "1.0",
+			create:          true,
+			expected:        `serde = { version = "1.0" }`,
+			expectedUpdated: true,
+		},
+		{
+			name:             "replace array",
+			input:            `tags = ["a", "b"]`,
+			path:             "tags",
+			value:            "c",
+			expectedErrorMsg: "can't set value at path tags: replacing an array or an inline table is not supported",
+		},
+		{
+			name:             "create array element",
+			input:            `tags = ["a", "b"]`,
+			path:             "tags[2]",
+			value:            "c",
+			create:           true,
+			expectedErrorMsg: "can't create value at path tags[2]: creating array elements is not supported",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			doc, err := Parse([]byte(test.input))
+			require.NoError(t, err)
+			path, err := ParsePath(test.path)
+			require.NoError(t, err)
+
+			actual, updated, err := doc.Set(path, test.value, test.create)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedUpdated, updated)
+			assert.Equal(t, test.expected, string(actual))
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Package tpl provides what is needed to execute the Go templates - such as the commit messages or the files rendered by the template updater:
+// the template functions, and the data of the repository being updated, shared through the context.
+package tpl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/google/go-github/v57/github"
+	stripmd "github.com/writeas/go-strip-markdown"
+)
+
+// GitHubClientFunc returns the GitHub client used by the template functions that need one - such as githubRelease.
+type GitHubClientFunc func(ctx context.Context) (*github.Client, error)
+
+type contextKey struct{}
+
+// New returns a new template with the given name, and all the template functions: the sprig functions and our custom functions.
+// The readFile function reads the files relative to the given repository path.
+func New(name, repoPath string, githubClient GitHubClientFunc) *template.Template {
+	return template.
+		New(name).
+		Funcs(sprig.TxtFuncMap()).
+		Funcs(template.FuncMap{
+			"readFile":            readFileFunc(repoPath),
+			"githubRelease":       gitHubReleaseFunc(githubClient),
+			"expandGithubLinks":   expandGitHubLinksToMarkdownFunc(),
+			"extractMarkdownURLs": extractMarkdownURLsFunc(),
+			"md2txt":              stripmd.Strip,
+		})
+}
+
+// NewContext returns a new context holding the given template data - such as the repository being updated.
+func NewContext(ctx context.Context, data map[string]interface{}) context.Context {
+	return context.WithValue(ctx, contextKey{}, data)
+}
+
+// DataFromContext returns the template data held by the given context - or an empty map if there is none.
+func DataFromContext(ctx context.Context) map[string]interface{} {
+	data, ok := ctx.Value(contextKey{}).(map[string]interface{})
+	if !ok || data == nil {
+		return map[string]interface{}{}
+	}
+	return data
+}
+
+func readFileFunc(repoPath string) func(string) string {
+	return func(path string) string {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(repoPath, path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			panic(fmt.Sprintf("failed to readFile %s: %v", path, err))
+		}
+		return string(content)
+	}
+}
+
+func gitHubReleaseFunc(githubClient GitHubClientFunc) func(string) string {
+	return func(releaseID string) string {
+		elems := strings.SplitN(releaseID, "/", 3)
+		if len(elems) < 3 {
+			panic("invalid syntax for the commitBodyFromRelease flag - expected 3 parts got " + fmt.Sprint(len(elems)))
+		}
+		owner, repo, tag := elems[0], elems[1], elems[2]
+
+		ctx := context.Background()
+		ghClient, err := githubClient(ctx)
+		if err != nil {
+			panic(fmt.Sprintf("failed to create github client: %s", err))
+		}
+		release, _, err := ghClient.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
+		if err != nil {
+			panic(fmt.Sprintf("failed to retrieve GitHub Release for %s/%s %s: %v", owner, repo, tag, err))
+		}
+		return fmt.Sprintf("# **%s** release [%s](%s)\n\nReleased %s\n\n%s",
+			repo, tag, release.GetHTMLURL(), release.GetPublishedAt().Format("on Monday January 2, 2006 at 15:04 (UTC)"), release.GetBody(),
+		)
+	}
+}
+
+func expandGitHubLinksToMarkdownFunc() func(string, string) string {
+	linkReg := regexp.MustCompile(`([^[]|\s)(#([0-9]+))`)
+	return func(fullRepoName, input string) string {
+		return linkReg.ReplaceAllString(input, fmt.Sprintf("$1[$2](https://github.com/%s/issues/$3)", fullRepoName))
+	}
+}
+
+func extractMarkdownURLsFunc() func(string) string {
+	linkReg := regexp.MustCompile(`\[(.*?)\][\[\(](.*?)[\]\)]`)
+	return func(input string) string {
+		return linkReg.ReplaceAllString(input, "$2")
+	}
+}
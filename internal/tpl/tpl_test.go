@@ -1,12 +1,13 @@
-package repository
+package tpl
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func TestTplExpandGitHubLinksToMarkdownFunc(t *testing.T) {
+func TestExpandGitHubLinksToMarkdownFunc(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
 		name         string
@@ -44,13 +45,13 @@ func TestTplExpandGitHubLinksToMarkdownFunc(t *testing.T) {
 		test := tests[i]
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			actual := tplExpandGitHubLinksToMarkdownFunc()(test.fullRepoName, test.input)
+			actual := expandGitHubLinksToMarkdownFunc()(test.fullRepoName, test.input)
 			assert.Equal(t, test.expected, actual)
 		})
 	}
 }
 
-func TestTplExtractMarkdownURLsFunc(t *testing.T) {
+func TestExtractMarkdownURLsFunc(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
 		name     string
@@ -78,8 +79,17 @@ func TestTplExtractMarkdownURLsFunc(t *testing.T) {
 		test := tests[i]
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			actual := tplExtractMarkdownURLsFunc()(test.input)
+			actual := extractMarkdownURLsFunc()(test.input)
 			assert.Equal(t, test.expected, actual)
 		})
 	}
 }
+
+func TestDataFromContext(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, DataFromContext(context.Background()))
+
+	data := map[string]interface{}{"repo": "owner/name"}
+	assert.Equal(t, data, DataFromContext(NewContext(context.Background(), data)))
+}
@@ -0,0 +1,268 @@
+// Package xml provides functions to parse and update XML content in place - while preserving the formatting and the comments.
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// NodeKind is the kind of a node: an element or an attribute.
+type NodeKind int
+
+// Node kinds
+const (
+	Element NodeKind = iota
+	Attribute
+)
+
+// Node is an element or an attribute defined in an XML document, with its position in the document's content.
+type Node struct {
+	Kind NodeKind
+	// Name is the local name of the node - without namespace prefix.
+	Name string
+	// QName is the qualified name of the node, as written in the document - with its namespace prefix if any.
+	QName  string
+	Parent *Node
+
+	// Attributes and Children are the attributes and child elements of an element - in the order in which they are defined.
+	Attributes []*Node
+	Children   []*Node
+
+	// Start and End are the positions of the whole node - the element with its start and end tags, or the attribute.
+	Start int
+	End   int
+	// ValueStart and ValueEnd are the positions of the value of the node: the content of an element - between its start and end tags -
+	// or the value of an attribute - without the quotes.
+	ValueStart int
+	ValueEnd   int
+	// SelfClosing is true for an empty element without end tag, such as <version/>.
+	SelfClosing bool
+
+	text string
+}
+
+// Path returns the location of the node in the document, such as /project/dependencies/dependency[2]/version or /project/@xmlns.
+func (n *Node) Path() string {
+	if n.Parent == nil {
+		return "/" + n.QName
+	}
+	if n.Kind == Attribute {
+		return n.Parent.Path() + "/@" + n.QName
+	}
+	var index, count int
+	for _, sibling := range n.Parent.Children {
+		if sibling.QName == n.QName {
+			count++
+			if sibling == n {
+				index = count
+			}
+		}
+	}
+	if count > 1 {
+		return fmt.Sprintf("%s/%s[%d]", n.Parent.Path(), n.QName, index)
+	}
+	return n.Parent.Path() + "/" + n.QName
+}
+
+// Document is a parsed XML document, which keeps track of the position of each node,
+// so that they can be updated in place - without changing anything else in the document.
+type Document struct {
+	data []byte
+	root *Node
+}
+
+// Parse parses the given XML content.
+func Parse(data []byte) (*Document, error) {
+	var (
+		decoder = xml.NewDecoder(bytes.NewReader(data))
+		root    *Node
+		stack   []*Node
+	)
+	decoder.Strict = true
+	for {
+		start := int(decoder.InputOffset())
+		token, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		end := int(decoder.InputOffset())
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &Node{
+				Kind:       Element,
+				Name:       t.Name.Local,
+				QName:      qualifiedName(t.Name),
+				Start:      start,
+				ValueStart: end,
+				ValueEnd:   end,
+			}
+			if err := parseAttributes(data, node, t.Attr); err != nil {
+				return nil, err
+			}
+			if len(stack) > 0 {
+				node.Parent = stack[len(stack)-1]
+				node.Parent.Children = append(node.Parent.Children, node)
+			} else if root == nil {
+				root = node
+			} else {
+				return nil, fmt.Errorf("unexpected element %s after the root element", node.QName)
+			}
+			node.SelfClosing = bytes.HasSuffix(data[start:end], []byte("/>"))
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("unexpected end element %s", qualifiedName(t.Name))
+			}
+			node := stack[len(stack)-1]
+			if name := qualifiedName(t.Name); name != node.QName {
+				return nil, fmt.Errorf("element %s closed by %s", node.QName, name)
+			}
+			stack = stack[:len(stack)-1]
+			if !node.SelfClosing {
+				node.ValueEnd = start
+			}
+			node.End = end
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("missing root element")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unclosed element %s", stack[len(stack)-1].QName)
+	}
+	return &Document{data: data, root: root}, nil
+}
+
+// Bytes returns the content of the document.
+func (d *Document) Bytes() []byte {
+	return d.data
+}
+
+// Root returns the root element of the document.
+func (d *Document) Root() *Node {
+	return d.root
+}
+
+// Text returns the text of the given node: the value of an attribute, or the text content of an element - without its child elements.
+func (d *Document) Text(n *Node) string {
+	return n.text
+}
+
+// Replacement is the new content of a node's value.
+type Replacement struct {
+	Node    *Node
+	Content string
+}
+
+// SetValue returns the replacement required to set the value of the given node, which will be escaped.
+// The value of an element with child elements can't be set.
+func (d *Document) SetValue(n *Node, value string) (Replacement, error) {
+	if n.Kind == Element && len(n.Children) > 0 {
+		return Replacement{}, fmt.Errorf("can't set the value of element %s: it has child elements", n.Path())
+	}
+	var escaped strings.Builder
+	_ = xml.EscapeText(&escaped, []byte(value))
+	content := escaped.String()
+	if n.Kind == Element {
+		// keep the new lines and tabs as-is in the elements content
+		content = strings.NewReplacer("&#xA;", "\n", "&#x9;", "\t", "&#xD;", "\r").Replace(content)
+	} else if d.data[n.ValueStart-1] == '\'' {
+		content = strings.ReplaceAll(content, "&#39;", "'")
+	}
+	return Replacement{Node: n, Content: content}, nil
+}
+
+// Replace returns the content of the document, with the values of the given nodes replaced.
+func (d *Document) Replace(replacements ...Replacement) []byte {
+	sort.SliceStable(replacements, func(i, j int) bool { return replacements[i].Node.ValueStart < replacements[j].Node.ValueStart })
+
+	var (
+		result   bytes.Buffer
+		position int
+	)
+	for _, r := range replacements {
+		n := r.Node
+		if n.Kind == Element && n.SelfClosing {
+			// <version/> becomes <version>value</version>
+			closing := bytes.LastIndex(d.data[n.Start:n.End], []byte("/>")) + n.Start
+			result.Write(d.data[position:closing])
+			fmt.Fprintf(&result, ">%s</%s>", r.Content, n.QName)
+			position = n.End
+			continue
+		}
+		result.Write(d.data[position:n.ValueStart])
+		result.WriteString(r.Content)
+		position = n.ValueEnd
+	}
+	result.Write(d.data[position:])
+	return result.Bytes()
+}
+
+// parseAttributes finds the position of the values of the attributes in the start tag of the given element.
+func parseAttributes(data []byte, element *Node, attrs []xml.Attr) error {
+	var (
+		tag = data[element.Start:element.ValueStart]
+		pos = 1 + len(element.QName)
+	)
+	for _, attr := range attrs {
+		for pos < len(tag) && isSpace(tag[pos]) {
+			pos++
+		}
+		nameStart := pos
+		for pos < len(tag) && tag[pos] != '=' && !isSpace(tag[pos]) {
+			pos++
+		}
+		nameEnd := pos
+		for pos < len(tag) && (isSpace(tag[pos]) || tag[pos] == '=') {
+			pos++
+		}
+		if pos >= len(tag) || (tag[pos] != '"' && tag[pos] != '\'') {
+			return fmt.Errorf("invalid attribute %s for element %s", qualifiedName(attr.Name), element.QName)
+		}
+		quote := tag[pos]
+		valueStart := pos + 1
+		valueEnd := bytes.IndexByte(tag[valueStart:], quote)
+		if valueEnd < 0 {
+			return fmt.Errorf("unterminated attribute %s for element %s", qualifiedName(attr.Name), element.QName)
+		}
+		valueEnd += valueStart
+		pos = valueEnd + 1
+
+		element.Attributes = append(element.Attributes, &Node{
+			Kind:       Attribute,
+			Name:       attr.Name.Local,
+			QName:      string(tag[nameStart:nameEnd]),
+			Parent:     element,
+			Start:      element.Start + nameStart,
+			End:        element.Start + pos,
+			ValueStart: element.Start + valueStart,
+			ValueEnd:   element.Start + valueEnd,
+			text:       attr.Value,
+		})
+	}
+	return nil
+}
+
+func qualifiedName(name xml.Name) string {
+	if len(name.Space) > 0 {
+		return name.Space + ":" + name.Local
+	}
+	return name.Local
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
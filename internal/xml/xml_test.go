@@ -0,0 +1,287 @@
+package xml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pomXML = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- the project -->
+<project xmlns="http://maven.apache.org/POM/4.0.0" xmlns:xsi='http://www.w3.org/2001/XMLSchema-instance'>
+  <version>1.0.0</version>
+  <properties>
+    <spring.version>5.3.0</spring.version>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>org.springframework</groupId>
+      <artifactId>spring-core</artifactId>
+      <version>${spring.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>my-lib</artifactId>
+      <version><!-- latest -->2.0.0</version>
+      <scope/>
+    </dependency>
+  </dependencies>
+  <build>
+    <plugins>
+      <plugin id="compiler" enabled="true"/>
+    </plugins>
+  </build>
+</project>
+`
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		xpath            string
+		expectedPaths    []string
+		expectedTexts    []string
+		expectedErrorMsg string
+	}{
+		{
+			name:          "absolute path",
+			xpath:         "/project/version",
+			expectedPaths: []string{"/project/version"},
+			expectedTexts: []string{"1.0.0"},
+		},
+		{
+			name:          "text function",
+			xpath:         "/project/version/text()",
+			expectedPaths: []string{"/project/version"},
+			expectedTexts: []string{"1.0.0"},
+		},
+		{
+			name:          "descendants",
+			xpath:         "//dependency/version",
+			expectedPaths: []string{"/project/dependencies/dependency[1]/version", "/project/dependencies/dependency[2]/version"},
+			expectedTexts: []string{"${spring.version}", "2.0.0"},
+		},
+		{
+			name:          "all descendants",
+			xpath:         "//version",
+			expectedPaths: []string{"/project/version", "/project/dependencies/dependency[1]/version", "/project/dependencies/dependency[2]/version"},
+			expectedTexts: []string{"1.0.0", "${spring.version}", "2.0.0"},
+		},
+		{
+			name:          "wildcard",
+			xpath:         "/project/properties/*",
+			expectedPaths: []string{"/project/properties/spring.version"},
+			expectedTexts: []string{"5.3.0"},
+		},
+		{
+			name:          "position",
+			xpath:         "/project/dependencies/dependency[2]/artifactId",
+			expectedPaths: []string{"/project/dependencies/dependency[2]/artifactId"},
+			expectedTexts: []string{"my-lib"},
+		},
+		{
+			name:          "child condition",
+			xpath:         "//dependency[artifactId='my-lib']/version",
+			expectedPaths: []string{"/project/dependencies/dependency[2]/version"},
+			expectedTexts: []string{"2.0.0"},
+		},
+		{
+			name:          "conditions with and and or",
+			xpath:         `//dependency[groupId="com.example" and artifactId='spring-core' or artifactId != 'my-lib']/artifactId`,
+			expectedPaths: []string{"/project/dependencies/dependency[1]/artifactId"},
+			expectedTexts: []string{"spring-core"},
+		},
+		{
+			name:          "existence condition",
+			xpath:         "//dependency[scope]/groupId",
+			expectedPaths: []string{"/project/dependencies/dependency[2]/groupId"},
+			expectedTexts: []string{"com.example"},
+		},
+		{
+			name:          "attribute with condition",
+			xpath:         "//plugin[@id='compiler']/@enabled",
+			expectedPaths: []string{"/project/build/plugins/plugin/@enabled"},
+			expectedTexts: []string{"true"},
+		},
+		{
+			name:          "namespaced attribute",
+			xpath:         "/project/@xmlns:xsi",
+			expectedPaths: []string{"/project/@xmlns:xsi"},
+			expectedTexts: []string{"http://www.w3.org/2001/XMLSchema-instance"},
+		},
+		{
+			name:  "no match",
+			xpath: "/project/parent/version",
+		},
+		{
+			name:             "relative path",
+			xpath:            "project/version",
+			expectedErrorMsg: `invalid xpath "project/version": expected an absolute path, starting with '/'`,
+		},
+		{
+			name:             "unterminated predicate",
+			xpath:            "//dependency[artifactId='my-lib'",
+			expectedErrorMsg: `invalid xpath "//dependency[artifactId='my-lib'": unterminated predicate at position 13`,
+		},
+		{
+			name:             "invalid position",
+			xpath:            "//dependency[0]",
+			expectedErrorMsg: `invalid xpath "//dependency[0]": invalid position 0: it starts at 1`,
+		},
+		{
+			name:             "step after attribute",
+			xpath:            "/project/@xmlns/version",
+			expectedErrorMsg: `invalid xpath "/project/@xmlns/version": unexpected step after attribute xmlns at position 15`,
+		},
+	}
+
+	doc, err := Parse([]byte(pomXML))
+	require.NoError(t, err)
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			xpath, err := ParseXPath(test.xpath)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+
+			var actualPaths, actualTexts []string
+			for _, node := range xpath.Select(doc) {
+				actualPaths = append(actualPaths, node.Path())
+				actualTexts = append(actualTexts, doc.Text(node))
+			}
+			assert.Equal(t, test.expectedPaths, actualPaths)
+			assert.Equal(t, test.expectedTexts, actualTexts)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		input            string
+		expectedErrorMsg string
+	}{
+		{
+			name:  "valid document",
+			input: pomXML,
+		},
+		{
+			name:             "empty document",
+			input:            "<!-- nothing -->",
+			expectedErrorMsg: "missing root element",
+		},
+		{
+			name:             "multiple root elements",
+			input:            "<a/><b/>",
+			expectedErrorMsg: "unexpected element b after the root element",
+		},
+		{
+			name:             "unclosed element",
+			input:            "<project><version>1.0.0</project>",
+			expectedErrorMsg: "element version closed by project",
+		},
+		{
+			name:             "unclosed root element",
+			input:            "<project><version>1.0.0</version>",
+			expectedErrorMsg: "unclosed element project",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Parse([]byte(test.input))
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestReplace(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		input            string
+		xpath            string
+		value            string
+		expected         string
+		expectedErrorMsg string
+	}{
+		{
+			name:     "element and keep formatting",
+			input:    "<project>\n\t<!-- comment -->\n  <version >1.0.0</version>\n</project>",
+			xpath:    "/project/version",
+			value:    "1.1.0",
+			expected: "<project>\n\t<!-- comment -->\n  <version >1.1.0</version>\n</project>",
+		},
+		{
+			name:     "multiple elements",
+			input:    "<a><b>1</b><c><b>2</b></c></a>",
+			xpath:    "//b",
+			value:    "3",
+			expected: "<a><b>3</b><c><b>3</b></c></a>",
+		},
+		{
+			name:     "self-closing element",
+			input:    `<a><b x="1" /></a>`,
+			xpath:    "/a/b",
+			value:    "2",
+			expected: `<a><b x="1" >2</b></a>`,
+		},
+		{
+			name:     "attributes with escaped characters",
+			input:    `<a x="1" y='2'/>`,
+			xpath:    "/a/@*",
+			value:    `it's "<new>"`,
+			expected: `<a x="it&#39;s &#34;&lt;new&gt;&#34;" y='it's &#34;&lt;new&gt;&#34;'/>`,
+		},
+		{
+			name:     "multi-line element value",
+			input:    "<a><b>1</b></a>",
+			xpath:    "/a/b",
+			value:    "first\n\tsecond & third",
+			expected: "<a><b>first\n\tsecond &amp; third</b></a>",
+		},
+		{
+			name:             "element with children",
+			input:            "<a><b>1</b></a>",
+			xpath:            "/a",
+			value:            "2",
+			expectedErrorMsg: "can't set the value of element /a: it has child elements",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			doc, err := Parse([]byte(test.input))
+			require.NoError(t, err)
+			xpath, err := ParseXPath(test.xpath)
+			require.NoError(t, err)
+
+			var replacements []Replacement
+			for _, node := range xpath.Select(doc) {
+				replacement, err := doc.SetValue(node, test.value)
+				if len(test.expectedErrorMsg) > 0 {
+					require.EqualError(t, err, test.expectedErrorMsg)
+					return
+				}
+				require.NoError(t, err)
+				replacements = append(replacements, replacement)
+			}
+			assert.Equal(t, test.expected, string(doc.Replace(replacements...)))
+		})
+	}
+}
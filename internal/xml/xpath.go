@@ -0,0 +1,376 @@
+package xml
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// XPath is an expression used to select nodes in an XML document. It supports a subset of XPath 1.0:
+//   - absolute paths, such as `/project/version`, and descendants, such as `//dependency/version`
+//   - wildcards, such as `/project/*/version`
+//   - attributes, such as `/project/@xmlns` or `//plugin/@id`
+//   - positional predicates, such as `//dependency[2]/version` - starting at 1
+//   - conditional predicates, such as `//dependency[artifactId='my-lib']/version`, using the `=` and `!=` operators,
+//     the `and` and `or` boolean operators, and the existence of a child element or an attribute - such as `//plugin[@id]`
+//
+// The names are matched against the local names of the nodes, ignoring the namespaces: `/project/version` matches
+// the version of a Maven POM, even if it uses the `http://maven.apache.org/POM/4.0.0` default namespace.
+type XPath struct {
+	raw   string
+	steps []xstep
+}
+
+type xstep struct {
+	descendants bool
+	attribute   bool
+	name        string
+	predicates  []predicate
+}
+
+// predicate is either a position - starting at 1 - or a list of conditions, joined by "or" then "and".
+type predicate struct {
+	position   int
+	conditions [][]condition
+}
+
+type condition struct {
+	path     []string
+	operator string
+	literal  string
+}
+
+// ParseXPath parses the given XPath expression.
+func ParseXPath(str string) (XPath, error) {
+	p := &xpathParser{str: strings.TrimSpace(str)}
+	steps, err := p.parse()
+	if err != nil {
+		return XPath{}, fmt.Errorf("invalid xpath %q: %w", str, err)
+	}
+	return XPath{raw: str, steps: steps}, nil
+}
+
+// String returns the string representation of the expression.
+func (x XPath) String() string {
+	return x.raw
+}
+
+// Select returns the nodes of the given document matching the expression - in the order in which they are defined.
+func (x XPath) Select(d *Document) []*Node {
+	// a virtual node representing the document, whose only child is the root element
+	document := &Node{Children: []*Node{d.root}}
+	nodes := []*Node{document}
+	for _, st := range x.steps {
+		var (
+			next []*Node
+			seen = make(map[*Node]bool)
+		)
+		for _, node := range nodes {
+			for _, selected := range st.apply(node) {
+				if !seen[selected] {
+					seen[selected] = true
+					next = append(next, selected)
+				}
+			}
+		}
+		nodes = next
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].Start < nodes[j].Start })
+	return nodes
+}
+
+func (st xstep) apply(node *Node) []*Node {
+	var candidates []*Node
+	switch {
+	case st.attribute:
+		elements := []*Node{node}
+		if st.descendants {
+			elements = descendants(node)
+		}
+		for _, element := range elements {
+			for _, attr := range element.Attributes {
+				if st.name == "*" || attr.Name == st.name {
+					candidates = append(candidates, attr)
+				}
+			}
+		}
+	case st.descendants:
+		for _, descendant := range descendants(node)[1:] {
+			if st.name == "*" || descendant.Name == st.name {
+				candidates = append(candidates, descendant)
+			}
+		}
+	default:
+		for _, child := range node.Children {
+			if st.name == "*" || child.Name == st.name {
+				candidates = append(candidates, child)
+			}
+		}
+	}
+
+	for _, pred := range st.predicates {
+		var filtered []*Node
+		for i, candidate := range candidates {
+			if pred.match(i+1, candidate) {
+				filtered = append(filtered, candidate)
+			}
+		}
+		candidates = filtered
+	}
+	return candidates
+}
+
+// descendants returns the given node and all its descendant elements.
+func descendants(node *Node) []*Node {
+	nodes := []*Node{node}
+	for _, child := range node.Children {
+		nodes = append(nodes, descendants(child)...)
+	}
+	return nodes
+}
+
+func (p predicate) match(position int, node *Node) bool {
+	if p.position > 0 {
+		return position == p.position
+	}
+	for _, and := range p.conditions {
+		matching := true
+		for _, c := range and {
+			if !c.match(node) {
+				matching = false
+				break
+			}
+		}
+		if matching {
+			return true
+		}
+	}
+	return false
+}
+
+func (c condition) match(node *Node) bool {
+	nodes := []*Node{node}
+	for _, name := range c.path {
+		var next []*Node
+		for _, n := range nodes {
+			switch {
+			case name == ".":
+				next = append(next, n)
+			case strings.HasPrefix(name, "@"):
+				for _, attr := range n.Attributes {
+					if attr.Name == name[1:] {
+						next = append(next, attr)
+					}
+				}
+			default:
+				for _, child := range n.Children {
+					if name == "*" || child.Name == name {
+						next = append(next, child)
+					}
+				}
+			}
+		}
+		nodes = next
+	}
+
+	for _, n := range nodes {
+		switch {
+		case len(c.operator) == 0:
+			return true
+		case (strings.TrimSpace(n.text) == c.literal) == (c.operator == "="):
+			return true
+		}
+	}
+	return false
+}
+
+type xpathParser struct {
+	str string
+	pos int
+}
+
+func (p *xpathParser) parse() ([]xstep, error) {
+	if !strings.HasPrefix(p.str, "/") {
+		return nil, fmt.Errorf("expected an absolute path, starting with '/'")
+	}
+
+	var steps []xstep
+	for p.pos < len(p.str) {
+		var st xstep
+		switch {
+		case strings.HasPrefix(p.str[p.pos:], "//"):
+			st.descendants = true
+			p.pos += 2
+		case p.str[p.pos] == '/':
+			p.pos++
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", p.str[p.pos], p.pos)
+		}
+
+		if p.pos < len(p.str) && p.str[p.pos] == '@' {
+			st.attribute = true
+			p.pos++
+		}
+		st.name = p.readName()
+		if len(st.name) == 0 {
+			return nil, fmt.Errorf("missing name at position %d", p.pos)
+		}
+		if st.name == "text()" {
+			// the text of an element is its value: the step is a no-op
+			continue
+		}
+
+		for p.pos < len(p.str) && p.str[p.pos] == '[' {
+			pred, err := p.parsePredicate()
+			if err != nil {
+				return nil, err
+			}
+			st.predicates = append(st.predicates, pred)
+		}
+		if st.attribute && p.pos < len(p.str) {
+			return nil, fmt.Errorf("unexpected step after attribute %s at position %d", st.name, p.pos)
+		}
+		steps = append(steps, st)
+	}
+
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+	return steps, nil
+}
+
+func (p *xpathParser) readName() string {
+	start := p.pos
+	if strings.HasPrefix(p.str[p.pos:], "text()") {
+		p.pos += len("text()")
+		return "text()"
+	}
+	for p.pos < len(p.str) && !strings.ContainsRune("/[]@=!() '\"", rune(p.str[p.pos])) {
+		p.pos++
+	}
+	name := p.str[start:p.pos]
+	// ignore the namespace prefix, such as pom:version
+	if i := strings.LastIndexByte(name, ':'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+func (p *xpathParser) parsePredicate() (predicate, error) {
+	p.pos++ // skip the '['
+	p.skipSpaces()
+
+	end := strings.IndexByte(p.str[p.pos:], ']')
+	if end < 0 {
+		return predicate{}, fmt.Errorf("unterminated predicate at position %d", p.pos)
+	}
+	if position, err := strconv.Atoi(strings.TrimSpace(p.str[p.pos : p.pos+end])); err == nil {
+		if position < 1 {
+			return predicate{}, fmt.Errorf("invalid position %d: it starts at 1", position)
+		}
+		p.pos += end + 1
+		return predicate{position: position}, nil
+	}
+
+	var (
+		pred predicate
+		and  []condition
+	)
+	for {
+		c, err := p.parseCondition()
+		if err != nil {
+			return predicate{}, err
+		}
+		and = append(and, c)
+
+		p.skipSpaces()
+		switch {
+		case strings.HasPrefix(p.str[p.pos:], "and "):
+			p.pos += len("and ")
+		case strings.HasPrefix(p.str[p.pos:], "or "):
+			p.pos += len("or ")
+			pred.conditions = append(pred.conditions, and)
+			and = nil
+		case strings.HasPrefix(p.str[p.pos:], "]"):
+			p.pos++
+			pred.conditions = append(pred.conditions, and)
+			return pred, nil
+		default:
+			return predicate{}, fmt.Errorf("expected ']' at position %d", p.pos)
+		}
+	}
+}
+
+func (p *xpathParser) parseCondition() (condition, error) {
+	p.skipSpaces()
+
+	var c condition
+	for {
+		var name string
+		switch {
+		case strings.HasPrefix(p.str[p.pos:], "text()"):
+			p.pos += len("text()")
+			name = "."
+		case strings.HasPrefix(p.str[p.pos:], "."):
+			p.pos++
+			name = "."
+		case strings.HasPrefix(p.str[p.pos:], "@"):
+			p.pos++
+			name = "@" + p.readName()
+		default:
+			name = p.readName()
+		}
+		if len(name) == 0 || name == "@" {
+			return condition{}, fmt.Errorf("missing name at position %d", p.pos)
+		}
+		c.path = append(c.path, name)
+		if p.pos >= len(p.str) || p.str[p.pos] != '/' || strings.HasPrefix(name, "@") {
+			break
+		}
+		p.pos++
+	}
+
+	p.skipSpaces()
+	for _, operator := range []string{"!=", "="} {
+		if strings.HasPrefix(p.str[p.pos:], operator) {
+			c.operator = operator
+			p.pos += len(operator)
+			p.skipSpaces()
+			literal, err := p.readLiteral()
+			if err != nil {
+				return condition{}, err
+			}
+			c.literal = literal
+			break
+		}
+	}
+	return c, nil
+}
+
+func (p *xpathParser) readLiteral() (string, error) {
+	if p.pos < len(p.str) && (p.str[p.pos] == '\'' || p.str[p.pos] == '"') {
+		quote := p.str[p.pos]
+		end := strings.IndexByte(p.str[p.pos+1:], quote)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated literal at position %d", p.pos)
+		}
+		literal := p.str[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return literal, nil
+	}
+	start := p.pos
+	for p.pos < len(p.str) && !strings.ContainsRune("] ", rune(p.str[p.pos])) {
+		p.pos++
+	}
+	if _, err := strconv.ParseFloat(p.str[start:p.pos], 64); err != nil {
+		return "", fmt.Errorf("invalid literal %q: expected a quoted string or a number", p.str[start:p.pos])
+	}
+	return p.str[start:p.pos], nil
+}
+
+func (p *xpathParser) skipSpaces() {
+	for p.pos < len(p.str) && p.str[p.pos] == ' ' {
+		p.pos++
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -11,6 +12,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dailymotion-oss/octopilot/internal/config"
 	"github.com/dailymotion-oss/octopilot/internal/git"
 	"github.com/dailymotion-oss/octopilot/repository"
 	"github.com/dailymotion-oss/octopilot/update"
@@ -27,8 +29,9 @@ var (
 )
 
 var options struct {
-	updates []string
-	repos   []string
+	configFile string
+	updates    []string
+	repos      []string
 	repository.UpdateOptions
 	logLevel           string
 	failOnError        bool
@@ -40,6 +43,9 @@ func init() {
 	// defaults
 	options.GitHub.PullRequest.Merge.BranchProtection = repository.BranchProtectionKindStatusChecks
 
+	// config file
+	pflag.StringVar(&options.configFile, "config", "", "Optional path to a YAML configuration file, defining the updates, the repositories and the options. Values set by the CLI flags take precedence over the values defined in the file - see the online documentation for more details.")
+
 	// required flags
 	pflag.StringArrayVarP(&options.updates, "update", "u", nil, `An update operation, such as "yaml(file=config.yaml,path='version')=file(path=VERSION)" - see the online documentation for all available updaters.`)
 	assert(pflag.CommandLine.SetAnnotation("update", "mandatory", []string{"true"}))
@@ -117,28 +123,49 @@ func init() {
 
 func main() {
 	ctx := context.Background()
+	cfg := loadConfigFile()
 	pflag.Parse()
 	printHelpOrVersion()
 	setLogLevel()
 	checkMandatoryFlags()
 
-	logrus.WithField("updates", options.updates).Trace("Parsing updates")
-	updaters, err := update.Parse(options.updates)
-	if err != nil {
-		logrus.
-			WithError(err).
-			WithField("updates", options.updates).
-			Fatal("Failed to parse updates")
+	var (
+		updaters []update.Updater
+		err      error
+	)
+	if cfg != nil && !pflag.CommandLine.Changed("update") {
+		updaters = cfg.Updaters()
+	} else {
+		logrus.WithField("updates", options.updates).Trace("Parsing updates")
+		updaters, err = update.Parse(options.updates)
+		if err != nil {
+			logrus.
+				WithError(err).
+				WithField("updates", options.updates).
+				Fatal("Failed to parse updates")
+		}
 	}
 	logrus.WithField("updaters", updaters).Debug("Updaters ready")
 
-	logrus.WithField("repos", options.repos).Trace("Parsing repositories")
-	repositories, err := repository.Parse(ctx, options.repos, options.GitHub)
-	if err != nil {
-		logrus.
-			WithError(err).
-			WithField("repos", options.repos).
-			Fatal("Failed to parse repos")
+	var repositories []repository.Repository
+	if cfg != nil && !pflag.CommandLine.Changed("repo") {
+		logrus.WithField("config-file", options.configFile).Trace("Resolving repositories from the configuration file")
+		repositories, err = cfg.Repositories(ctx, options.GitHub)
+		if err != nil {
+			logrus.
+				WithError(err).
+				WithField("config-file", options.configFile).
+				Fatal("Failed to resolve repos")
+		}
+	} else {
+		logrus.WithField("repos", options.repos).Trace("Parsing repositories")
+		repositories, err = repository.Parse(ctx, options.repos, options.GitHub)
+		if err != nil {
+			logrus.
+				WithError(err).
+				WithField("repos", options.repos).
+				Fatal("Failed to parse repos")
+		}
 	}
 	logrus.WithField("repositories", repositories).Debug("Repositories ready")
 
@@ -267,6 +294,41 @@ func writeResults(results *repository.ResultFile, file string) error {
 	return os.WriteFile(file, jsonBytes, 0644)
 }
 
+// loadConfigFile loads the configuration file defined by the "--config" flag - if any - on top of the default options.
+// It must be called before parsing all the flags, so that the values set by the flags take precedence over the values defined in the file.
+func loadConfigFile() *config.Config {
+	flags := pflag.NewFlagSet("config", pflag.ContinueOnError)
+	flags.ParseErrorsWhitelist.UnknownFlags = true
+	flags.SetOutput(io.Discard)
+	flags.Usage = func() {}
+	configFile := flags.String("config", "", "")
+	_ = flags.Parse(os.Args[1:])
+	if len(*configFile) == 0 {
+		return nil
+	}
+
+	cfg := &config.Config{
+		UpdateOptions:      options.UpdateOptions,
+		LogLevel:           options.logLevel,
+		FailOnError:        options.failOnError,
+		MaxConcurrentRepos: options.maxConcurrentRepos,
+		OutputResults:      options.outputResults,
+	}
+	if err := config.Load(*configFile, cfg); err != nil {
+		logrus.
+			WithError(err).
+			WithField("config-file", *configFile).
+			Fatal("Failed to load configuration file")
+	}
+
+	options.UpdateOptions = cfg.UpdateOptions
+	options.logLevel = cfg.LogLevel
+	options.failOnError = cfg.FailOnError
+	options.maxConcurrentRepos = cfg.MaxConcurrentRepos
+	options.outputResults = cfg.OutputResults
+	return cfg
+}
+
 func checkMandatoryFlags() {
 	var missingFlags []string
 	pflag.CommandLine.VisitAll(func(flag *pflag.Flag) {
@@ -337,8 +337,12 @@ func commitChanges(ctx context.Context, gitRepo *git.Repository, opts commitOpti
 	}
 
 	for _, pattern := range opts.GitOpts.StagePatterns {
-		err = workTree.AddGlob(pattern)
+		deleted, err := stageDeletedFiles(workTree, status, pattern)
 		if err != nil {
+			return false, fmt.Errorf("failed to stage deleted files using pattern %s: %w", pattern, err)
+		}
+		err = workTree.AddGlob(pattern)
+		if err != nil && !(deleted && errors.Is(err, git.ErrGlobNoMatches)) {
 			return false, fmt.Errorf("failed to stage files using pattern %s: %w", pattern, err)
 		}
 	}
@@ -377,6 +381,40 @@ func commitChanges(ctx context.Context, gitRepo *git.Repository, opts commitOpti
 	return true, nil
 }
 
+// stageDeletedFiles stages the deletion of the files matching the given pattern - or inside a matching directory -
+// because they can't be matched by AddGlob anymore. It returns true if at least one deleted file has been staged.
+func stageDeletedFiles(workTree *git.Worktree, status git.Status, pattern string) (bool, error) {
+	var staged bool
+	for path, fileStatus := range status {
+		if fileStatus.Worktree != git.Deleted {
+			continue
+		}
+		matched, err := matchesPathOrParent(pattern, path)
+		if err != nil {
+			return false, err
+		}
+		if !matched {
+			continue
+		}
+		if _, err = workTree.Remove(path); err != nil {
+			return false, fmt.Errorf("failed to stage deleted file %s: %w", path, err)
+		}
+		staged = true
+	}
+	return staged, nil
+}
+
+// matchesPathOrParent returns true if the given pattern matches the path, or one of its parent directories.
+func matchesPathOrParent(pattern, path string) (bool, error) {
+	for ; path != "." && path != "/"; path = filepath.Dir(path) {
+		matched, err := filepath.Match(pattern, path)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
 func parseSigningKey(signingKeyPath, signingKeyPassphrase string) (*openpgp.Entity, error) {
 	if signingKeyPath == "" {
 		return nil, nil
@@ -471,6 +509,15 @@ func buildDiffTreeEntries(ctx context.Context, base, head *object.Commit) ([]*gi
 			SHA:     sha,
 			Content: content,
 		})
+
+		// a renamed file is a single change, so its old path must be deleted explicitly - with a nil SHA and content
+		if c.From.TreeEntry.Mode != filemode.Empty && c.To.TreeEntry.Mode != filemode.Empty && c.From.Name != c.To.Name {
+			treeEntries = append(treeEntries, &github.TreeEntry{
+				Path: ptr(c.From.Name),
+				Type: ptr(treeEntryModeToTreeType(c.From.TreeEntry.Mode)),
+				Mode: ptr(fmt.Sprintf("%06o", uint32(c.From.TreeEntry.Mode))),
+			})
+		}
 	}
 
 	return treeEntries, nil
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"os"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/mholt/archiver"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -177,3 +179,62 @@ func TestParseSigningKey(t *testing.T) {
 		})
 	}
 }
+
+func TestCommitChangesWithDeletedFiles(t *testing.T) {
+	t.Parallel()
+
+	repoPath := filepath.Join("testdata", "commit-deleted-files", "repo")
+	require.NoError(t, os.RemoveAll(repoPath))
+	repo, err := git.PlainInit(repoPath, false)
+	require.NoError(t, err)
+	workTree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	for _, filename := range []string{"old.txt", "keep.txt", "legacy/a.txt", "legacy/b.txt"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(repoPath, filename)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, filename), []byte(filename), 0644))
+		_, err = workTree.Add(filename)
+		require.NoError(t, err)
+	}
+	_, err = workTree.Commit("Initial commit", &git.CommitOptions{Author: &object.Signature{Name: "Octopilot", Email: "octopilot@example.com"}})
+	require.NoError(t, err)
+
+	// move old.txt to new.txt, and delete the legacy directory
+	require.NoError(t, os.Rename(filepath.Join(repoPath, "old.txt"), filepath.Join(repoPath, "new.txt")))
+	require.NoError(t, os.RemoveAll(filepath.Join(repoPath, "legacy")))
+
+	committed, err := commitChanges(context.Background(), repo, commitOptions{
+		CommitMessage: CommitMessage{Headline: "Move and delete files"},
+		GitOpts: GitOptions{
+			StagePatterns: []string{"*.txt", "legacy"},
+			AuthorName:    "Octopilot",
+			AuthorEmail:   "octopilot@example.com",
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, committed)
+
+	status, err := workTree.Status()
+	require.NoError(t, err)
+	assert.True(t, status.IsClean(), "unexpected status: %s", status)
+
+	head, err := getLatestCommit(context.Background(), repo)
+	require.NoError(t, err)
+	parent, err := head.Parent(0)
+	require.NoError(t, err)
+	entries, err := buildDiffTreeEntries(context.Background(), parent, head)
+	require.NoError(t, err)
+
+	actual := map[string]string{}
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		require.NoError(t, err)
+		actual[entry.GetPath()] = string(data)
+	}
+	assert.Equal(t, map[string]string{
+		"legacy/a.txt": `{"sha":null,"path":"legacy/a.txt","mode":"100644","type":"blob"}`,
+		"legacy/b.txt": `{"sha":null,"path":"legacy/b.txt","mode":"100644","type":"blob"}`,
+		"old.txt":      `{"sha":null,"path":"old.txt","mode":"100644","type":"blob"}`,
+		"new.txt":      `{"path":"new.txt","mode":"100644","type":"blob","content":"old.txt"}`,
+	}, actual)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/dailymotion-oss/octopilot/update"
 	"github.com/go-git/go-git/v5"
+	"gopkg.in/yaml.v3"
 )
 
 // definition of the different kind of Pull Request update operations
@@ -22,41 +23,41 @@ const (
 
 // UpdateOptions is the options entrypoint for a git repo update
 type UpdateOptions struct {
-	DryRun    bool
-	KeepFiles bool
-	Git       GitOptions
-	GitHub    GitHubOptions
-	Strategy  string
+	DryRun    bool          `yaml:"dryRun"`
+	KeepFiles bool          `yaml:"keepFiles"`
+	Git       GitOptions    `yaml:"git"`
+	GitHub    GitHubOptions `yaml:"github"`
+	Strategy  string        `yaml:"strategy"`
 }
 
 // GitOptions holds all the options required to perform git operations: clone, commit, ...
 type GitOptions struct {
-	CloneDir             string
-	StagePatterns        []string
-	StageAllChanged      bool
-	AuthorName           string
-	AuthorEmail          string
-	CommitterName        string
-	CommitterEmail       string
-	CommitTitle          string
-	CommitBody           string
-	CommitFooter         string
-	BranchPrefix         string
-	SigningKeyPath       string
-	SigningKeyPassphrase string
-	RecurseSubmodules    bool
+	CloneDir             string   `yaml:"cloneDir"`
+	StagePatterns        []string `yaml:"stagePatterns"`
+	StageAllChanged      bool     `yaml:"stageAllChanged"`
+	AuthorName           string   `yaml:"authorName"`
+	AuthorEmail          string   `yaml:"authorEmail"`
+	CommitterName        string   `yaml:"committerName"`
+	CommitterEmail       string   `yaml:"committerEmail"`
+	CommitTitle          string   `yaml:"commitTitle"`
+	CommitBody           string   `yaml:"commitBody"`
+	CommitFooter         string   `yaml:"commitFooter"`
+	BranchPrefix         string   `yaml:"branchPrefix"`
+	SigningKeyPath       string   `yaml:"signingKeyPath"`
+	SigningKeyPassphrase string   `yaml:"signingKeyPassphrase"`
+	RecurseSubmodules    bool     `yaml:"recurseSubmodules"`
 }
 
 // GitHubOptions holds all the options required to perform github operations: auth, PRs, ...
 type GitHubOptions struct {
-	URL            string
-	AuthMethod     string
-	Token          string
-	AppID          int64
-	InstallationID int64
-	PrivateKey     string
-	PrivateKeyPath string
-	PullRequest    PullRequestOptions
+	URL            string             `yaml:"url"`
+	AuthMethod     string             `yaml:"authMethod"`
+	Token          string             `yaml:"token"`
+	AppID          int64              `yaml:"appId"`
+	InstallationID int64              `yaml:"installationId"`
+	PrivateKey     string             `yaml:"privateKey"`
+	PrivateKeyPath string             `yaml:"privateKeyPath"`
+	PullRequest    PullRequestOptions `yaml:"pullRequest"`
 }
 
 func (o *GitHubOptions) isEnterprise() bool {
@@ -65,18 +66,18 @@ func (o *GitHubOptions) isEnterprise() bool {
 
 // PullRequestOptions holds all the options required to perform github PR operations: title/body, merge, ...
 type PullRequestOptions struct {
-	Labels               []string
-	BaseBranch           string
-	Title                string
-	TitleUpdateOperation string
-	Body                 string
-	BodyUpdateOperation  string
-	Comments             []string
-	Assignees            []string
-	Reviewers            []string
-	TeamReviewers        []string
-	Draft                bool
-	Merge                PullRequestMergeOptions
+	Labels               []string                `yaml:"labels"`
+	BaseBranch           string                  `yaml:"baseBranch"`
+	Title                string                  `yaml:"title"`
+	TitleUpdateOperation string                  `yaml:"titleUpdateOperation"`
+	Body                 string                  `yaml:"body"`
+	BodyUpdateOperation  string                  `yaml:"bodyUpdateOperation"`
+	Comments             []string                `yaml:"comments"`
+	Assignees            []string                `yaml:"assignees"`
+	Reviewers            []string                `yaml:"reviewers"`
+	TeamReviewers        []string                `yaml:"teamReviewers"`
+	Draft                bool                    `yaml:"draft"`
+	Merge                PullRequestMergeOptions `yaml:"merge"`
 }
 
 // BranchProtectionKind enumerates possible branch protections to wait for before attempting a PR merge.
@@ -118,48 +119,36 @@ func (b *BranchProtectionKind) Type() string {
 	)
 }
 
+// UnmarshalYAML decodes and validates a branch protection kind defined in a YAML configuration file.
+func (b *BranchProtectionKind) UnmarshalYAML(node *yaml.Node) error {
+	if err := b.Set(node.Value); err != nil {
+		return fmt.Errorf("line %d, column %d: invalid branch protection kind %q, expected one of %s", node.Line, node.Column, node.Value, b.Type())
+	}
+	return nil
+}
+
 // PullRequestMergeOptions holds all the options required to merge github PRs
 type PullRequestMergeOptions struct {
-	Enabled          bool
-	Auto             bool
-	AutoWait         bool
-	Method           string
-	CommitTitle      string
-	CommitMessage    string
-	SHA              string
-	PollInterval     time.Duration
-	PollTimeout      time.Duration
-	RetryCount       int
-	BranchProtection BranchProtectionKind
-}
-
-func (o *GitOptions) setDefaultValues(updaters []update.Updater, tplExecutorFunc templateExecutor) error {
-	if len(updaters) == 1 {
-		title, body := updaters[0].Message()
-		if len(o.CommitTitle) == 0 {
-			o.CommitTitle = title
-		}
-		if len(o.CommitBody) == 0 {
-			o.CommitBody = body
-		}
+	Enabled          bool                 `yaml:"enabled"`
+	Auto             bool                 `yaml:"auto"`
+	AutoWait         bool                 `yaml:"autoWait"`
+	Method           string               `yaml:"method"`
+	CommitTitle      string               `yaml:"commitTitle"`
+	CommitMessage    string               `yaml:"commitMessage"`
+	SHA              string               `yaml:"sha"`
+	PollInterval     time.Duration        `yaml:"pollInterval"`
+	PollTimeout      time.Duration        `yaml:"pollTimeout"`
+	RetryCount       int                  `yaml:"retryCount"`
+	BranchProtection BranchProtectionKind `yaml:"branchProtection"`
+}
+
+func (o *GitOptions) setDefaultValues(updaters []update.Updater, repoPath string, tplExecutorFunc templateExecutor) error {
+	if len(o.CommitTitle) == 0 && len(updaters) == 1 {
+		o.CommitTitle, _ = updaters[0].Message()
 	}
 	if len(o.CommitTitle) == 0 {
 		o.CommitTitle = "Octopilot update"
 	}
-	if len(o.CommitBody) == 0 {
-		body := new(strings.Builder)
-		body.WriteString("Updates:")
-		for _, updater := range updaters {
-			updaterTitle, updaterBody := updater.Message()
-			body.WriteString("\n\n### ")
-			body.WriteString(updater.String())
-			body.WriteString("\n")
-			body.WriteString(updaterTitle)
-			body.WriteString("\n")
-			body.WriteString(updaterBody)
-		}
-		o.CommitBody = body.String()
-	}
 
 	commitTitle, err := tplExecutorFunc(o.CommitTitle)
 	if err != nil {
@@ -167,6 +156,15 @@ func (o *GitOptions) setDefaultValues(updaters []update.Updater, tplExecutorFunc
 	}
 	o.CommitTitle = commitTitle
 
+	if len(o.CommitBody) == 0 {
+		commitBody, err := defaultCommitBody(updaters, repoPath, tplExecutorFunc)
+		if err != nil {
+			return err
+		}
+		o.CommitBody = commitBody
+		return nil
+	}
+
 	commitBody, err := tplExecutorFunc(o.CommitBody)
 	if err != nil {
 		return fmt.Errorf("failed to run template for git commit body %s: %w", o.CommitBody, err)
@@ -176,16 +174,49 @@ func (o *GitOptions) setDefaultValues(updaters []update.Updater, tplExecutorFunc
 	return nil
 }
 
+// defaultCommitBody returns the default commit body for the given updaters: their messages - executed as templates -
+// each followed by the report of their changes. The reports are appended once the templates have been executed,
+// because they hold values read from the repositories - such as lines of Helm templates - which must be kept as is.
+func defaultCommitBody(updaters []update.Updater, repoPath string, tplExecutorFunc templateExecutor) (string, error) {
+	if len(updaters) == 1 {
+		_, body := updaters[0].Message()
+		return executeMessage(body, update.Report(updaters[0], repoPath), tplExecutorFunc)
+	}
+
+	body := new(strings.Builder)
+	body.WriteString("Updates:")
+	for _, updater := range updaters {
+		updaterTitle, updaterBody := updater.Message()
+		section, err := executeMessage(
+			fmt.Sprintf("### %s\n%s\n%s", updater.String(), updaterTitle, updaterBody),
+			update.Report(updater, repoPath),
+			tplExecutorFunc,
+		)
+		if err != nil {
+			return "", err
+		}
+		body.WriteString("\n\n")
+		body.WriteString(section)
+	}
+	return body.String(), nil
+}
+
+// executeMessage executes the given message as a template, and appends the given report as is.
+func executeMessage(message, report string, tplExecutorFunc templateExecutor) (string, error) {
+	result, err := tplExecutorFunc(message)
+	if err != nil {
+		return "", fmt.Errorf("failed to run template for git commit body %s: %w", message, err)
+	}
+	if len(report) > 0 {
+		result += "\n\n" + report
+	}
+	return result, nil
+}
+
 func (o *GitHubOptions) setDefaultValues(git GitOptions, tplExecutorFunc templateExecutor) error {
 	if len(o.PullRequest.Title) == 0 {
 		o.PullRequest.Title = git.CommitTitle
 	}
-	if len(o.PullRequest.Body) == 0 {
-		o.PullRequest.Body = git.CommitBody
-	}
-	if len(git.CommitFooter) > 0 {
-		o.PullRequest.Body += fmt.Sprintf("\n\n-- \n%s", git.CommitFooter)
-	}
 
 	prTitle, err := tplExecutorFunc(o.PullRequest.Title)
 	if err != nil {
@@ -193,11 +224,23 @@ func (o *GitHubOptions) setDefaultValues(git GitOptions, tplExecutorFunc templat
 	}
 	o.PullRequest.Title = prTitle
 
-	prBody, err := tplExecutorFunc(o.PullRequest.Body)
-	if err != nil {
-		return fmt.Errorf("failed to run template for pull request body %s: %w", o.PullRequest.Body, err)
+	if len(o.PullRequest.Body) > 0 {
+		prBody, err := tplExecutorFunc(o.PullRequest.Body)
+		if err != nil {
+			return fmt.Errorf("failed to run template for pull request body %s: %w", o.PullRequest.Body, err)
+		}
+		o.PullRequest.Body = prBody
+	} else {
+		// the commit body has already been executed - and it may hold the reports of the changes, which must be kept as is
+		o.PullRequest.Body = git.CommitBody
+	}
+	if len(git.CommitFooter) > 0 {
+		footer, err := tplExecutorFunc(git.CommitFooter)
+		if err != nil {
+			return fmt.Errorf("failed to run template for git commit footer %s: %w", git.CommitFooter, err)
+		}
+		o.PullRequest.Body += fmt.Sprintf("\n\n-- \n%s", footer)
 	}
-	o.PullRequest.Body = prBody
 
 	prMergeCommitTitle, err := tplExecutorFunc(o.PullRequest.Merge.CommitTitle)
 	if err != nil {
@@ -1,11 +1,18 @@
 package repository
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/dailymotion-oss/octopilot/update"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/changelog"
+	"github.com/dailymotion-oss/octopilot/update/lineinfile"
+	"github.com/dailymotion-oss/octopilot/update/submodule"
+	"github.com/dailymotion-oss/octopilot/update/value"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/mholt/archiver"
@@ -118,3 +125,145 @@ func TestAdjustOptionsFromGitRepository(t *testing.T) {
 		})
 	}
 }
+
+// reportingUpdater is an updater which reports the changes recorded by the tests - with a templated message.
+type reportingUpdater struct {
+	change.Recorder
+}
+
+func (u *reportingUpdater) Update(_ context.Context, _ string) (bool, error) { return true, nil }
+
+func (u *reportingUpdater) Message() (string, string) {
+	return "Update {{ .repo.Name }}", "Updating `{{ .repo.FullName }}`"
+}
+
+func (u *reportingUpdater) String() string { return "Reporting" }
+
+func TestSetDefaultValues(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		// updaters returns the updaters - which already updated the repository cloned at the given path
+		updaters      func(t *testing.T, repoPath string) []update.Updater
+		options       GitOptions
+		expectedTitle string
+		expectedBody  string
+	}{
+		{
+			name: "changes holding templates",
+			updaters: func(_ *testing.T, repoPath string) []update.Updater {
+				updater := &reportingUpdater{}
+				updater.Record(repoPath, change.Change{File: "templates/deployment.yaml", Name: "labels", Old: `{{ include "chart.labels" . }}`, New: "{{ .Values.labels }}"})
+				return []update.Updater{updater}
+			},
+			expectedTitle: "Update repo",
+			expectedBody:  "Updating `owner/repo`\n\n- `templates/deployment.yaml`: `labels` from `{{ include \"chart.labels\" . }}` to `{{ .Values.labels }}`",
+		},
+		{
+			name: "multiple updaters with changes holding templates",
+			updaters: func(_ *testing.T, repoPath string) []update.Updater {
+				first, second := &reportingUpdater{}, &reportingUpdater{}
+				first.Record(repoPath, change.Change{Name: "first", New: "{{ .Values.first }}"})
+				second.Record(repoPath, change.Change{Name: "second", Old: "{{ .Values.second }}"})
+				return []update.Updater{first, second}
+			},
+			expectedTitle: "Octopilot update",
+			expectedBody:  "Updates:\n\n### Reporting\nUpdate repo\nUpdating `owner/repo`\n\n- `first` set to `{{ .Values.first }}`\n\n### Reporting\nUpdate repo\nUpdating `owner/repo`\n\n- `second` removed (was `{{ .Values.second }}`)",
+		},
+		{
+			name: "submodule commit log holding templates",
+			updaters: func(_ *testing.T, repoPath string) []update.Updater {
+				updater := &submodule.SubmoduleUpdater{Path: "protos"}
+				updater.Record(repoPath, change.Change{Name: "protos", Old: "1234567", New: "89abcde"})
+				updater.SetDetails(repoPath, "Commits in `protos` from `1234567` to `89abcde`:\n\n- `89abcde` Render {{ .Values.name }} in the {{ template }} docs")
+				return []update.Updater{updater}
+			},
+			expectedTitle: "Update submodule protos",
+			expectedBody:  "Updating git submodule `protos`\n\n- `protos` from `1234567` to `89abcde`\n\nCommits in `protos` from `1234567` to `89abcde`:\n\n- `89abcde` Render {{ .Values.name }} in the {{ template }} docs",
+		},
+		{
+			name: "lineinfile updates of Helm template lines",
+			updaters: func(t *testing.T, repoPath string) []update.Updater {
+				t.Helper()
+				require.NoError(t, os.MkdirAll(filepath.Join(repoPath, "templates"), 0755))
+				require.NoError(t, os.WriteFile(filepath.Join(repoPath, "templates", "deployment.yaml"), []byte(`metadata:
+  labels: {{ include "chart.labels" . }}
+spec:
+  image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+`), 0644))
+				replacer, err := lineinfile.NewUpdater(map[string]string{"file": "templates/deployment.yaml", "regexp": "^  image:", "line": `  image: "registry.example.com/app:1.2.3"`})
+				require.NoError(t, err)
+				remover, err := lineinfile.NewUpdater(map[string]string{"file": "templates/deployment.yaml", "regexp": "^  labels:", "state": "absent"})
+				require.NoError(t, err)
+				updaters := []update.Updater{replacer, remover}
+				for _, updater := range updaters {
+					updated, err := updater.Update(context.Background(), repoPath)
+					require.NoError(t, err)
+					require.True(t, updated)
+				}
+				return updaters
+			},
+			expectedTitle: "Octopilot update",
+			expectedBody: "Updates:\n\n" +
+				"### LineInFile[file=templates/deployment.yaml,line=  image: \"registry.example.com/app:1.2.3\",regexp=^  image:,after=,before=,state=present,create=false]\n" +
+				"Update templates/deployment.yaml\n" +
+				"Ensuring line `  image: \"registry.example.com/app:1.2.3\"` is present in file(s) `templates/deployment.yaml`\n\n" +
+				"- `templates/deployment.yaml`: `line 4` from `  image: \"{{ .Values.image.repository }}:{{ .Values.image.tag }}\"` to `  image: \"registry.example.com/app:1.2.3\"`\n\n" +
+				"### LineInFile[file=templates/deployment.yaml,line=,regexp=^  labels:,after=,before=,state=absent,create=false]\n" +
+				"Remove line from templates/deployment.yaml\n" +
+				"Removing the lines matching `^  labels:` from file(s) `templates/deployment.yaml`\n\n" +
+				"- `templates/deployment.yaml`: `line 2` removed (was `  labels: {{ include \"chart.labels\" . }}`)",
+		},
+		{
+			name: "changelog entry rendered as a template",
+			updaters: func(t *testing.T, repoPath string) []update.Updater {
+				t.Helper()
+				require.NoError(t, os.WriteFile(filepath.Join(repoPath, "CHANGELOG.md"), []byte("# Changelog\n\n## [Unreleased]\n"), 0644))
+				updater, err := changelog.NewUpdater(map[string]string{}, value.StringValuer(`Document the {{ "{{ .Values.image.tag }}" }} value`))
+				require.NoError(t, err)
+				updated, err := updater.Update(context.Background(), repoPath)
+				require.NoError(t, err)
+				require.True(t, updated)
+				return []update.Updater{updater}
+			},
+			expectedTitle: "Update CHANGELOG.md",
+			expectedBody:  "Adding a `Changed` entry to the `Unreleased` section of file(s) `CHANGELOG.md`\n\n- `CHANGELOG.md`: `Changed` set to `Document the {{ .Values.image.tag }} value`",
+		},
+		{
+			name: "custom templates using changes holding templates",
+			updaters: func(_ *testing.T, repoPath string) []update.Updater {
+				updater := &reportingUpdater{}
+				updater.Record(repoPath, change.Change{Name: "labels", New: "{{ .Values.labels }}"})
+				return []update.Updater{updater}
+			},
+			options: GitOptions{
+				CommitTitle: "Update {{ (index .changes 0).Name }}",
+				CommitBody:  "Set to {{ (index .changes 0).New }}",
+			},
+			expectedTitle: "Update labels",
+			expectedBody:  "Set to {{ .Values.labels }}",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var (
+				repoPath = t.TempDir()
+				repo     = Repository{Owner: "owner", Name: "repo"}
+				updaters = test.updaters(t, repoPath)
+				executor = templateExecutorFor(UpdateOptions{}, repo, repoPath, updaters)
+				options  = test.options
+			)
+			require.NoError(t, options.setDefaultValues(updaters, repoPath, executor))
+			assert.Equal(t, test.expectedTitle, options.CommitTitle)
+			assert.Equal(t, test.expectedBody, options.CommitBody)
+
+			githubOptions := GitHubOptions{}
+			require.NoError(t, githubOptions.setDefaultValues(options, executor))
+			assert.Equal(t, test.expectedTitle, githubOptions.PullRequest.Title)
+			assert.Equal(t, test.expectedBody, githubOptions.PullRequest.Body)
+		})
+	}
+}
@@ -9,8 +9,11 @@ import (
 	"regexp"
 	"strconv"
 
+	"github.com/dailymotion-oss/octopilot/internal/ghclient"
 	"github.com/dailymotion-oss/octopilot/internal/parameters"
+	"github.com/dailymotion-oss/octopilot/internal/tpl"
 	"github.com/dailymotion-oss/octopilot/update"
+	"github.com/dailymotion-oss/octopilot/update/change"
 	"github.com/google/go-github/v57/github"
 	"github.com/rs/xid"
 	"github.com/sirupsen/logrus"
@@ -65,7 +68,7 @@ func Parse(ctx context.Context, repos []string, githubOpts GitHubOptions) ([]Rep
 
 		switch matches[1] {
 		case "discover-from":
-			discoveredRepos, err := discoverRepositoriesFrom(ctx, parameters.Parse(matches[2]), githubOpts)
+			discoveredRepos, err := Discover(ctx, parameters.Parse(matches[2]), githubOpts)
 			if err != nil {
 				return nil, fmt.Errorf("failed to discover repositories: %w", err)
 			}
@@ -87,7 +90,27 @@ func Parse(ctx context.Context, repos []string, githubOpts GitHubOptions) ([]Rep
 	return removeDuplicate(repositories), nil
 }
 
-func discoverRepositoriesFrom(ctx context.Context, params map[string]string, githubOpts GitHubOptions) ([]Repository, error) {
+// New returns a static repository, defined by its full name - in the form "owner/name" - and its optional params.
+func New(fullName string, params map[string]string) (Repository, error) {
+	matches := repoWithNameRegexp.FindStringSubmatch(fullName)
+	if len(matches) < 4 || len(matches[3]) > 0 {
+		return Repository{}, fmt.Errorf("invalid repository name %s: expected owner/name", fullName)
+	}
+
+	if params == nil {
+		params = map[string]string{}
+	}
+	return Repository{
+		Owner:  matches[1],
+		Name:   matches[2],
+		Params: params,
+	}, nil
+}
+
+// Discover returns the repositories dynamically discovered from the given params:
+// either from a GitHub search query (the "query" param) or from an environment variable (the "env" param).
+// Expected params are documented in the user documentation: docs/current-version/content/repos/dynamic.md
+func Discover(ctx context.Context, params map[string]string, githubOpts GitHubOptions) ([]Repository, error) {
 	searchType := parseSearchType(params["searchtype"])
 	if query, ok := params["query"]; ok {
 		return discoverRepositoriesFromQuery(ctx, searchType, query, params, githubOpts)
@@ -141,6 +164,15 @@ func (r Repository) Update(ctx context.Context, updaters []update.Updater, optio
 		strategy = NewResetStrategy(r, repoPath, updaters, options)
 	}
 
+	// share the GitHub client and credentials - and the templates data - with the updaters and valuers that need them
+	client, token, err := githubClient(ctx, options.GitHub)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to create GitHub client: %w", err)
+	}
+	ctx = ghclient.NewContext(ctx, client)
+	ctx = ghclient.NewCredentialsContext(ctx, ghclient.Credentials{URL: options.GitHub.URL, Token: token})
+	ctx = tpl.NewContext(ctx, templateData(r, nil))
+
 	repoUpdated, pr, err := strategy.Run(ctx)
 	if err != nil {
 		return false, pr, fmt.Errorf("%w", err)
@@ -177,7 +209,8 @@ func (r Repository) runUpdaters(ctx context.Context, updaters []update.Updater,
 			"repository": r.FullName(),
 			"updater":    updater.String(),
 		}).Trace("Running updater")
-		updated, err := updater.Update(ctx, repoPath)
+		// let the updater know if the previous ones changed something - such as the bump updater, which only bumps versions of updated repositories
+		updated, err := updater.Update(change.NewContext(ctx, repoUpdated), repoPath)
 		if err != nil {
 			return false, fmt.Errorf("failed to update repository %s: %w", r.FullName(), err)
 		}
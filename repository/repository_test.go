@@ -5,6 +5,8 @@ import (
 	"os"
 	"testing"
 
+	"github.com/dailymotion-oss/octopilot/update"
+	"github.com/dailymotion-oss/octopilot/update/change"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -159,3 +161,94 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		fullName         string
+		params           map[string]string
+		expected         Repository
+		expectedErrorMsg string
+	}{
+		{
+			name:     "repository without parameters",
+			fullName: "dailymotion-oss/octopilot",
+			expected: Repository{
+				Owner:  "dailymotion-oss",
+				Name:   "octopilot",
+				Params: map[string]string{},
+			},
+		},
+		{
+			name:     "repository with parameters",
+			fullName: "dailymotion-oss/octopilot",
+			params: map[string]string{
+				"merge": "true",
+			},
+			expected: Repository{
+				Owner: "dailymotion-oss",
+				Name:  "octopilot",
+				Params: map[string]string{
+					"merge": "true",
+				},
+			},
+		},
+		{
+			name:             "missing owner",
+			fullName:         "octopilot",
+			expectedErrorMsg: "invalid repository name octopilot: expected owner/name",
+		},
+		{
+			name:             "string-encoded parameters",
+			fullName:         "dailymotion-oss/octopilot(merge=true)",
+			expectedErrorMsg: "invalid repository name dailymotion-oss/octopilot(merge=true): expected owner/name",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := New(test.fullName, test.params)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+// contextRecorderUpdater is an updater which records whether the previous updaters updated the repository.
+type contextRecorderUpdater struct {
+	updated         bool
+	previousUpdated bool
+}
+
+func (u *contextRecorderUpdater) Update(ctx context.Context, _ string) (bool, error) {
+	u.previousUpdated = change.UpdatedFromContext(ctx)
+	return u.updated, nil
+}
+
+func (u *contextRecorderUpdater) Message() (string, string) { return "", "" }
+
+func (u *contextRecorderUpdater) String() string { return "ContextRecorder" }
+
+func TestRunUpdaters(t *testing.T) {
+	t.Parallel()
+
+	updaters := []*contextRecorderUpdater{
+		{updated: false},
+		{updated: true},
+		{updated: false},
+	}
+	repo := Repository{Owner: "owner", Name: "repo"}
+	updated, err := repo.runUpdaters(context.Background(), []update.Updater{updaters[0], updaters[1], updaters[2]}, ".")
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.False(t, updaters[0].previousUpdated)
+	assert.False(t, updaters[1].previousUpdated)
+	assert.True(t, updaters[2].previousUpdated)
+}
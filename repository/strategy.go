@@ -65,10 +65,10 @@ func (s *Strategy) Run(ctx context.Context) (bool, *github.PullRequest, error) {
 		return false, existingPR, nil
 	}
 
-	if err = s.Options.Git.setDefaultValues(s.Updaters, templateExecutorFor(s.Options, s.Repository, s.RepoPath)); err != nil {
+	if err = s.Options.Git.setDefaultValues(s.Updaters, s.RepoPath, templateExecutorFor(s.Options, s.Repository, s.RepoPath, s.Updaters)); err != nil {
 		return false, existingPR, fmt.Errorf("failed to set default git values: %w", err)
 	}
-	if err = s.Options.GitHub.setDefaultValues(s.Options.Git, templateExecutorFor(s.Options, s.Repository, s.RepoPath)); err != nil {
+	if err = s.Options.GitHub.setDefaultValues(s.Options.Git, templateExecutorFor(s.Options, s.Repository, s.RepoPath, s.Updaters)); err != nil {
 		return false, existingPR, fmt.Errorf("failed to set default github values: %w", err)
 	}
 	if len(s.DefaultUpdateOperation) > 0 {
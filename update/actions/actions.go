@@ -0,0 +1,294 @@
+// Package actions provides an updater that updates the GitHub Actions used in workflows and composite actions.
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dailymotion-oss/octopilot/internal/ghclient"
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+)
+
+// PinMode defines if the actions references should be pinned to a commit SHA
+type PinMode string
+
+// Pin modes
+const (
+	// PinAuto pins the references which are already pinned to a commit SHA, and keeps the tags for the others
+	PinAuto PinMode = "auto"
+	// PinAlways pins all the references to a commit SHA
+	PinAlways PinMode = "always"
+	// PinNever uses the tags for all the references
+	PinNever PinMode = "never"
+)
+
+var (
+	// the default files: the workflows, and the composite actions
+	defaultFilePaths = []string{
+		".github/workflows/*.yml",
+		".github/workflows/*.yaml",
+		"**/action.yml",
+		"**/action.yaml",
+	}
+
+	// uses: owner/repo/path@ref # comment
+	usesRegexp = regexp.MustCompile(`^(\s*(?:-\s+)?uses:\s*)(["']?)([^\s"'#@]+)@([^\s"'#]+)(["']?)(?:(\s+)#\s*(.*?))?\s*$`)
+	shaRegexp  = regexp.MustCompile(`^[0-9a-f]{40}$`)
+)
+
+// ActionsUpdater is an updater that updates the version of a GitHub Action in the `uses` references of workflows and composite actions.
+type ActionsUpdater struct {
+	FilePaths []string
+	Uses      string
+	Pin       PinMode
+	Valuer    value.Valuer
+
+	change.Recorder
+
+	// resolutions of the commit SHAs, by action repository and tag - shared by all the repositories
+	shasMutex sync.Mutex
+	shas      map[string]*resolution
+}
+
+// resolution is the resolution of the commit SHA of a tag, which is closed once done - so that concurrent callers can wait for it.
+type resolution struct {
+	done chan struct{}
+	sha  string
+	err  error
+}
+
+// NewUpdater builds a new GitHub Actions updater from the given parameters and valuer
+func NewUpdater(params map[string]string, valuer value.Valuer) (*ActionsUpdater, error) {
+	updater := &ActionsUpdater{}
+
+	updater.FilePaths = defaultFilePaths
+	if filePath := params["file"]; len(filePath) > 0 {
+		updater.FilePaths = []string{filePath}
+	}
+
+	updater.Uses = params["uses"]
+	if len(updater.Uses) == 0 {
+		return nil, errors.New("missing uses parameter")
+	}
+	if strings.Contains(updater.Uses, "@") {
+		return nil, fmt.Errorf("invalid uses parameter %s: it must not contain a version - you can use %s instead", updater.Uses, strings.SplitN(updater.Uses, "@", 2)[0])
+	}
+	if len(strings.Split(updater.Uses, "/")) < 2 {
+		return nil, fmt.Errorf("invalid uses parameter %s: expected an action in the owner/repo format", updater.Uses)
+	}
+
+	updater.Pin = PinMode(params["pin"])
+	switch updater.Pin {
+	case "":
+		updater.Pin = PinAuto
+	case PinAuto, PinAlways, PinNever:
+	default:
+		return nil, fmt.Errorf("invalid pin parameter %s: must be one of %s, %s or %s", updater.Pin, PinAuto, PinAlways, PinNever)
+	}
+
+	updater.Valuer = valuer
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *ActionsUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	u.Reset(repoPath)
+
+	tag, err := u.Valuer.Value(ctx, repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get value: %w", err)
+	}
+	tag = strings.TrimSpace(tag)
+	if len(tag) == 0 {
+		return false, errors.New("invalid empty value: expected a tag")
+	}
+
+	var filePaths []string
+	for _, pattern := range u.FilePaths {
+		paths, err := glob.ExpandGlobPattern(repoPath, pattern)
+		if err != nil {
+			return false, fmt.Errorf("failed to expand glob pattern %s: %w", pattern, err)
+		}
+		filePaths = append(filePaths, paths...)
+	}
+
+	var updated bool
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+		}
+
+		newContent, changes, err := u.updateContent(ctx, string(content), tag)
+		if err != nil {
+			return false, fmt.Errorf("failed to update file %s: %w", relFilePath, err)
+		}
+		if newContent == string(content) {
+			continue
+		}
+		for i := range changes {
+			changes[i].File = filepath.ToSlash(relFilePath)
+		}
+
+		if err = os.WriteFile(filePath, []byte(newContent), fileInfo.Mode()); err != nil {
+			return false, fmt.Errorf("failed to write updated content to file %s: %w", relFilePath, err)
+		}
+
+		u.Record(repoPath, changes...)
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *ActionsUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Update GitHub Action %s", u.Uses)
+	body = fmt.Sprintf("Updating GitHub Action `%s` in file(s) `%s`", u.Uses, strings.Join(u.FilePaths, "`, `"))
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *ActionsUpdater) String() string {
+	return fmt.Sprintf("Actions[uses=%s,file=%s,pin=%s]", u.Uses, strings.Join(u.FilePaths, ";"), u.Pin)
+}
+
+// updateContent updates all the `uses` lines referencing the action, line by line - so that the rest of the YAML formatting is kept.
+func (u *ActionsUpdater) updateContent(ctx context.Context, content, tag string) (string, []change.Change, error) {
+	var (
+		lines   = strings.SplitAfter(content, "\n")
+		changes []change.Change
+	)
+	for i, line := range lines {
+		eol := line[len(strings.TrimRight(line, "\r\n")):]
+		matches := usesRegexp.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+		if len(matches) == 0 {
+			continue
+		}
+		var (
+			prefix, quote, action, oldRef = matches[1], matches[2], matches[3], matches[4]
+			closingQuote, commentSpaces   = matches[5], matches[6]
+			oldComment                    = matches[7]
+		)
+		if !u.matches(action) {
+			continue
+		}
+
+		newRef, newComment := tag, oldComment
+		if oldPinned := shaRegexp.MatchString(oldRef); u.Pin == PinAlways || (u.Pin == PinAuto && oldPinned) {
+			sha, err := u.resolve(ctx, action, tag)
+			if err != nil {
+				return "", nil, err
+			}
+			newRef, newComment = sha, tag
+		} else if oldPinned {
+			// the comment was the tag of the pinned commit
+			newComment = ""
+		}
+		if len(commentSpaces) == 0 {
+			commentSpaces = " "
+		}
+
+		newLine := prefix + quote + action + "@" + newRef + closingQuote
+		if len(newComment) > 0 {
+			newLine += commentSpaces + "# " + newComment
+		}
+		if newLine == strings.TrimRight(line, "\r\n") {
+			continue
+		}
+		lines[i] = newLine + eol
+
+		oldVersion, newVersion := version(oldRef, oldComment), version(newRef, newComment)
+		if c := (change.Change{Name: action, Old: oldVersion, New: newVersion}); oldVersion != newVersion && !slices.Contains(changes, c) {
+			changes = append(changes, c)
+		}
+	}
+	return strings.Join(lines, ""), changes, nil
+}
+
+// matches returns true if the given action is the one to update - or one of its sub-actions, such as github/codeql-action/init for github/codeql-action.
+func (u *ActionsUpdater) matches(action string) bool {
+	return strings.EqualFold(action, u.Uses) || strings.HasPrefix(strings.ToLower(action), strings.ToLower(u.Uses)+"/")
+}
+
+// resolve returns the SHA of the commit referenced by the given tag, in the repository of the given action.
+// The results are cached, because the same action is usually used in multiple files and repositories.
+// Only the resolution of the same tag is shared between concurrent callers - and its failure is not cached, so that it can be retried.
+func (u *ActionsUpdater) resolve(ctx context.Context, action, tag string) (string, error) {
+	parts := strings.SplitN(action, "/", 3)
+	owner, repo := parts[0], parts[1]
+	key := strings.ToLower(owner+"/"+repo) + "@" + tag
+
+	u.shasMutex.Lock()
+	r, found := u.shas[key]
+	if !found {
+		if u.shas == nil {
+			u.shas = make(map[string]*resolution)
+		}
+		r = &resolution{done: make(chan struct{})}
+		u.shas[key] = r
+	}
+	u.shasMutex.Unlock()
+
+	if found {
+		select {
+		case <-r.done:
+			return r.sha, r.err
+		case <-ctx.Done():
+			return "", fmt.Errorf("failed to resolve %s/%s@%s: %w", owner, repo, tag, ctx.Err())
+		}
+	}
+
+	r.sha, r.err = resolveSHA(ctx, owner, repo, tag)
+	if r.err != nil {
+		u.shasMutex.Lock()
+		delete(u.shas, key)
+		u.shasMutex.Unlock()
+	}
+	close(r.done)
+	return r.sha, r.err
+}
+
+// resolveSHA returns the SHA of the commit referenced by the given tag, using the GitHub API.
+func resolveSHA(ctx context.Context, owner, repo, tag string) (string, error) {
+	client, err := ghclient.FromContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s/%s@%s: %w", owner, repo, tag, err)
+	}
+	sha, _, err := client.Repositories.GetCommitSHA1(ctx, owner, repo, tag, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s/%s@%s: %w", owner, repo, tag, err)
+	}
+	return sha, nil
+}
+
+// version returns a human-readable version of a reference: the tag, with the short commit SHA if it's pinned.
+func version(ref, comment string) string {
+	if !shaRegexp.MatchString(ref) {
+		return ref
+	}
+	if len(comment) > 0 {
+		return fmt.Sprintf("%s (%s)", comment, ref[:7])
+	}
+	return ref[:7]
+}
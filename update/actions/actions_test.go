@@ -0,0 +1,429 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dailymotion-oss/octopilot/internal/ghclient"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	checkoutSHA = "b4ffde65f46336ab88eb53be808477a3936bae11"
+	codeqlSHA   = "e8893c57a1f3a2b659b6b55564fdfdbbd2982911"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *ActionsUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params with default files",
+			params: map[string]string{
+				"uses": "actions/checkout",
+			},
+			expected: &ActionsUpdater{
+				FilePaths: defaultFilePaths,
+				Uses:      "actions/checkout",
+				Pin:       PinAuto,
+			},
+		},
+		{
+			name: "valid params with custom file",
+			params: map[string]string{
+				"file": ".github/workflows/release.yml",
+				"uses": "github/codeql-action",
+				"pin":  "always",
+			},
+			expected: &ActionsUpdater{
+				FilePaths: []string{".github/workflows/release.yml"},
+				Uses:      "github/codeql-action",
+				Pin:       PinAlways,
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing uses parameter",
+		},
+		{
+			name: "uses with version",
+			params: map[string]string{
+				"uses": "actions/checkout@v4",
+			},
+			expectedErrorMsg: "invalid uses parameter actions/checkout@v4: it must not contain a version - you can use actions/checkout instead",
+		},
+		{
+			name: "uses without owner",
+			params: map[string]string{
+				"uses": "checkout",
+			},
+			expectedErrorMsg: "invalid uses parameter checkout: expected an action in the owner/repo format",
+		},
+		{
+			name: "invalid pin",
+			params: map[string]string{
+				"uses": "actions/checkout",
+				"pin":  "sometimes",
+			},
+			expectedErrorMsg: "invalid pin parameter sometimes: must be one of auto, always or never",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params, nil)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/actions/checkout/commits/v4.1.1":
+			fmt.Fprint(w, checkoutSHA)
+		case "/repos/github/codeql-action/commits/v3.22.12":
+			fmt.Fprint(w, codeqlSHA)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	ctx := ghclient.NewContext(context.Background(), client)
+
+	tests := []struct {
+		name             string
+		files            map[string]string
+		updater          *ActionsUpdater
+		ctx              context.Context
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+		expectedChanges  []change.Change
+	}{
+		{
+			name: "update tags and pinned references",
+			files: map[string]string{
+				"auto/.github/workflows/ci.yml": `name: CI
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v3
+      - name: Checkout other
+        uses: "actions/checkout@f43a0e5ff2bd294095638e18286ca9a3d1956744"  # v3.6.0
+        with:
+          repository: org/other
+      - uses: actions/setup-go@v5
+`,
+				"auto/.github/actions/build/action.yaml": "runs:\r\n  using: composite\r\n  steps:\r\n    - uses: actions/checkout@f43a0e5ff2bd294095638e18286ca9a3d1956744\r\n",
+			},
+			updater: &ActionsUpdater{
+				FilePaths: []string{"auto/.github/workflows/*.yml", "auto/**/action.yaml"},
+				Uses:      "actions/checkout",
+				Pin:       PinAuto,
+				Valuer:    value.StringValuer("v4.1.1"),
+			},
+			ctx:      ctx,
+			expected: true,
+			expectedFiles: map[string]string{
+				"auto/.github/workflows/ci.yml": `name: CI
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4.1.1
+      - name: Checkout other
+        uses: "actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11"  # v4.1.1
+        with:
+          repository: org/other
+      - uses: actions/setup-go@v5
+`,
+				"auto/.github/actions/build/action.yaml": "runs:\r\n  using: composite\r\n  steps:\r\n    - uses: actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11 # v4.1.1\r\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "auto/.github/actions/build/action.yaml", Name: "actions/checkout", Old: "f43a0e5", New: "v4.1.1 (b4ffde6)"},
+				{File: "auto/.github/workflows/ci.yml", Name: "actions/checkout", Old: "v3", New: "v4.1.1"},
+				{File: "auto/.github/workflows/ci.yml", Name: "actions/checkout", Old: "v3.6.0 (f43a0e5)", New: "v4.1.1 (b4ffde6)"},
+			},
+		},
+		{
+			name: "always pin sub-actions",
+			files: map[string]string{
+				"always/.github/workflows/codeql.yml": `steps:
+  - uses: github/codeql-action/init@v2 # init
+  - uses: github/codeql-action/analyze@v2
+  - uses: github/codeql-action-other@v2
+`,
+			},
+			updater: &ActionsUpdater{
+				FilePaths: []string{"always/.github/workflows/*.yml"},
+				Uses:      "github/codeql-action",
+				Pin:       PinAlways,
+				Valuer:    value.StringValuer("v3.22.12"),
+			},
+			ctx:      ctx,
+			expected: true,
+			expectedFiles: map[string]string{
+				"always/.github/workflows/codeql.yml": `steps:
+  - uses: github/codeql-action/init@e8893c57a1f3a2b659b6b55564fdfdbbd2982911 # v3.22.12
+  - uses: github/codeql-action/analyze@e8893c57a1f3a2b659b6b55564fdfdbbd2982911 # v3.22.12
+  - uses: github/codeql-action-other@v2
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "always/.github/workflows/codeql.yml", Name: "github/codeql-action/init", Old: "v2", New: "v3.22.12 (e8893c5)"},
+				{File: "always/.github/workflows/codeql.yml", Name: "github/codeql-action/analyze", Old: "v2", New: "v3.22.12 (e8893c5)"},
+			},
+		},
+		{
+			name: "never pin",
+			files: map[string]string{
+				"never/.github/workflows/ci.yml": `steps:
+  - uses: actions/checkout@f43a0e5ff2bd294095638e18286ca9a3d1956744 # v3.6.0
+  - uses: actions/checkout@v4.1.1
+`,
+			},
+			updater: &ActionsUpdater{
+				FilePaths: []string{"never/.github/workflows/*.yml"},
+				Uses:      "actions/checkout",
+				Pin:       PinNever,
+				Valuer:    value.StringValuer("v4.1.1"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"never/.github/workflows/ci.yml": `steps:
+  - uses: actions/checkout@v4.1.1
+  - uses: actions/checkout@v4.1.1
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "never/.github/workflows/ci.yml", Name: "actions/checkout", Old: "v3.6.0 (f43a0e5)", New: "v4.1.1"},
+			},
+		},
+		{
+			name: "no changes",
+			files: map[string]string{
+				"no-changes/.github/workflows/ci.yml": `steps:
+  - uses: actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11 # v4.1.1
+  - uses: ./.github/actions/local
+`,
+			},
+			updater: &ActionsUpdater{
+				FilePaths: []string{"no-changes/.github/workflows/*.yml"},
+				Uses:      "actions/checkout",
+				Pin:       PinAuto,
+				Valuer:    value.StringValuer("v4.1.1"),
+			},
+			ctx:      ctx,
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-changes/.github/workflows/ci.yml": `steps:
+  - uses: actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11 # v4.1.1
+  - uses: ./.github/actions/local
+`,
+			},
+		},
+		{
+			name: "unknown tag",
+			files: map[string]string{
+				"unknown-tag/.github/workflows/ci.yml": `steps:
+  - uses: actions/checkout@v4.1.1
+`,
+			},
+			updater: &ActionsUpdater{
+				FilePaths: []string{"unknown-tag/.github/workflows/*.yml"},
+				Uses:      "actions/checkout",
+				Pin:       PinAlways,
+				Valuer:    value.StringValuer("v999"),
+			},
+			ctx:              ctx,
+			expectedErrorMsg: fmt.Sprintf("failed to update file unknown-tag/.github/workflows/ci.yml: failed to resolve actions/checkout@v999: GET %s/repos/actions/checkout/commits/v999: 404 Not Found []", server.URL),
+		},
+		{
+			name: "no github client",
+			files: map[string]string{
+				"no-client/.github/workflows/ci.yml": `steps:
+  - uses: actions/checkout@v4.1.1
+`,
+			},
+			updater: &ActionsUpdater{
+				FilePaths: []string{"no-client/.github/workflows/*.yml"},
+				Uses:      "actions/checkout",
+				Pin:       PinAlways,
+				Valuer:    value.StringValuer("v4.1.1"),
+			},
+			expectedErrorMsg: "failed to update file no-client/.github/workflows/ci.yml: failed to resolve actions/checkout@v4.1.1: no GitHub client available",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			ctx := test.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			actual, err := test.updater.Update(ctx, "testdata")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+				for filename, expectedContent := range test.expectedFiles {
+					actualContent, err := os.ReadFile(filepath.Join("testdata", filename))
+					require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+					assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+				}
+				assert.Equal(t, test.expectedChanges, test.updater.Changes("testdata"))
+			}
+		})
+	}
+}
+
+func TestResolveCache(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fmt.Fprint(w, checkoutSHA)
+	}))
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	ctx := ghclient.NewContext(context.Background(), client)
+
+	updater := &ActionsUpdater{Uses: "actions/checkout"}
+	for i := 0; i < 3; i++ {
+		sha, err := updater.resolve(ctx, "actions/checkout", "v4.1.1")
+		require.NoError(t, err)
+		assert.Equal(t, checkoutSHA, sha)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&requests))
+}
+
+func TestResolveConcurrently(t *testing.T) {
+	t.Parallel()
+
+	var (
+		requests         int32
+		checkoutReceived = make(chan struct{})
+		codeqlReceived   = make(chan struct{})
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if strings.Contains(r.URL.Path, "/codeql-action/") {
+			close(codeqlReceived)
+			fmt.Fprint(w, codeqlSHA)
+			return
+		}
+		close(checkoutReceived)
+		// the checkout action is resolved only once the codeql action is being resolved too - which can't happen if they are resolved one at a time
+		select {
+		case <-codeqlReceived:
+			fmt.Fprint(w, checkoutSHA)
+		case <-time.After(5 * time.Second):
+			w.WriteHeader(http.StatusGatewayTimeout)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	ctx := ghclient.NewContext(context.Background(), client)
+
+	updater := &ActionsUpdater{Uses: "actions/checkout"}
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sha, err := updater.resolve(ctx, "actions/checkout", "v4.1.1")
+			assert.NoError(t, err)
+			assert.Equal(t, checkoutSHA, sha)
+		}()
+	}
+
+	<-checkoutReceived
+	sha, err := updater.resolve(ctx, "github/codeql-action/init", "v3.22.12")
+	require.NoError(t, err)
+	assert.Equal(t, codeqlSHA, sha)
+
+	wg.Wait()
+	assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+}
+
+func TestResolveFailureIsNotCached(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, checkoutSHA)
+	}))
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	ctx := ghclient.NewContext(context.Background(), client)
+
+	updater := &ActionsUpdater{Uses: "actions/checkout"}
+	_, err := updater.resolve(ctx, "actions/checkout", "v4.1.1")
+	require.Error(t, err)
+
+	sha, err := updater.resolve(ctx, "actions/checkout", "v4.1.1")
+	require.NoError(t, err)
+	assert.Equal(t, checkoutSHA, sha)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+}
@@ -0,0 +1,371 @@
+// Package bump provides an updater that increments a semantic version defined in a file.
+package bump
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/mikefarah/yq/v4/pkg/yqlib"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	"github.com/dailymotion-oss/octopilot/internal/json"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	yamlupdater "github.com/dailymotion-oss/octopilot/update/yaml"
+)
+
+// Part is the part of the version to increment.
+type Part string
+
+// The supported parts
+const (
+	PartMajor      Part = "major"
+	PartMinor      Part = "minor"
+	PartPatch      Part = "patch"
+	PartPrerelease Part = "prerelease"
+)
+
+// BumpUpdater is an updater that increments a semantic version defined in a file:
+// the whole content of the file, a value selected by a YAML or JSON path, or a value matched by a regex.
+type BumpUpdater struct {
+	FilePath string
+	// Path is the YAML or JSON path of the version - depending on the extension of the file
+	Path    string
+	Pattern string
+	Regexp  *regexp.Regexp
+	Part    Part
+	// PreID is the identifier of the prerelease versions, such as "rc" for 1.2.3-rc.0
+	PreID string
+	// Always is true to bump the version even if the previous updaters didn't change anything in the repository
+	Always bool
+
+	change.Recorder
+}
+
+// location is the location of a version in the content of a file.
+type location struct {
+	start, end int
+}
+
+// NewUpdater builds a new bump updater from the given parameters
+func NewUpdater(params map[string]string) (*BumpUpdater, error) {
+	updater := &BumpUpdater{}
+
+	updater.FilePath = params["file"]
+	if len(updater.FilePath) == 0 {
+		return nil, errors.New("missing file parameter")
+	}
+
+	updater.Path = params["path"]
+	updater.Pattern = params["pattern"]
+	if len(updater.Path) > 0 && len(updater.Pattern) > 0 {
+		return nil, errors.New("invalid parameters: path and pattern can't be used together")
+	}
+	if len(updater.Pattern) > 0 {
+		var err error
+		updater.Regexp, err = regexp.Compile(updater.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", updater.Pattern, err)
+		}
+		if subexp := updater.Regexp.NumSubexp(); subexp != 1 {
+			return nil, fmt.Errorf("invalid pattern %s: it must have a single parenthesized subexpression, but it has %d", updater.Pattern, subexp)
+		}
+	}
+
+	updater.Part = Part(params["part"])
+	switch updater.Part {
+	case "":
+		updater.Part = PartPatch
+	case PartMajor, PartMinor, PartPatch, PartPrerelease:
+	default:
+		return nil, fmt.Errorf("invalid part parameter %s: must be one of %s, %s, %s or %s", updater.Part, PartMajor, PartMinor, PartPatch, PartPrerelease)
+	}
+
+	updater.PreID = params["preid"]
+	if len(updater.PreID) > 0 && updater.Part != PartPrerelease {
+		return nil, fmt.Errorf("invalid preid parameter %s: it is only supported by the %s part", updater.PreID, PartPrerelease)
+	}
+
+	updater.Always, _ = strconv.ParseBool(params["always"])
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *BumpUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	u.Reset(repoPath)
+
+	if !u.Always && !change.UpdatedFromContext(ctx) {
+		// there is nothing new to release - and a new version would be bumped on every run
+		return false, nil
+	}
+
+	filePaths, err := glob.ExpandGlobPattern(repoPath, u.FilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to expand glob pattern %s: %w", u.FilePath, err)
+	}
+
+	var updated bool
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+		}
+
+		locations, err := u.findVersions(filePath, content)
+		if err != nil {
+			return false, fmt.Errorf("failed to find version in file %s: %w", relFilePath, err)
+		}
+		if len(locations) == 0 {
+			continue
+		}
+
+		var (
+			updatedContent bytes.Buffer
+			position       int
+			changes        []change.Change
+		)
+		for _, loc := range locations {
+			oldVersion := string(content[loc.start:loc.end])
+			newVersion, err := Bump(oldVersion, u.Part, u.PreID)
+			if err != nil {
+				return false, fmt.Errorf("failed to bump version in file %s: %w", relFilePath, err)
+			}
+			updatedContent.Write(content[position:loc.start])
+			updatedContent.WriteString(newVersion)
+			position = loc.end
+			changes = append(changes, change.Change{File: filepath.ToSlash(relFilePath), Name: u.name(), Old: oldVersion, New: newVersion})
+		}
+		updatedContent.Write(content[position:])
+
+		if err = os.WriteFile(filePath, updatedContent.Bytes(), fileInfo.Mode()); err != nil {
+			return false, fmt.Errorf("failed to write updated content to file %s: %w", relFilePath, err)
+		}
+		u.Record(repoPath, changes...)
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *BumpUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Bump %s version of %s", u.Part, u.FilePath)
+	body = fmt.Sprintf("Bumping the %s version of `%s` in file(s) `%s`", u.Part, u.name(), u.FilePath)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *BumpUpdater) String() string {
+	return fmt.Sprintf("Bump[file=%s,path=%s,pattern=%s,part=%s,preid=%s,always=%v]", u.FilePath, u.Path, u.Pattern, u.Part, u.PreID, u.Always)
+}
+
+func (u *BumpUpdater) name() string {
+	if len(u.Path) > 0 {
+		return u.Path
+	}
+	return "version"
+}
+
+// findVersions returns the locations of the versions in the content of the given file - sorted by position.
+func (u *BumpUpdater) findVersions(filePath string, content []byte) ([]location, error) {
+	switch {
+	case u.Regexp != nil:
+		var locations []location
+		for _, indexes := range u.Regexp.FindAllSubmatchIndex(content, -1) {
+			if indexes[2] >= 0 {
+				locations = append(locations, location{start: indexes[2], end: indexes[3]})
+			}
+		}
+		return locations, nil
+	case len(u.Path) > 0 && strings.EqualFold(filepath.Ext(filePath), ".json"):
+		return findJSONVersions(content, u.Path)
+	case len(u.Path) > 0:
+		return findYAMLVersions(content, u.Path)
+	default:
+		// the whole content of the file is the version - such as a VERSION file
+		version := bytes.TrimSpace(content)
+		if len(version) == 0 {
+			return nil, nil
+		}
+		start := bytes.Index(content, version)
+		return []location{{start: start, end: start + len(version)}}, nil
+	}
+}
+
+// findJSONVersions returns the locations of the string values matching the given JSON path - without their quotes.
+func findJSONVersions(content []byte, path string) ([]location, error) {
+	selector, err := json.ParseSelector(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := json.Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	var locations []location
+	for _, value := range selector.Select(doc) {
+		if value.Kind != json.String || strings.Contains(doc.Raw(value), `\`) {
+			return nil, fmt.Errorf("invalid value at path %s: expected a string, got %s", value.Path(), doc.Raw(value))
+		}
+		locations = append(locations, location{start: value.Start + 1, end: value.End - 1})
+	}
+	return locations, nil
+}
+
+// findYAMLVersions returns the locations of the scalar values matching the given YAML path - without their quotes.
+// The values are located using their line and column, so that the rest of the file is not changed.
+func findYAMLVersions(content []byte, path string) ([]location, error) {
+	var (
+		expression = yamlupdater.PathExpression(path)
+		evaluator  = yqlib.NewAllAtOnceEvaluator()
+		decoder    = yaml.NewDecoder(bytes.NewReader(content))
+		lineStarts = lineOffsets(content)
+		locations  []location
+	)
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+
+		matches, err := evaluator.EvaluateNodes(expression, &doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate expression `%s`: %w", expression, err)
+		}
+		for e := matches.Front(); e != nil; e = e.Next() {
+			node := e.Value.(*yqlib.CandidateNode).Node
+			if node.Kind != yaml.ScalarNode || node.Line <= 0 || node.Line > len(lineStarts) {
+				continue
+			}
+			start := lineStarts[node.Line-1]
+			for i := 1; i < node.Column && start < len(content); i++ {
+				_, size := utf8.DecodeRune(content[start:])
+				start += size
+			}
+
+			raw := node.Value
+			switch node.Style {
+			case yaml.DoubleQuotedStyle:
+				raw = `"` + node.Value + `"`
+			case yaml.SingleQuotedStyle:
+				raw = `'` + node.Value + `'`
+			case 0, yaml.TaggedStyle, yaml.FlowStyle:
+			default:
+				return nil, fmt.Errorf("invalid value at line %d: unsupported YAML scalar style", node.Line)
+			}
+			if !bytes.HasPrefix(content[start:], []byte(raw)) {
+				return nil, fmt.Errorf("invalid value at line %d: unsupported YAML syntax", node.Line)
+			}
+			if len(raw) > len(node.Value) {
+				// skip the opening quote
+				start++
+			}
+			locations = append(locations, location{start: start, end: start + len(node.Value)})
+		}
+	}
+
+	sort.Slice(locations, func(i, j int) bool { return locations[i].start < locations[j].start })
+	return locations, nil
+}
+
+// lineOffsets returns the offset of the beginning of each line of the content.
+func lineOffsets(content []byte) []int {
+	offsets := []int{0}
+	for i, b := range content {
+		if b == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// Bump increments the given part of a semantic version - keeping the optional "v" prefix.
+// For the prerelease part, the prerelease number is incremented - or a new prerelease of the next patch version is started.
+// The given prerelease identifier - if any - is used for the new prerelease versions, such as 1.2.4-rc.0 for the "rc" identifier.
+func Bump(version string, part Part, preID string) (string, error) {
+	prefix := ""
+	if strings.HasPrefix(version, "v") {
+		prefix, version = "v", strings.TrimPrefix(version, "v")
+	}
+	v, err := semver.StrictNewVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("invalid version %q: %w", prefix+version, err)
+	}
+
+	var next semver.Version
+	switch part {
+	case PartMajor:
+		next = v.IncMajor()
+	case PartMinor:
+		next = v.IncMinor()
+	case PartPatch:
+		next = v.IncPatch()
+	case PartPrerelease:
+		next, err = incPrerelease(v, preID)
+		if err != nil {
+			return "", fmt.Errorf("invalid version %q: %w", prefix+version, err)
+		}
+	default:
+		return "", fmt.Errorf("invalid part %s", part)
+	}
+	return prefix + next.String(), nil
+}
+
+func incPrerelease(v *semver.Version, preID string) (semver.Version, error) {
+	var (
+		base = *v
+		pre  string
+	)
+	switch identifiers := strings.Split(v.Prerelease(), "."); {
+	case len(v.Prerelease()) == 0:
+		// a new prerelease of the next patch version
+		base = v.IncPatch()
+		pre = "0"
+		if len(preID) > 0 {
+			pre = preID + ".0"
+		}
+	case len(preID) > 0 && identifiers[0] != preID:
+		// a new prerelease with a different identifier - such as from alpha to beta
+		pre = preID + ".0"
+	default:
+		last := identifiers[len(identifiers)-1]
+		if n, err := strconv.ParseUint(last, 10, 64); err == nil {
+			identifiers[len(identifiers)-1] = strconv.FormatUint(n+1, 10)
+		} else {
+			identifiers = append(identifiers, "0")
+		}
+		pre = strings.Join(identifiers, ".")
+	}
+
+	base, err := base.SetMetadata("")
+	if err != nil {
+		return semver.Version{}, err
+	}
+	return base.SetPrerelease(pre)
+}
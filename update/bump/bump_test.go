@@ -0,0 +1,383 @@
+package bump
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/update/change"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *BumpUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "whole file with default part",
+			params: map[string]string{
+				"file": "VERSION",
+			},
+			expected: &BumpUpdater{
+				FilePath: "VERSION",
+				Part:     PartPatch,
+			},
+		},
+		{
+			name: "path with prerelease part",
+			params: map[string]string{
+				"file":  "charts/*/Chart.yaml",
+				"path":  "version",
+				"part":  "prerelease",
+				"preid": "rc",
+			},
+			expected: &BumpUpdater{
+				FilePath: "charts/*/Chart.yaml",
+				Path:     "version",
+				Part:     PartPrerelease,
+				PreID:    "rc",
+			},
+		},
+		{
+			name: "pattern",
+			params: map[string]string{
+				"file":    "version.go",
+				"pattern": `Version = "(.*)"`,
+				"part":    "minor",
+				"always":  "true",
+			},
+			expected: &BumpUpdater{
+				FilePath: "version.go",
+				Pattern:  `Version = "(.*)"`,
+				Regexp:   regexp.MustCompile(`Version = "(.*)"`),
+				Part:     PartMinor,
+				Always:   true,
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing file parameter",
+		},
+		{
+			name: "path and pattern",
+			params: map[string]string{
+				"file":    "Chart.yaml",
+				"path":    "version",
+				"pattern": `version: (.*)`,
+			},
+			expectedErrorMsg: "invalid parameters: path and pattern can't be used together",
+		},
+		{
+			name: "pattern without subexpression",
+			params: map[string]string{
+				"file":    "version.go",
+				"pattern": `Version = ".*"`,
+			},
+			expectedErrorMsg: `invalid pattern Version = ".*": it must have a single parenthesized subexpression, but it has 0`,
+		},
+		{
+			name: "invalid part",
+			params: map[string]string{
+				"file": "VERSION",
+				"part": "build",
+			},
+			expectedErrorMsg: "invalid part parameter build: must be one of major, minor, patch or prerelease",
+		},
+		{
+			name: "preid without prerelease part",
+			params: map[string]string{
+				"file":  "VERSION",
+				"part":  "minor",
+				"preid": "rc",
+			},
+			expectedErrorMsg: "invalid preid parameter rc: it is only supported by the prerelease part",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	// the bump updater only bumps versions of repositories updated by the previous updaters
+	updatedCtx := change.NewContext(context.Background(), true)
+	tests := []struct {
+		name             string
+		ctx              context.Context
+		files            map[string]string
+		updater          *BumpUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+		expectedChanges  []change.Change
+	}{
+		{
+			name: "whole file",
+			ctx:  updatedCtx,
+			files: map[string]string{
+				"whole-file/VERSION": "1.2.3\n",
+			},
+			updater: &BumpUpdater{
+				FilePath: "whole-file/VERSION",
+				Part:     PartMinor,
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"whole-file/VERSION": "1.3.0\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "whole-file/VERSION", Name: "version", Old: "1.2.3", New: "1.3.0"},
+			},
+		},
+		{
+			name: "yaml path in multiple files",
+			ctx:  updatedCtx,
+			files: map[string]string{
+				"yaml/charts/api/Chart.yaml": "apiVersion: v2\nname: api\n# the version of the chart\nversion: 1.2.3 # bumped on release\nappVersion: \"1.2.3\"\n",
+				"yaml/charts/web/Chart.yaml": "apiVersion: v2\nname: web\nversion: 'v0.9.9'\n",
+			},
+			updater: &BumpUpdater{
+				FilePath: "yaml/charts/*/Chart.yaml",
+				Path:     "version",
+				Part:     PartPatch,
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"yaml/charts/api/Chart.yaml": "apiVersion: v2\nname: api\n# the version of the chart\nversion: 1.2.4 # bumped on release\nappVersion: \"1.2.3\"\n",
+				"yaml/charts/web/Chart.yaml": "apiVersion: v2\nname: web\nversion: 'v0.9.10'\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "yaml/charts/api/Chart.yaml", Name: "version", Old: "1.2.3", New: "1.2.4"},
+				{File: "yaml/charts/web/Chart.yaml", Name: "version", Old: "v0.9.9", New: "v0.9.10"},
+			},
+		},
+		{
+			name: "yaml path in multiple documents",
+			ctx:  updatedCtx,
+			files: map[string]string{
+				"yaml-documents/versions.yaml": "app:\n  version: \"2.0.0\"\n---\napp:\n  version: 3.1.0\n",
+			},
+			updater: &BumpUpdater{
+				FilePath: "yaml-documents/versions.yaml",
+				Path:     ".app.version",
+				Part:     PartMajor,
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"yaml-documents/versions.yaml": "app:\n  version: \"3.0.0\"\n---\napp:\n  version: 4.0.0\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "yaml-documents/versions.yaml", Name: ".app.version", Old: "2.0.0", New: "3.0.0"},
+				{File: "yaml-documents/versions.yaml", Name: ".app.version", Old: "3.1.0", New: "4.0.0"},
+			},
+		},
+		{
+			name: "json path",
+			ctx:  updatedCtx,
+			files: map[string]string{
+				"json/package.json": "{\n  \"name\": \"app\",\n  \"version\": \"1.0.0-rc.1\",\n  \"dependencies\": {}\n}\n",
+			},
+			updater: &BumpUpdater{
+				FilePath: "json/package.json",
+				Path:     "version",
+				Part:     PartPrerelease,
+				PreID:    "rc",
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"json/package.json": "{\n  \"name\": \"app\",\n  \"version\": \"1.0.0-rc.2\",\n  \"dependencies\": {}\n}\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "json/package.json", Name: "version", Old: "1.0.0-rc.1", New: "1.0.0-rc.2"},
+			},
+		},
+		{
+			name: "pattern",
+			ctx:  updatedCtx,
+			files: map[string]string{
+				"pattern/version.go": "package version\n\n// Version is the version of the app\nconst Version = \"v1.4.2\"\n",
+			},
+			updater: &BumpUpdater{
+				FilePath: "pattern/version.go",
+				Regexp:   regexp.MustCompile(`Version = "(.*)"`),
+				Part:     PartPrerelease,
+				PreID:    "beta",
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"pattern/version.go": "package version\n\n// Version is the version of the app\nconst Version = \"v1.4.3-beta.0\"\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "pattern/version.go", Name: "version", Old: "v1.4.2", New: "v1.4.3-beta.0"},
+			},
+		},
+		{
+			name: "nothing else changed",
+			ctx:  change.NewContext(context.Background(), false),
+			files: map[string]string{
+				"nothing-else-changed/VERSION": "1.2.3\n",
+			},
+			updater: &BumpUpdater{
+				FilePath: "nothing-else-changed/VERSION",
+				Part:     PartPatch,
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"nothing-else-changed/VERSION": "1.2.3\n",
+			},
+		},
+		{
+			name: "always bump",
+			ctx:  context.Background(),
+			files: map[string]string{
+				"always/VERSION": "1.2.3\n",
+			},
+			updater: &BumpUpdater{
+				FilePath: "always/VERSION",
+				Part:     PartPatch,
+				Always:   true,
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"always/VERSION": "1.2.4\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "always/VERSION", Name: "version", Old: "1.2.3", New: "1.2.4"},
+			},
+		},
+		{
+			name: "no match",
+			ctx:  updatedCtx,
+			files: map[string]string{
+				"no-match/Chart.yaml": "apiVersion: v2\nname: app\n",
+			},
+			updater: &BumpUpdater{
+				FilePath: "no-match/Chart.yaml",
+				Path:     "version",
+				Part:     PartPatch,
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-match/Chart.yaml": "apiVersion: v2\nname: app\n",
+			},
+		},
+		{
+			name: "invalid version",
+			ctx:  updatedCtx,
+			files: map[string]string{
+				"invalid/VERSION": "latest\n",
+			},
+			updater: &BumpUpdater{
+				FilePath: "invalid/VERSION",
+				Part:     PartPatch,
+			},
+			expectedErrorMsg: `failed to bump version in file invalid/VERSION: invalid version "latest": Invalid Semantic Version`,
+			expectedFiles: map[string]string{
+				"invalid/VERSION": "latest\n",
+			},
+		},
+		{
+			name: "json value which is not a string",
+			ctx:  updatedCtx,
+			files: map[string]string{
+				"json-number/package.json": `{"version": 1}`,
+			},
+			updater: &BumpUpdater{
+				FilePath: "json-number/package.json",
+				Path:     "version",
+				Part:     PartPatch,
+			},
+			expectedErrorMsg: "failed to find version in file json-number/package.json: invalid value at path $.version: expected a string, got 1",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(test.ctx, "testdata")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+			for filename, expectedContent := range test.expectedFiles {
+				actualContent, err := os.ReadFile(filepath.Join("testdata", filename))
+				require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+				assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+			}
+			assert.Equal(t, test.expectedChanges, test.updater.Changes("testdata"))
+		})
+	}
+}
+
+func TestBump(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		version          string
+		part             Part
+		preID            string
+		expected         string
+		expectedErrorMsg string
+	}{
+		{version: "1.2.3", part: PartMajor, expected: "2.0.0"},
+		{version: "1.2.3", part: PartMinor, expected: "1.3.0"},
+		{version: "1.2.3", part: PartPatch, expected: "1.2.4"},
+		{version: "v1.2.3+build.5", part: PartPatch, expected: "v1.2.4"},
+		{version: "1.2.3-rc.1", part: PartPatch, expected: "1.2.3"},
+		{version: "1.2.3-rc.1", part: PartMinor, expected: "1.3.0"},
+		{version: "1.2.3", part: PartPrerelease, expected: "1.2.4-0"},
+		{version: "1.2.3", part: PartPrerelease, preID: "rc", expected: "1.2.4-rc.0"},
+		{version: "1.2.4-rc.0", part: PartPrerelease, preID: "rc", expected: "1.2.4-rc.1"},
+		{version: "1.2.4-alpha.3", part: PartPrerelease, preID: "beta", expected: "1.2.4-beta.0"},
+		{version: "1.2.4-alpha", part: PartPrerelease, expected: "1.2.4-alpha.0"},
+		{version: "1.2.4-9+build", part: PartPrerelease, expected: "1.2.4-10"},
+		{version: "1.2", part: PartPatch, expectedErrorMsg: `invalid version "1.2": Invalid Semantic Version`},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.version+" "+string(test.part)+" "+test.preID, func(t *testing.T) {
+			t.Parallel()
+			actual, err := Bump(test.version, test.part, test.preID)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
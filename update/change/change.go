@@ -0,0 +1,79 @@
+// Package change provides a way for the updaters to record the changes they made to the repositories
+// - such as the old and new versions of a dependency - so that they can be used in the commits / pull requests.
+package change
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Change is a single change made by an updater to a repository.
+type Change struct {
+	// File is the path of the changed file, relative to the root of the repository
+	File string
+	// Name is the name of what has been changed - such as a dependency or a key
+	Name string
+	// Old is the value before the change - empty if it has been created
+	Old string
+	// New is the value after the change - empty if it has been removed
+	New string
+}
+
+// String returns a markdown representation of the change.
+func (c Change) String() string {
+	var sb strings.Builder
+	if len(c.File) > 0 {
+		fmt.Fprintf(&sb, "`%s`: ", c.File)
+	}
+	fmt.Fprintf(&sb, "`%s`", c.Name)
+	switch {
+	case len(c.Old) > 0 && len(c.New) > 0:
+		fmt.Fprintf(&sb, " from `%s` to `%s`", c.Old, c.New)
+	case len(c.New) > 0:
+		fmt.Fprintf(&sb, " set to `%s`", c.New)
+	case len(c.Old) > 0:
+		fmt.Fprintf(&sb, " removed (was `%s`)", c.Old)
+	}
+	return sb.String()
+}
+
+// Recorder records the changes made by an updater, for each repository.
+// It can be safely used by multiple goroutines at the same time - because an updater is used to update multiple repositories in parallel.
+// Its zero value is ready to use.
+type Recorder struct {
+	mutex   sync.Mutex
+	changes map[string][]Change
+}
+
+// Reset removes all the changes recorded for the repository cloned at the given path.
+func (r *Recorder) Reset(repoPath string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.changes, repoPath)
+}
+
+// Record records new changes for the repository cloned at the given path.
+func (r *Recorder) Record(repoPath string, changes ...Change) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.changes == nil {
+		r.changes = make(map[string][]Change)
+	}
+	r.changes[repoPath] = append(r.changes[repoPath], changes...)
+}
+
+// Changes returns the changes recorded for the repository cloned at the given path, sorted by file
+// - because the files are not always processed in the same order.
+func (r *Recorder) Changes(repoPath string) []Change {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.changes[repoPath]) == 0 {
+		return nil
+	}
+	changes := make([]Change, len(r.changes[repoPath]))
+	copy(changes, r.changes[repoPath])
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].File < changes[j].File })
+	return changes
+}
@@ -0,0 +1,97 @@
+package change
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeString(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		change   Change
+		expected string
+	}{
+		{
+			name:     "updated value",
+			change:   Change{File: "go.mod", Name: "github.com/org/lib", Old: "v1.0.0", New: "v1.1.0"},
+			expected: "`go.mod`: `github.com/org/lib` from `v1.0.0` to `v1.1.0`",
+		},
+		{
+			name:     "created value without file",
+			change:   Change{Name: "version", New: "1.0.0"},
+			expected: "`version` set to `1.0.0`",
+		},
+		{
+			name:     "removed value",
+			change:   Change{File: "go.sum", Name: "github.com/org/lib", Old: "v1.0.0"},
+			expected: "`go.sum`: `github.com/org/lib` removed (was `v1.0.0`)",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected, test.change.String())
+		})
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	var (
+		recorder Recorder
+		wg       sync.WaitGroup
+	)
+	assert.Empty(t, recorder.Changes("repo-0"))
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(repoPath string) {
+			defer wg.Done()
+			recorder.Record(repoPath, Change{Name: "first"})
+			recorder.Record(repoPath, Change{Name: "second"})
+		}(fmt.Sprintf("repo-%d", i))
+	}
+	wg.Wait()
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, []Change{{Name: "first"}, {Name: "second"}}, recorder.Changes(fmt.Sprintf("repo-%d", i)))
+	}
+
+	recorder.Record("repo-2", Change{File: "b.yaml", Name: "third"}, Change{File: "a.yaml", Name: "fourth"})
+	assert.Equal(t, []Change{{Name: "first"}, {Name: "second"}, {File: "a.yaml", Name: "fourth"}, {File: "b.yaml", Name: "third"}}, recorder.Changes("repo-2"))
+
+	recorder.Reset("repo-0")
+	assert.Empty(t, recorder.Changes("repo-0"))
+	assert.Len(t, recorder.Changes("repo-1"), 2)
+}
+
+func TestDetailsRecorder(t *testing.T) {
+	t.Parallel()
+
+	var recorder DetailsRecorder
+	assert.Empty(t, recorder.Details("repo-0"))
+
+	recorder.SetDetails("repo-0", "first")
+	recorder.SetDetails("repo-1", "second")
+	assert.Equal(t, "first", recorder.Details("repo-0"))
+	assert.Equal(t, "second", recorder.Details("repo-1"))
+
+	recorder.SetDetails("repo-0", "")
+	assert.Empty(t, recorder.Details("repo-0"))
+}
+
+func TestUpdatedFromContext(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, UpdatedFromContext(context.Background()))
+	assert.False(t, UpdatedFromContext(NewContext(context.Background(), false)))
+	assert.True(t, UpdatedFromContext(NewContext(context.Background(), true)))
+}
@@ -0,0 +1,17 @@
+package change
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a new context holding whether the repository has been updated by the updaters which already ran
+// - so that an updater can depend on the changes made by the previous ones.
+func NewContext(ctx context.Context, updated bool) context.Context {
+	return context.WithValue(ctx, contextKey{}, updated)
+}
+
+// UpdatedFromContext returns true if the given context holds that the repository has been updated by the previous updaters.
+func UpdatedFromContext(ctx context.Context) bool {
+	updated, _ := ctx.Value(contextKey{}).(bool)
+	return updated
+}
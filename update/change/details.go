@@ -0,0 +1,28 @@
+package change
+
+import "sync"
+
+// DetailsRecorder records a markdown description of the changes made by an updater, for each repository
+// - such as the commits between the old and new versions of a dependency.
+// It can be safely used by multiple goroutines at the same time, and its zero value is ready to use.
+type DetailsRecorder struct {
+	mutex   sync.Mutex
+	details map[string]string
+}
+
+// SetDetails sets the details of the changes made to the repository cloned at the given path - replacing any previous details.
+func (r *DetailsRecorder) SetDetails(repoPath, details string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.details == nil {
+		r.details = make(map[string]string)
+	}
+	r.details[repoPath] = details
+}
+
+// Details returns the details of the changes made to the repository cloned at the given path.
+func (r *DetailsRecorder) Details(repoPath string) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.details[repoPath]
+}
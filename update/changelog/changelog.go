@@ -0,0 +1,351 @@
+// Package changelog provides an updater that adds entries to changelog files written in the "Keep a Changelog" format.
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+
+	"github.com/dailymotion-oss/octopilot/internal/ghclient"
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	"github.com/dailymotion-oss/octopilot/internal/tpl"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+)
+
+// DefaultFilePath is the default path of the changelog file.
+const DefaultFilePath = "CHANGELOG.md"
+
+// Sections are the types of changes defined by the "Keep a Changelog" format - in the order in which they should appear.
+var Sections = []string{"Added", "Changed", "Deprecated", "Removed", "Fixed", "Security"}
+
+var (
+	// ## [1.0.0] - 2024-01-01
+	releaseRegexp = regexp.MustCompile(`^##\s`)
+	// ## [Unreleased]
+	unreleasedRegexp = regexp.MustCompile(`(?i)^##\s+\[?unreleased\]?`)
+	// ### Changed
+	sectionRegexp = regexp.MustCompile(`^###\s+(.+?)\s*$`)
+	// # Changelog, ## [1.0.0], ### Changed
+	headingRegexp = regexp.MustCompile(`^#{1,3}\s`)
+)
+
+// ChangelogUpdater is an updater that adds an entry to the "Unreleased" section of changelog files
+// written in the "Keep a Changelog" format - see https://keepachangelog.com/.
+// The value is a Go template, rendered with the same data and functions as the commit / pull request templates.
+type ChangelogUpdater struct {
+	FilePath string
+	// Section is the type of change, such as "Added" or "Changed"
+	Section string
+	Valuer  value.Valuer
+
+	change.Recorder
+}
+
+// NewUpdater builds a new changelog updater from the given parameters and valuer
+func NewUpdater(params map[string]string, valuer value.Valuer) (*ChangelogUpdater, error) {
+	updater := &ChangelogUpdater{}
+
+	updater.FilePath = params["file"]
+	if len(updater.FilePath) == 0 {
+		updater.FilePath = DefaultFilePath
+	}
+
+	updater.Section = params["section"]
+	if len(updater.Section) == 0 {
+		updater.Section = "Changed"
+	}
+	section, ok := sectionName(updater.Section)
+	if !ok {
+		return nil, fmt.Errorf("invalid section parameter %s: must be one of %s or %s", updater.Section, strings.Join(Sections[:len(Sections)-1], ", "), Sections[len(Sections)-1])
+	}
+	updater.Section = section
+
+	updater.Valuer = valuer
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *ChangelogUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	u.Reset(repoPath)
+
+	value, err := u.Valuer.Value(ctx, repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get value: %w", err)
+	}
+	entry, err := renderEntry(ctx, repoPath, value)
+	if err != nil {
+		return false, err
+	}
+	if len(entry) == 0 {
+		return false, errors.New("invalid value: the changelog entry is empty")
+	}
+
+	filePaths, err := glob.ExpandGlobPattern(repoPath, u.FilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to expand glob pattern %s: %w", u.FilePath, err)
+	}
+
+	var updated bool
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+		}
+
+		f := parseFile(string(content))
+		if !f.addEntry(u.Section, entry) {
+			continue
+		}
+
+		if err = os.WriteFile(filePath, []byte(f.String()), fileInfo.Mode()); err != nil {
+			return false, fmt.Errorf("failed to write updated content to file %s: %w", relFilePath, err)
+		}
+		u.Record(repoPath, change.Change{File: filepath.ToSlash(relFilePath), Name: u.Section, New: entry})
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *ChangelogUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Update %s", u.FilePath)
+	body = fmt.Sprintf("Adding a `%s` entry to the `Unreleased` section of file(s) `%s`", u.Section, u.FilePath)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *ChangelogUpdater) String() string {
+	return fmt.Sprintf("Changelog[file=%s,section=%s]", u.FilePath, u.Section)
+}
+
+// renderEntry renders the given value as a Go template, and returns the trimmed result.
+func renderEntry(ctx context.Context, repoPath, value string) (string, error) {
+	githubClient := func(_ context.Context) (*github.Client, error) {
+		return ghclient.FromContext(ctx)
+	}
+	t, err := tpl.New("changelog", repoPath, githubClient).Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", value, err)
+	}
+
+	var buffer bytes.Buffer
+	if err = t.Execute(&buffer, tpl.DataFromContext(ctx)); err != nil {
+		return "", fmt.Errorf("failed to execute template %s: %w", value, err)
+	}
+	return strings.TrimSpace(buffer.String()), nil
+}
+
+// sectionName returns the canonical name of the given section - ignoring the case.
+func sectionName(name string) (string, bool) {
+	for _, section := range Sections {
+		if strings.EqualFold(section, name) {
+			return section, true
+		}
+	}
+	return "", false
+}
+
+// sectionOrder returns the position of the given section in the list of sections - or the number of sections if it is unknown.
+func sectionOrder(name string) int {
+	for i, section := range Sections {
+		if strings.EqualFold(section, name) {
+			return i
+		}
+	}
+	return len(Sections)
+}
+
+// bulletLines returns the lines of the bullet for the given entry - with the continuation lines indented.
+func bulletLines(entry string) []string {
+	var lines []string
+	for i, line := range strings.Split(entry, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case i == 0 && (strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ")):
+		case i == 0:
+			line = "- " + line
+		case len(line) > 0:
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// bulletText returns the text of a bullet line - without its list marker - or false if the line is not a bullet.
+func bulletText(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	for _, marker := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(line, marker) {
+			return strings.TrimSpace(strings.TrimPrefix(line, marker)), true
+		}
+	}
+	return "", false
+}
+
+// file is a changelog file, as a list of lines without their line terminator.
+type file struct {
+	lines []string
+	// the line terminator used by the file - \n or \r\n
+	eol string
+}
+
+func parseFile(content string) *file {
+	f := &file{eol: "\n"}
+	if strings.Contains(content, "\r\n") {
+		f.eol = "\r\n"
+	}
+	content = strings.TrimSuffix(content, "\n")
+	if len(content) == 0 {
+		return f
+	}
+	for _, line := range strings.Split(content, "\n") {
+		f.lines = append(f.lines, strings.TrimSuffix(line, "\r"))
+	}
+	return f
+}
+
+// addEntry adds the given entry to the given section of the "Unreleased" release - creating them if needed.
+// It returns false if the entry already exists.
+func (f *file) addEntry(section, entry string) bool {
+	bullet := bulletLines(entry)
+
+	unreleased := f.find(unreleasedRegexp, 0, len(f.lines))
+	if unreleased < 0 {
+		// the "Unreleased" release is always the first one
+		position := f.find(releaseRegexp, 0, len(f.lines))
+		if position < 0 {
+			position = f.lastNonBlank(0, len(f.lines)) + 1
+		}
+		block := append([]string{"## [Unreleased]", ""}, f.sectionHeading(section)...)
+		f.insertBlock(position, append(block, bullet...))
+		return true
+	}
+
+	end := f.find(releaseRegexp, unreleased+1, len(f.lines))
+	if end < 0 {
+		end = len(f.lines)
+	}
+
+	var (
+		start    = -1
+		position = -1
+	)
+	for i := unreleased + 1; i < end; i++ {
+		matches := sectionRegexp.FindStringSubmatch(f.lines[i])
+		if matches == nil {
+			continue
+		}
+		if strings.EqualFold(matches[1], section) {
+			start = i
+			break
+		}
+		if position < 0 && sectionOrder(matches[1]) > sectionOrder(section) {
+			position = i
+		}
+	}
+
+	if start < 0 {
+		// the new section is inserted before the first section that should come after it - or at the end of the release
+		if position < 0 {
+			position = f.lastNonBlank(unreleased, end) + 1
+		}
+		f.insertBlock(position, append(f.sectionHeading(section), bullet...))
+		return true
+	}
+
+	sectionEnd := f.find(headingRegexp, start+1, end)
+	if sectionEnd < 0 {
+		sectionEnd = end
+	}
+	text, _ := bulletText(bullet[0])
+	for i := start + 1; i < sectionEnd; i++ {
+		if existing, ok := bulletText(f.lines[i]); ok && existing == text {
+			return false
+		}
+	}
+
+	// the new bullet is added at the end of the section
+	position = f.lastNonBlank(start, sectionEnd) + 1
+	if position == start+1 {
+		// the section is empty
+		heading := f.sectionHeading(section)
+		bullet = append(heading[1:], bullet...)
+	}
+	if position < len(f.lines) && len(strings.TrimSpace(f.lines[position])) > 0 {
+		bullet = append(bullet, "")
+	}
+	f.lines = append(f.lines[:position], append(bullet, f.lines[position:]...)...)
+	return true
+}
+
+// sectionHeading returns the lines of the heading of a new section
+// - followed by a blank line, unless the existing sections of the file don't have one.
+func (f *file) sectionHeading(section string) []string {
+	heading := []string{"### " + section, ""}
+	if i := f.find(sectionRegexp, 0, len(f.lines)); i >= 0 && i+1 < len(f.lines) && len(strings.TrimSpace(f.lines[i+1])) > 0 {
+		heading = heading[:1]
+	}
+	return heading
+}
+
+// find returns the index of the first line in [from, to) matching the given regexp - or -1.
+func (f *file) find(re *regexp.Regexp, from, to int) int {
+	for i := from; i < to; i++ {
+		if re.MatchString(f.lines[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastNonBlank returns the index of the last non-blank line in [from, to) - or from-1.
+func (f *file) lastNonBlank(from, to int) int {
+	for i := to - 1; i >= from; i-- {
+		if len(strings.TrimSpace(f.lines[i])) > 0 {
+			return i
+		}
+	}
+	return from - 1
+}
+
+// insertBlock inserts the given lines at the given position, surrounded by blank lines.
+func (f *file) insertBlock(position int, block []string) {
+	var lines []string
+	if position > 0 && len(strings.TrimSpace(f.lines[position-1])) > 0 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, block...)
+	if position < len(f.lines) && len(strings.TrimSpace(f.lines[position])) > 0 {
+		lines = append(lines, "")
+	}
+	f.lines = append(f.lines[:position], append(lines, f.lines[position:]...)...)
+}
+
+// String returns the content of the file - with a final line terminator.
+func (f *file) String() string {
+	if len(f.lines) == 0 {
+		return ""
+	}
+	return strings.Join(f.lines, f.eol) + f.eol
+}
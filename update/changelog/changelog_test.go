@@ -0,0 +1,312 @@
+package changelog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/internal/tpl"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		valuer           value.Valuer
+		expected         *ChangelogUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name:   "default params",
+			valuer: value.StringValuer("Bump lib"),
+			expected: &ChangelogUpdater{
+				FilePath: "CHANGELOG.md",
+				Section:  "Changed",
+				Valuer:   value.StringValuer("Bump lib"),
+			},
+		},
+		{
+			name: "valid params",
+			params: map[string]string{
+				"file":    "**/CHANGELOG.md",
+				"section": "security",
+			},
+			valuer: value.StringValuer("Bump lib"),
+			expected: &ChangelogUpdater{
+				FilePath: "**/CHANGELOG.md",
+				Section:  "Security",
+				Valuer:   value.StringValuer("Bump lib"),
+			},
+		},
+		{
+			name: "invalid section",
+			params: map[string]string{
+				"section": "Updated",
+			},
+			valuer:           value.StringValuer("Bump lib"),
+			expectedErrorMsg: "invalid section parameter Updated: must be one of Added, Changed, Deprecated, Removed, Fixed or Security",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params, test.valuer)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		files            map[string]string
+		updater          *ChangelogUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+		expectedChanges  []change.Change
+	}{
+		{
+			name: "add to existing section",
+			files: map[string]string{
+				"existing-section/CHANGELOG.md": `# Changelog
+
+## [Unreleased]
+
+### Added
+
+- New feature
+
+### Changed
+
+- Existing change
+
+## [1.0.0] - 2024-01-01
+
+### Changed
+
+- Old change
+`,
+			},
+			updater: &ChangelogUpdater{
+				FilePath: "existing-section/CHANGELOG.md",
+				Section:  "Changed",
+				Valuer:   value.StringValuer("Bump lib to 1.2.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"existing-section/CHANGELOG.md": `# Changelog
+
+## [Unreleased]
+
+### Added
+
+- New feature
+
+### Changed
+
+- Existing change
+- Bump lib to 1.2.0
+
+## [1.0.0] - 2024-01-01
+
+### Changed
+
+- Old change
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "existing-section/CHANGELOG.md", Name: "Changed", New: "Bump lib to 1.2.0"},
+			},
+		},
+		{
+			name: "create section in order",
+			files: map[string]string{
+				"new-section/CHANGELOG.md": `# Changelog
+
+## [Unreleased]
+### Added
+- New feature
+### Removed
+- Old feature
+
+## [1.0.0] - 2024-01-01
+`,
+			},
+			updater: &ChangelogUpdater{
+				FilePath: "new-section/CHANGELOG.md",
+				Section:  "Changed",
+				Valuer:   value.StringValuer("Bump lib to 1.2.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"new-section/CHANGELOG.md": `# Changelog
+
+## [Unreleased]
+### Added
+- New feature
+
+### Changed
+- Bump lib to 1.2.0
+
+### Removed
+- Old feature
+
+## [1.0.0] - 2024-01-01
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "new-section/CHANGELOG.md", Name: "Changed", New: "Bump lib to 1.2.0"},
+			},
+		},
+		{
+			name: "create unreleased release",
+			files: map[string]string{
+				"new-release/CHANGELOG.md": `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+## [1.0.0] - 2024-01-01
+
+### Added
+
+- First release
+`,
+			},
+			updater: &ChangelogUpdater{
+				FilePath: "new-release/CHANGELOG.md",
+				Section:  "Security",
+				Valuer:   value.StringValuer("- Bump lib to 1.2.0\nto fix CVE-2024-0001"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"new-release/CHANGELOG.md": `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+## [Unreleased]
+
+### Security
+
+- Bump lib to 1.2.0
+  to fix CVE-2024-0001
+
+## [1.0.0] - 2024-01-01
+
+### Added
+
+- First release
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "new-release/CHANGELOG.md", Name: "Security", New: "- Bump lib to 1.2.0\nto fix CVE-2024-0001"},
+			},
+		},
+		{
+			name: "create unreleased release in a changelog without releases",
+			files: map[string]string{
+				"no-release/a/CHANGELOG.md": "# Changelog\r\n",
+				"no-release/b/CHANGELOG.md": "# Changelog\n\n## Unreleased\n",
+			},
+			updater: &ChangelogUpdater{
+				FilePath: "no-release/**/CHANGELOG.md",
+				Section:  "Fixed",
+				Valuer:   value.StringValuer("Fix {{ .repo }}"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"no-release/a/CHANGELOG.md": "# Changelog\r\n\r\n## [Unreleased]\r\n\r\n### Fixed\r\n\r\n- Fix octopilot\r\n",
+				"no-release/b/CHANGELOG.md": "# Changelog\n\n## Unreleased\n\n### Fixed\n\n- Fix octopilot\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "no-release/a/CHANGELOG.md", Name: "Fixed", New: "Fix octopilot"},
+				{File: "no-release/b/CHANGELOG.md", Name: "Fixed", New: "Fix octopilot"},
+			},
+		},
+		{
+			name: "duplicate entry",
+			files: map[string]string{
+				"duplicate/CHANGELOG.md": "# Changelog\n\n## [Unreleased]\n\n### Changed\n\n* Bump lib to 1.2.0\n",
+			},
+			updater: &ChangelogUpdater{
+				FilePath: "duplicate/CHANGELOG.md",
+				Section:  "Changed",
+				Valuer:   value.StringValuer("Bump lib to 1.2.0"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"duplicate/CHANGELOG.md": "# Changelog\n\n## [Unreleased]\n\n### Changed\n\n* Bump lib to 1.2.0\n",
+			},
+		},
+		{
+			name: "missing file",
+			updater: &ChangelogUpdater{
+				FilePath: "missing/CHANGELOG.md",
+				Section:  "Changed",
+				Valuer:   value.StringValuer("Bump lib to 1.2.0"),
+			},
+			expected: false,
+		},
+		{
+			name: "invalid template",
+			updater: &ChangelogUpdater{
+				FilePath: "invalid/CHANGELOG.md",
+				Section:  "Changed",
+				Valuer:   value.StringValuer("Bump {{ .repo"),
+			},
+			expectedErrorMsg: "failed to parse template Bump {{ .repo: template: changelog:1: unclosed action",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			ctx := tpl.NewContext(context.Background(), map[string]interface{}{"repo": "octopilot"})
+			actual, err := test.updater.Update(ctx, "testdata")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+			for filename, expectedContent := range test.expectedFiles {
+				actualContent, err := os.ReadFile(filepath.Join("testdata", filename))
+				require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+				assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+			}
+			assert.Equal(t, test.expectedChanges, test.updater.Changes("testdata"))
+
+			// running the updater again must not change anything
+			actual, err = test.updater.Update(ctx, "testdata")
+			require.NoError(t, err)
+			assert.False(t, actual)
+		})
+	}
+}
@@ -0,0 +1,361 @@
+// Package dockerfile provides an updater that updates the base images used in Dockerfiles.
+package dockerfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	"github.com/dailymotion-oss/octopilot/internal/image"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+)
+
+var (
+	// $VAR or ${VAR}
+	variableRegexp = regexp.MustCompile(`^\$(?:\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))$`)
+)
+
+// DockerfileUpdater is an updater that updates the tag and/or digest of an image used in the FROM instructions of Dockerfiles.
+type DockerfileUpdater struct {
+	FilePath string
+	Image    string
+	Valuer   value.Valuer
+
+	change.Recorder
+}
+
+// NewUpdater builds a new Dockerfile updater from the given parameters and valuer
+func NewUpdater(params map[string]string, valuer value.Valuer) (*DockerfileUpdater, error) {
+	updater := &DockerfileUpdater{}
+
+	updater.FilePath = params["file"]
+	if len(updater.FilePath) == 0 {
+		updater.FilePath = "**/Dockerfile"
+	}
+
+	updater.Image = params["image"]
+	if len(updater.Image) == 0 {
+		return nil, errors.New("missing image parameter")
+	}
+	if name, tag, digest := image.ParseReference(updater.Image); len(tag) > 0 || len(digest) > 0 {
+		return nil, fmt.Errorf("invalid image parameter %s: it must not contain a tag or a digest - you can use %s instead", updater.Image, name)
+	}
+
+	updater.Valuer = valuer
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *DockerfileUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	u.Reset(repoPath)
+
+	value, err := u.Valuer.Value(ctx, repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get value: %w", err)
+	}
+	newTag, newDigest := image.ParseVersion(value)
+	if len(newTag) == 0 && len(newDigest) == 0 {
+		return false, fmt.Errorf("invalid value %q: expected a tag and/or a digest", value)
+	}
+
+	filePaths, err := glob.ExpandGlobPattern(repoPath, u.FilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to expand glob pattern %s: %w", u.FilePath, err)
+	}
+
+	var updated bool
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+		}
+
+		edits, changes := u.updateDockerfile(parseDockerfile(content), newTag, newDigest)
+		if len(edits) == 0 {
+			continue
+		}
+		for i := range changes {
+			changes[i].File = filepath.ToSlash(relFilePath)
+		}
+
+		if err = os.WriteFile(filePath, applyEdits(content, edits), fileInfo.Mode()); err != nil {
+			return false, fmt.Errorf("failed to write updated content to file %s: %w", relFilePath, err)
+		}
+
+		u.Record(repoPath, changes...)
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// updateDockerfile returns the edits required to update all the FROM instructions using the image.
+func (u *DockerfileUpdater) updateDockerfile(dockerfile dockerfile, newTag, newDigest string) ([]edit, []change.Change) {
+	var (
+		edits   = make(map[int]edit)
+		changes []change.Change
+	)
+	for _, from := range dockerfile.images {
+		ref := from
+		// the whole image reference is defined by an ARG, such as FROM ${BASE_IMAGE}
+		if name, ok := variableName(from.text); ok {
+			arg, found := dockerfile.args[name]
+			if !found {
+				continue
+			}
+			ref = arg
+		}
+
+		name, tag, digest := image.ParseReference(ref.text)
+		if !image.SameName(name, u.Image) {
+			continue
+		}
+
+		// the tag is defined by an ARG, such as FROM registry/image:${VERSION}
+		var (
+			tagArg     token
+			tagFromArg bool
+		)
+		if argName, ok := variableName(tag); ok {
+			if tagArg, tagFromArg = dockerfile.args[argName]; !tagFromArg {
+				// without a default value, the tag is only known at build time - such as with --build-arg - so it is left untouched
+				continue
+			}
+		}
+
+		var (
+			oldTag        = tag
+			updatedTag    = tag
+			updatedDigest = digest
+		)
+		if len(newTag) > 0 {
+			updatedTag = newTag
+			if tagFromArg {
+				oldTag, updatedTag = tagArg.text, tag
+				if tagArg.text != newTag {
+					edits[tagArg.start] = edit{token: tagArg, replacement: newTag}
+				}
+			}
+			// an existing digest would not match the new tag
+			updatedDigest = ""
+		}
+		if len(newDigest) > 0 {
+			updatedDigest = newDigest
+		}
+
+		updatedRef := image.FormatReference(name, updatedTag, updatedDigest)
+		if updatedRef != ref.text {
+			edits[ref.start] = edit{token: ref, replacement: updatedRef}
+		}
+		oldVersion, newVersion := image.FormatVersion(oldTag, digest), image.FormatVersion(newTag, updatedDigest)
+		if len(newTag) == 0 {
+			newVersion = image.FormatVersion(oldTag, updatedDigest)
+		}
+		if c := (change.Change{Name: u.Image, Old: oldVersion, New: newVersion}); oldVersion != newVersion && !slices.Contains(changes, c) {
+			changes = append(changes, c)
+		}
+	}
+
+	sorted := make([]edit, 0, len(edits))
+	for _, e := range edits {
+		sorted = append(sorted, e)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].token.start < sorted[j].token.start })
+	return sorted, changes
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *DockerfileUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Update image %s", u.Image)
+	body = fmt.Sprintf("Updating image `%s` in file(s) `%s`", u.Image, u.FilePath)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *DockerfileUpdater) String() string {
+	return fmt.Sprintf("Dockerfile[image=%s,file=%s]", u.Image, u.FilePath)
+}
+
+// dockerfile holds the relevant parts of a Dockerfile: the images used in the FROM instructions,
+// and the global ARG instructions - declared before the first FROM instruction - which can be used in the FROM instructions.
+type dockerfile struct {
+	images []token
+	args   map[string]token
+}
+
+// token is a part of the Dockerfile content, with its position.
+type token struct {
+	text  string
+	start int
+	end   int
+}
+
+type edit struct {
+	token       token
+	replacement string
+}
+
+func parseDockerfile(content []byte) dockerfile {
+	var (
+		result = dockerfile{args: make(map[string]token)}
+		pos    int
+	)
+	for pos < len(content) {
+		pos = skipSpaces(content, pos)
+		if pos >= len(content) {
+			break
+		}
+		if content[pos] == '\n' || content[pos] == '\r' {
+			pos++
+			continue
+		}
+		if content[pos] == '#' {
+			pos = skipLine(content, pos)
+			continue
+		}
+
+		keywordStart := pos
+		for pos < len(content) && !isSpace(content[pos]) && content[pos] != '\n' && content[pos] != '\r' {
+			pos++
+		}
+		keyword := strings.ToUpper(string(content[keywordStart:pos]))
+
+		var tokens []token
+		tokens, pos = readTokens(content, pos)
+
+		switch keyword {
+		case "FROM":
+			for _, tok := range tokens {
+				if !strings.HasPrefix(tok.text, "--") {
+					result.images = append(result.images, tok)
+					break
+				}
+			}
+		case "ARG":
+			if len(result.images) > 0 {
+				continue
+			}
+			for _, tok := range tokens {
+				name, value, found := strings.Cut(tok.text, "=")
+				if !found {
+					continue
+				}
+				start := tok.start + len(name) + 1
+				if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+					value = value[1 : len(value)-1]
+					start++
+				}
+				result.args[name] = token{text: value, start: start, end: start + len(value)}
+			}
+		}
+	}
+	return result
+}
+
+// readTokens reads the whitespace-separated tokens of an instruction, until the end of the - possibly continued - line.
+func readTokens(content []byte, pos int) ([]token, int) {
+	var tokens []token
+	for pos < len(content) {
+		pos = skipSpaces(content, pos)
+		if pos >= len(content) {
+			break
+		}
+		if content[pos] == '\n' || content[pos] == '\r' {
+			return tokens, pos
+		}
+		if next, ok := skipLineContinuation(content, pos); ok {
+			pos = next
+			continue
+		}
+		start := pos
+		for pos < len(content) && !isSpace(content[pos]) && content[pos] != '\n' && content[pos] != '\r' {
+			if _, ok := skipLineContinuation(content, pos); ok {
+				break
+			}
+			pos++
+		}
+		tokens = append(tokens, token{text: string(content[start:pos]), start: start, end: pos})
+	}
+	return tokens, pos
+}
+
+// skipLineContinuation skips a backslash at the end of a line - and the line break -
+// and the comment lines that may be inside a continued instruction.
+func skipLineContinuation(content []byte, pos int) (int, bool) {
+	if content[pos] != '\\' {
+		return pos, false
+	}
+	next := skipSpaces(content, pos+1)
+	if next < len(content) && content[next] == '\r' {
+		next++
+	}
+	if next < len(content) && content[next] != '\n' {
+		return pos, false
+	}
+	next++
+	for {
+		lineStart := skipSpaces(content, next)
+		if lineStart >= len(content) || content[lineStart] != '#' {
+			return next, true
+		}
+		next = skipLine(content, lineStart)
+	}
+}
+
+func skipSpaces(content []byte, pos int) int {
+	for pos < len(content) && isSpace(content[pos]) {
+		pos++
+	}
+	return pos
+}
+
+func skipLine(content []byte, pos int) int {
+	for pos < len(content) && content[pos] != '\n' {
+		pos++
+	}
+	return pos + 1
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+func applyEdits(content []byte, edits []edit) []byte {
+	var (
+		result   []byte
+		position int
+	)
+	for _, e := range edits {
+		result = append(result, content[position:e.token.start]...)
+		result = append(result, e.replacement...)
+		position = e.token.end
+	}
+	return append(result, content[position:]...)
+}
+
+func variableName(text string) (string, bool) {
+	matches := variableRegexp.FindStringSubmatch(text)
+	if len(matches) == 0 {
+		return "", false
+	}
+	return matches[1] + matches[2], true
+}
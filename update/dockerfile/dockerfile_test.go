@@ -0,0 +1,286 @@
+package dockerfile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *DockerfileUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params with default file",
+			params: map[string]string{
+				"image": "registry.example.com/org/base",
+			},
+			expected: &DockerfileUpdater{
+				FilePath: "**/Dockerfile",
+				Image:    "registry.example.com/org/base",
+			},
+		},
+		{
+			name: "valid params with custom file",
+			params: map[string]string{
+				"file":  "build/*.Dockerfile",
+				"image": "localhost:5000/base",
+			},
+			expected: &DockerfileUpdater{
+				FilePath: "build/*.Dockerfile",
+				Image:    "localhost:5000/base",
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing image parameter",
+		},
+		{
+			name: "image with tag",
+			params: map[string]string{
+				"image": "registry.example.com/org/base:1.0",
+			},
+			expectedErrorMsg: "invalid image parameter registry.example.com/org/base:1.0: it must not contain a tag or a digest - you can use registry.example.com/org/base instead",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params, nil)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		files            map[string]string
+		updater          *DockerfileUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+		expectedChanges  []change.Change
+	}{
+		{
+			name: "update multi-stage Dockerfile",
+			files: map[string]string{
+				"multi-stage/Dockerfile": `# syntax=docker/dockerfile:1
+FROM --platform=$BUILDPLATFORM registry.example.com/org/base:1.0 AS builder
+RUN make build \
+    FROM=registry.example.com/org/base:1.0
+
+from registry.example.com/org/base:1.0@sha256:0123456789abcdef as runtime
+COPY --from=builder /app /app
+FROM builder
+`,
+			},
+			updater: &DockerfileUpdater{
+				FilePath: "multi-stage/Dockerfile",
+				Image:    "registry.example.com/org/base",
+				Valuer:   value.StringValuer("1.1"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"multi-stage/Dockerfile": `# syntax=docker/dockerfile:1
+FROM --platform=$BUILDPLATFORM registry.example.com/org/base:1.1 AS builder
+RUN make build \
+    FROM=registry.example.com/org/base:1.0
+
+from registry.example.com/org/base:1.1 as runtime
+COPY --from=builder /app /app
+FROM builder
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "multi-stage/Dockerfile", Name: "registry.example.com/org/base", Old: "1.0", New: "1.1"},
+				{File: "multi-stage/Dockerfile", Name: "registry.example.com/org/base", Old: "1.0@sha256:0123456789abcdef", New: "1.1"},
+			},
+		},
+		{
+			name: "update ARG-parameterised images",
+			files: map[string]string{
+				"args/Dockerfile": `ARG BASE_IMAGE="registry.example.com/org/base:1.0"
+ARG GO_VERSION=1.21 \
+    OTHER=value
+FROM ${BASE_IMAGE} AS base
+FROM golang:${GO_VERSION}
+ARG GO_VERSION=ignored
+FROM $BASE_IMAGE
+`,
+			},
+			updater: &DockerfileUpdater{
+				FilePath: "args/Dockerfile",
+				Image:    "docker.io/library/golang",
+				Valuer:   value.StringValuer("1.22"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"args/Dockerfile": `ARG BASE_IMAGE="registry.example.com/org/base:1.0"
+ARG GO_VERSION=1.22 \
+    OTHER=value
+FROM ${BASE_IMAGE} AS base
+FROM golang:${GO_VERSION}
+ARG GO_VERSION=ignored
+FROM $BASE_IMAGE
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "args/Dockerfile", Name: "docker.io/library/golang", Old: "1.21", New: "1.22"},
+			},
+		},
+		{
+			name: "update digest of images defined by an ARG",
+			files: map[string]string{
+				"digest/Dockerfile": `ARG BASE_IMAGE="registry.example.com/org/base:1.0"
+FROM ${BASE_IMAGE} AS base
+FROM $BASE_IMAGE
+`,
+			},
+			updater: &DockerfileUpdater{
+				FilePath: "digest/Dockerfile",
+				Image:    "registry.example.com/org/base",
+				Valuer:   value.StringValuer("sha256:fedcba9876543210"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"digest/Dockerfile": `ARG BASE_IMAGE="registry.example.com/org/base:1.0@sha256:fedcba9876543210"
+FROM ${BASE_IMAGE} AS base
+FROM $BASE_IMAGE
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "digest/Dockerfile", Name: "registry.example.com/org/base", Old: "1.0", New: "1.0@sha256:fedcba9876543210"},
+			},
+		},
+		{
+			name: "keep tags defined by an ARG without default value",
+			files: map[string]string{
+				"args-without-default/Dockerfile": `ARG VERSION
+FROM golang:${VERSION} AS builder
+FROM golang:$VERSION
+FROM golang:1.21
+`,
+			},
+			updater: &DockerfileUpdater{
+				FilePath: "args-without-default/Dockerfile",
+				Image:    "golang",
+				Valuer:   value.StringValuer("1.22"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"args-without-default/Dockerfile": `ARG VERSION
+FROM golang:${VERSION} AS builder
+FROM golang:$VERSION
+FROM golang:1.22
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "args-without-default/Dockerfile", Name: "golang", Old: "1.21", New: "1.22"},
+			},
+		},
+		{
+			name: "update multiple files with tag and digest",
+			files: map[string]string{
+				"multiple/first/Dockerfile":  "FROM localhost:5000/base:1.0\r\nRUN echo first\r\n",
+				"multiple/second/Dockerfile": "FROM localhost:5000/base\n",
+			},
+			updater: &DockerfileUpdater{
+				FilePath: "multiple/**/Dockerfile",
+				Image:    "localhost:5000/base",
+				Valuer:   value.StringValuer("2.0@sha256:fedcba9876543210"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"multiple/first/Dockerfile":  "FROM localhost:5000/base:2.0@sha256:fedcba9876543210\r\nRUN echo first\r\n",
+				"multiple/second/Dockerfile": "FROM localhost:5000/base:2.0@sha256:fedcba9876543210\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "multiple/first/Dockerfile", Name: "localhost:5000/base", Old: "1.0", New: "2.0@sha256:fedcba9876543210"},
+				{File: "multiple/second/Dockerfile", Name: "localhost:5000/base", New: "2.0@sha256:fedcba9876543210"},
+			},
+		},
+		{
+			name: "no changes",
+			files: map[string]string{
+				"no-changes/Dockerfile": `FROM registry.example.com/org/base:1.0
+FROM registry.example.com/org/other:0.1
+`,
+			},
+			updater: &DockerfileUpdater{
+				FilePath: "no-changes/Dockerfile",
+				Image:    "registry.example.com/org/base",
+				Valuer:   value.StringValuer("1.0"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-changes/Dockerfile": `FROM registry.example.com/org/base:1.0
+FROM registry.example.com/org/other:0.1
+`,
+			},
+		},
+		{
+			name: "invalid value",
+			files: map[string]string{
+				"invalid-value/Dockerfile": `FROM registry.example.com/org/base:1.0
+`,
+			},
+			updater: &DockerfileUpdater{
+				FilePath: "invalid-value/Dockerfile",
+				Image:    "registry.example.com/org/base",
+				Valuer:   value.StringValuer(""),
+			},
+			expectedErrorMsg: `invalid value "": expected a tag and/or a digest`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(context.Background(), "testdata")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+				for filename, expectedContent := range test.expectedFiles {
+					actualContent, err := os.ReadFile(filepath.Join("testdata", filename))
+					require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+					assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+				}
+				assert.Equal(t, test.expectedChanges, test.updater.Changes("testdata"))
+			}
+		})
+	}
+}
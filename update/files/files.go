@@ -0,0 +1,424 @@
+// Package files provides an updater that copies, deletes or moves files in a repository.
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-zglob"
+
+	"github.com/dailymotion-oss/octopilot/internal/ghclient"
+	"github.com/dailymotion-oss/octopilot/update/change"
+)
+
+// Operation is the operation applied to the files matching the source pattern.
+type Operation string
+
+// The supported operations
+const (
+	// OperationCopy copies the files from a local directory, another GitHub repository, or the repository itself
+	OperationCopy Operation = "copy"
+	// OperationDelete deletes the files from the repository
+	OperationDelete Operation = "delete"
+	// OperationMove moves the files inside the repository
+	OperationMove Operation = "move"
+)
+
+// FilesUpdater is an updater that copies, deletes or moves the files matching a pattern.
+type FilesUpdater struct {
+	Operation Operation
+	Source    string
+	Dest      string
+	// Dir is the local directory to copy the files from
+	Dir string
+	// Repo and Ref are the GitHub repository - in the owner/name format - and the git reference to copy the files from
+	Repo string
+	Ref  string
+
+	change.DetailsRecorder
+
+	// the files of the GitHub repository - shared by all the repositories
+	remoteMutex sync.Mutex
+	remoteFiles []sourceFile
+}
+
+// sourceFile is a file to copy, with its path relative to the root of the source.
+type sourceFile struct {
+	path    string
+	content []byte
+	mode    fs.FileMode
+}
+
+// NewUpdater builds a new files updater from the given parameters
+func NewUpdater(params map[string]string) (*FilesUpdater, error) {
+	updater := &FilesUpdater{}
+
+	updater.Operation = Operation(params["op"])
+	switch updater.Operation {
+	case "":
+		return nil, errors.New("missing op parameter")
+	case OperationCopy, OperationDelete, OperationMove:
+	default:
+		return nil, fmt.Errorf("invalid op parameter %s: must be one of %s, %s or %s", updater.Operation, OperationCopy, OperationDelete, OperationMove)
+	}
+
+	updater.Source = params["src"]
+	if len(updater.Source) == 0 {
+		return nil, errors.New("missing src parameter")
+	}
+
+	updater.Dest = params["dest"]
+	switch {
+	case updater.Operation == OperationDelete && len(updater.Dest) > 0:
+		return nil, fmt.Errorf("invalid dest parameter %s: it is not supported by the %s operation", updater.Dest, updater.Operation)
+	case updater.Operation != OperationDelete && len(updater.Dest) == 0:
+		return nil, errors.New("missing dest parameter")
+	case updater.Operation != OperationDelete && !filepath.IsLocal(strings.TrimSuffix(updater.Dest, "/")):
+		return nil, fmt.Errorf("invalid dest parameter %s: it must be a relative path inside the repository", updater.Dest)
+	}
+
+	updater.Dir = params["dir"]
+	updater.Repo = params["repo"]
+	updater.Ref = params["ref"]
+	switch {
+	case updater.Operation != OperationCopy && (len(updater.Dir) > 0 || len(updater.Repo) > 0):
+		return nil, fmt.Errorf("invalid parameters: dir and repo are only supported by the %s operation", OperationCopy)
+	case len(updater.Dir) > 0 && len(updater.Repo) > 0:
+		return nil, errors.New("invalid parameters: dir and repo can't be used together")
+	case len(updater.Ref) > 0 && len(updater.Repo) == 0:
+		return nil, errors.New("invalid ref parameter: it requires the repo parameter")
+	case len(updater.Repo) > 0 && len(strings.Split(updater.Repo, "/")) != 2:
+		return nil, fmt.Errorf("invalid repo parameter %s: expected a repository in the owner/name format", updater.Repo)
+	}
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *FilesUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	u.SetDetails(repoPath, "")
+
+	var (
+		lines []string
+		err   error
+	)
+	switch u.Operation {
+	case OperationCopy:
+		lines, err = u.copyFiles(ctx, repoPath)
+	case OperationDelete:
+		lines, err = u.deleteFiles(repoPath)
+	case OperationMove:
+		lines, err = u.moveFiles(repoPath)
+	}
+	if err != nil {
+		return false, err
+	}
+	if len(lines) == 0 {
+		return false, nil
+	}
+
+	u.SetDetails(repoPath, strings.Join(lines, "\n"))
+	return true, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *FilesUpdater) Message() (title, body string) {
+	switch u.Operation {
+	case OperationDelete:
+		title = fmt.Sprintf("Delete %s", u.Source)
+		body = fmt.Sprintf("Deleting files `%s`", u.Source)
+	case OperationMove:
+		title = fmt.Sprintf("Move %s to %s", u.Source, u.Dest)
+		body = fmt.Sprintf("Moving files `%s` to `%s`", u.Source, u.Dest)
+	default:
+		title = fmt.Sprintf("Copy %s to %s", u.Source, u.Dest)
+		body = fmt.Sprintf("Copying files `%s` from %s to `%s`", u.Source, u.sourceName(), u.Dest)
+	}
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *FilesUpdater) String() string {
+	return fmt.Sprintf("Files[op=%s,src=%s,dest=%s,dir=%s,repo=%s,ref=%s]", u.Operation, u.Source, u.Dest, u.Dir, u.Repo, u.Ref)
+}
+
+func (u *FilesUpdater) sourceName() string {
+	switch {
+	case len(u.Repo) > 0 && len(u.Ref) > 0:
+		return fmt.Sprintf("`%s@%s`", u.Repo, u.Ref)
+	case len(u.Repo) > 0:
+		return fmt.Sprintf("`%s`", u.Repo)
+	case len(u.Dir) > 0:
+		return fmt.Sprintf("`%s`", filepath.Base(u.Dir))
+	default:
+		return "the repository"
+	}
+}
+
+// copyFiles copies the matching files to the destination, and returns a description of each created or updated file.
+func (u *FilesUpdater) copyFiles(ctx context.Context, repoPath string) ([]string, error) {
+	var (
+		files []sourceFile
+		err   error
+	)
+	switch {
+	case len(u.Repo) > 0:
+		files, err = u.remoteSourceFiles(ctx)
+	case len(u.Dir) > 0:
+		files, err = localSourceFiles(u.Dir, u.Source)
+	default:
+		files, err = localSourceFiles(repoPath, u.Source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files matching %s in %s", u.Source, u.sourceName())
+	}
+
+	var lines []string
+	for _, file := range files {
+		destPath := destinationPath(u.Source, file.path, u.Dest)
+		fullDestPath := filepath.Join(repoPath, filepath.FromSlash(destPath))
+
+		action := "created"
+		if oldContent, err := os.ReadFile(fullDestPath); err == nil {
+			if string(oldContent) == string(file.content) {
+				continue
+			}
+			action = "updated"
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read file %s: %w", destPath, err)
+		}
+
+		if err = os.MkdirAll(filepath.Dir(fullDestPath), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directories for file %s: %w", destPath, err)
+		}
+		if err = os.WriteFile(fullDestPath, file.content, file.mode); err != nil {
+			return nil, fmt.Errorf("failed to write file %s: %w", destPath, err)
+		}
+		if err = os.Chmod(fullDestPath, file.mode); err != nil {
+			return nil, fmt.Errorf("failed to change the permissions of file %s: %w", destPath, err)
+		}
+		lines = append(lines, fmt.Sprintf("- `%s`: %s from `%s`", destPath, action, file.path))
+	}
+	return lines, nil
+}
+
+// deleteFiles deletes the matching files, and returns a description of each deleted file.
+func (u *FilesUpdater) deleteFiles(repoPath string) ([]string, error) {
+	paths, err := listFiles(repoPath, u.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, p := range paths {
+		if err = os.Remove(filepath.Join(repoPath, filepath.FromSlash(p))); err != nil {
+			return nil, fmt.Errorf("failed to delete file %s: %w", p, err)
+		}
+		removeEmptyParents(repoPath, p)
+		lines = append(lines, fmt.Sprintf("- `%s`: deleted", p))
+	}
+	return lines, nil
+}
+
+// moveFiles moves the matching files to the destination, and returns a description of each moved file.
+func (u *FilesUpdater) moveFiles(repoPath string) ([]string, error) {
+	paths, err := listFiles(repoPath, u.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, p := range paths {
+		destPath := destinationPath(u.Source, p, u.Dest)
+		if destPath == p {
+			continue
+		}
+		fullDestPath := filepath.Join(repoPath, filepath.FromSlash(destPath))
+		if err = os.MkdirAll(filepath.Dir(fullDestPath), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directories for file %s: %w", destPath, err)
+		}
+		if err = os.Rename(filepath.Join(repoPath, filepath.FromSlash(p)), fullDestPath); err != nil {
+			return nil, fmt.Errorf("failed to move file %s to %s: %w", p, destPath, err)
+		}
+		removeEmptyParents(repoPath, p)
+		lines = append(lines, fmt.Sprintf("- `%s`: moved to `%s`", p, destPath))
+	}
+	return lines, nil
+}
+
+// remoteSourceFiles returns the matching files of the GitHub repository.
+// They are retrieved only once, because they are the same for all the updated repositories.
+func (u *FilesUpdater) remoteSourceFiles(ctx context.Context) ([]sourceFile, error) {
+	u.remoteMutex.Lock()
+	defer u.remoteMutex.Unlock()
+	if u.remoteFiles != nil {
+		return u.remoteFiles, nil
+	}
+
+	client, err := ghclient.FromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve files from %s: %w", u.Repo, err)
+	}
+	owner, name, _ := strings.Cut(u.Repo, "/")
+
+	ref := u.Ref
+	if len(ref) == 0 {
+		repo, _, err := client.Repositories.Get(ctx, owner, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve repository %s: %w", u.Repo, err)
+		}
+		ref = repo.GetDefaultBranch()
+	}
+
+	tree, _, err := client.Git.GetTree(ctx, owner, name, ref, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the files of %s@%s: %w", u.Repo, ref, err)
+	}
+	if tree.GetTruncated() {
+		return nil, fmt.Errorf("failed to retrieve the files of %s@%s: too many files", u.Repo, ref)
+	}
+
+	files := []sourceFile{}
+	for _, entry := range tree.Entries {
+		// only the regular files are copied - not the symlinks or the submodules
+		if entry.GetType() != "blob" || (entry.GetMode() != "100644" && entry.GetMode() != "100755") {
+			continue
+		}
+		matched, err := matchesPathOrParent(u.Source, entry.GetPath())
+		if err != nil {
+			return nil, fmt.Errorf("invalid src parameter %s: %w", u.Source, err)
+		}
+		if !matched {
+			continue
+		}
+
+		content, _, err := client.Git.GetBlobRaw(ctx, owner, name, entry.GetSHA())
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve file %s from %s@%s: %w", entry.GetPath(), u.Repo, ref, err)
+		}
+		mode := fs.FileMode(0644)
+		if entry.GetMode() == "100755" {
+			mode = 0755
+		}
+		files = append(files, sourceFile{path: entry.GetPath(), content: content, mode: mode})
+	}
+
+	u.remoteFiles = files
+	return files, nil
+}
+
+// localSourceFiles returns the files of the given directory matching the pattern.
+func localSourceFiles(dir, pattern string) ([]sourceFile, error) {
+	paths, err := listFiles(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]sourceFile, 0, len(paths))
+	for _, p := range paths {
+		fullPath := filepath.Join(dir, filepath.FromSlash(p))
+		fileInfo, err := os.Stat(fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to access file %s: %w", p, err)
+		}
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", p, err)
+		}
+		files = append(files, sourceFile{path: p, content: content, mode: fileInfo.Mode().Perm()})
+	}
+	return files, nil
+}
+
+// listFiles returns the sorted paths - relative to the given directory and using slashes - of the regular files matching the pattern,
+// or inside a matching directory. The .git directory is ignored.
+func listFiles(dir, pattern string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(fullPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, fullPath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		matched, err := matchesPathOrParent(pattern, relPath)
+		if err != nil {
+			return fmt.Errorf("invalid src parameter %s: %w", pattern, err)
+		}
+		if matched {
+			paths = append(paths, relPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in %s: %w", filepath.Base(dir), err)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// matchesPathOrParent returns true if the given pattern matches the path, or one of its parent directories.
+func matchesPathOrParent(pattern, p string) (bool, error) {
+	pattern = strings.TrimSuffix(path.Clean(filepath.ToSlash(pattern)), "/")
+	for ; p != "." && p != "/"; p = path.Dir(p) {
+		matched, err := zglob.Match(pattern, p)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+// destinationPath returns the destination of the given file, matched by the pattern:
+//   - if the pattern is the path of the file, and the destination doesn't end with a slash, the destination is the new path of the file
+//   - otherwise the destination is a directory, and the file keeps its path relative to the "static" part of the pattern,
+//     such as `a/b.txt` for the `templates/**/*.txt` pattern and the `templates/a/b.txt` file.
+func destinationPath(pattern, file, dest string) string {
+	pattern = path.Clean(filepath.ToSlash(pattern))
+	dest = filepath.ToSlash(dest)
+	if pattern == file && !strings.HasSuffix(dest, "/") {
+		return path.Clean(dest)
+	}
+
+	base := path.Dir(pattern)
+	if i := strings.IndexAny(pattern, "*?[{"); i >= 0 {
+		base = path.Dir(pattern[:i] + "x")
+	}
+	relPath := strings.TrimPrefix(file, base+"/")
+	if base == "." {
+		relPath = file
+	}
+	return path.Join(dest, relPath)
+}
+
+// removeEmptyParents removes the parent directories of the given file, if they are empty - up to the root of the repository.
+func removeEmptyParents(repoPath, file string) {
+	for dir := path.Dir(file); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if err := os.Remove(filepath.Join(repoPath, filepath.FromSlash(dir))); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,447 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+
+	"github.com/dailymotion-oss/octopilot/internal/ghclient"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *FilesUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "copy from github repository",
+			params: map[string]string{
+				"op":   "copy",
+				"src":  ".github/workflows/*.yml",
+				"dest": ".github/workflows",
+				"repo": "org/templates",
+				"ref":  "v1",
+			},
+			expected: &FilesUpdater{
+				Operation: OperationCopy,
+				Source:    ".github/workflows/*.yml",
+				Dest:      ".github/workflows",
+				Repo:      "org/templates",
+				Ref:       "v1",
+			},
+		},
+		{
+			name: "delete",
+			params: map[string]string{
+				"op":  "delete",
+				"src": "**/*.bak",
+			},
+			expected: &FilesUpdater{
+				Operation: OperationDelete,
+				Source:    "**/*.bak",
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing op parameter",
+		},
+		{
+			name: "invalid op",
+			params: map[string]string{
+				"op":  "rename",
+				"src": "a.txt",
+			},
+			expectedErrorMsg: "invalid op parameter rename: must be one of copy, delete or move",
+		},
+		{
+			name: "missing src",
+			params: map[string]string{
+				"op": "delete",
+			},
+			expectedErrorMsg: "missing src parameter",
+		},
+		{
+			name: "missing dest",
+			params: map[string]string{
+				"op":  "move",
+				"src": "a.txt",
+			},
+			expectedErrorMsg: "missing dest parameter",
+		},
+		{
+			name: "dest with delete",
+			params: map[string]string{
+				"op":   "delete",
+				"src":  "a.txt",
+				"dest": "b.txt",
+			},
+			expectedErrorMsg: "invalid dest parameter b.txt: it is not supported by the delete operation",
+		},
+		{
+			name: "dest outside of the repository",
+			params: map[string]string{
+				"op":   "copy",
+				"src":  "a.txt",
+				"dest": "../b.txt",
+			},
+			expectedErrorMsg: "invalid dest parameter ../b.txt: it must be a relative path inside the repository",
+		},
+		{
+			name: "repo with move",
+			params: map[string]string{
+				"op":   "move",
+				"src":  "a.txt",
+				"dest": "b.txt",
+				"repo": "org/templates",
+			},
+			expectedErrorMsg: "invalid parameters: dir and repo are only supported by the copy operation",
+		},
+		{
+			name: "dir and repo",
+			params: map[string]string{
+				"op":   "copy",
+				"src":  "a.txt",
+				"dest": "b.txt",
+				"dir":  "/templates",
+				"repo": "org/templates",
+			},
+			expectedErrorMsg: "invalid parameters: dir and repo can't be used together",
+		},
+		{
+			name: "invalid repo",
+			params: map[string]string{
+				"op":   "copy",
+				"src":  "a.txt",
+				"dest": "b.txt",
+				"repo": "templates",
+			},
+			expectedErrorMsg: "invalid repo parameter templates: expected a repository in the owner/name format",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/repos/org/templates":
+			fmt.Fprint(w, `{"default_branch": "main"}`)
+		case "/repos/org/templates/git/trees/main":
+			fmt.Fprint(w, `{"truncated": false, "tree": [
+				{"path": ".github", "mode": "040000", "type": "tree", "sha": "0"},
+				{"path": ".github/workflows", "mode": "040000", "type": "tree", "sha": "1"},
+				{"path": ".github/workflows/ci.yml", "mode": "100644", "type": "blob", "sha": "ci"},
+				{"path": ".github/workflows/release.yml", "mode": "100644", "type": "blob", "sha": "release"},
+				{"path": ".github/workflows/README.md", "mode": "100644", "type": "blob", "sha": "readme"},
+				{"path": "scripts/lint.sh", "mode": "100755", "type": "blob", "sha": "lint"}
+			]}`)
+		case "/repos/org/templates/git/blobs/ci", "/repos/org/templates/git/blobs/release", "/repos/org/templates/git/blobs/lint":
+			// the blobs are retrieved raw
+			fmt.Fprintf(w, "# %s\n", path.Base(r.URL.Path))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	ctx := ghclient.NewContext(context.Background(), client)
+
+	tests := []struct {
+		name             string
+		repoPath         string
+		files            map[string]string
+		updater          *FilesUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+		expectedMissing  []string
+		expectedDetails  string
+	}{
+		{
+			name:     "copy from local directory",
+			repoPath: "copy-from-dir",
+			files: map[string]string{
+				"repo/.github/workflows/ci.yml":    "# old ci\n",
+				"repo/.github/workflows/lint.yml":  "# lint\n",
+				"source/workflows/ci.yml":          "# ci\n",
+				"source/workflows/nested/lint.yml": "# lint\n",
+				"source/workflows/README.md":       "# readme\n",
+			},
+			updater: &FilesUpdater{
+				Operation: OperationCopy,
+				Source:    "workflows/**/*.yml",
+				Dest:      ".github/workflows",
+				Dir:       filepath.Join("testdata", "copy-from-dir", "source"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				".github/workflows/ci.yml":          "# ci\n",
+				".github/workflows/lint.yml":        "# lint\n",
+				".github/workflows/nested/lint.yml": "# lint\n",
+			},
+			expectedMissing: []string{".github/workflows/README.md"},
+			expectedDetails: "- `.github/workflows/ci.yml`: updated from `workflows/ci.yml`\n- `.github/workflows/nested/lint.yml`: created from `workflows/nested/lint.yml`",
+		},
+		{
+			name:     "copy single file inside the repository",
+			repoPath: "copy-single-file",
+			files: map[string]string{
+				"repo/.golangci.yml": "linters: {}\n",
+			},
+			updater: &FilesUpdater{
+				Operation: OperationCopy,
+				Source:    ".golangci.yml",
+				Dest:      "tools/golangci.yml",
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				".golangci.yml":      "linters: {}\n",
+				"tools/golangci.yml": "linters: {}\n",
+			},
+			expectedDetails: "- `tools/golangci.yml`: created from `.golangci.yml`",
+		},
+		{
+			name:     "copy from github repository",
+			repoPath: "copy-from-github",
+			files: map[string]string{
+				"repo/.github/workflows/release.yml": "# release\n",
+			},
+			updater: &FilesUpdater{
+				Operation: OperationCopy,
+				Source:    ".github/workflows/*.yml",
+				Dest:      ".github/workflows",
+				Repo:      "org/templates",
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				".github/workflows/ci.yml":      "# ci\n",
+				".github/workflows/release.yml": "# release\n",
+			},
+			expectedMissing: []string{".github/workflows/README.md", "scripts/lint.sh"},
+			expectedDetails: "- `.github/workflows/ci.yml`: created from `.github/workflows/ci.yml`",
+		},
+		{
+			name:     "copy without changes",
+			repoPath: "copy-no-changes",
+			files: map[string]string{
+				"repo/.golangci.yml":   "linters: {}\n",
+				"source/.golangci.yml": "linters: {}\n",
+			},
+			updater: &FilesUpdater{
+				Operation: OperationCopy,
+				Source:    ".golangci.yml",
+				Dest:      ".golangci.yml",
+				Dir:       filepath.Join("testdata", "copy-no-changes", "source"),
+			},
+			expected: false,
+		},
+		{
+			name:     "copy without matching files",
+			repoPath: "copy-no-match",
+			files: map[string]string{
+				"source/config.yaml": "config\n",
+			},
+			updater: &FilesUpdater{
+				Operation: OperationCopy,
+				Source:    "*.yml",
+				Dest:      "config",
+				Dir:       filepath.Join("testdata", "copy-no-match", "source"),
+			},
+			expectedErrorMsg: "no files matching *.yml in `source`",
+		},
+		{
+			name:     "delete files",
+			repoPath: "delete-files",
+			files: map[string]string{
+				"repo/config.yml":        "config\n",
+				"repo/config.yml.bak":    "old config\n",
+				"repo/docs/index.md.bak": "old docs\n",
+			},
+			updater: &FilesUpdater{
+				Operation: OperationDelete,
+				Source:    "**/*.bak",
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"config.yml": "config\n",
+			},
+			expectedMissing: []string{"config.yml.bak", "docs"},
+			expectedDetails: "- `config.yml.bak`: deleted\n- `docs/index.md.bak`: deleted",
+		},
+		{
+			name:     "delete directory",
+			repoPath: "delete-directory",
+			files: map[string]string{
+				"repo/config.yml":         "config\n",
+				"repo/legacy/a.txt":       "a\n",
+				"repo/legacy/nested/b.sh": "b\n",
+			},
+			updater: &FilesUpdater{
+				Operation: OperationDelete,
+				Source:    "legacy",
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"config.yml": "config\n",
+			},
+			expectedMissing: []string{"legacy"},
+			expectedDetails: "- `legacy/a.txt`: deleted\n- `legacy/nested/b.sh`: deleted",
+		},
+		{
+			name:     "delete without matching files",
+			repoPath: "delete-no-match",
+			files: map[string]string{
+				"repo/config.yml": "config\n",
+			},
+			updater: &FilesUpdater{
+				Operation: OperationDelete,
+				Source:    "**/*.bak",
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"config.yml": "config\n",
+			},
+		},
+		{
+			name:     "move directory",
+			repoPath: "move-directory",
+			files: map[string]string{
+				"repo/ci/build.sh":      "build\n",
+				"repo/ci/lib/common.sh": "common\n",
+			},
+			updater: &FilesUpdater{
+				Operation: OperationMove,
+				Source:    "ci",
+				Dest:      "scripts",
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"scripts/ci/build.sh":      "build\n",
+				"scripts/ci/lib/common.sh": "common\n",
+			},
+			expectedMissing: []string{"ci"},
+			expectedDetails: "- `ci/build.sh`: moved to `scripts/ci/build.sh`\n- `ci/lib/common.sh`: moved to `scripts/ci/lib/common.sh`",
+		},
+		{
+			name:     "rename file",
+			repoPath: "rename-file",
+			files: map[string]string{
+				"repo/.golangci.yaml": "linters: {}\n",
+			},
+			updater: &FilesUpdater{
+				Operation: OperationMove,
+				Source:    ".golangci.yaml",
+				Dest:      ".golangci.yml",
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				".golangci.yml": "linters: {}\n",
+			},
+			expectedMissing: []string{".golangci.yaml"},
+			expectedDetails: "- `.golangci.yaml`: moved to `.golangci.yml`",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			repoPath := filepath.Join("testdata", test.repoPath, "repo")
+			{
+				err := os.RemoveAll(filepath.Join("testdata", test.repoPath))
+				require.NoErrorf(t, err, "can't remove testdata directory %s", test.repoPath)
+				err = os.MkdirAll(repoPath, 0755)
+				require.NoErrorf(t, err, "can't create testdata directory %s", repoPath)
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", test.repoPath, filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", test.repoPath, filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(ctx, repoPath)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+			for filename, expectedContent := range test.expectedFiles {
+				actualContent, err := os.ReadFile(filepath.Join(repoPath, filename))
+				require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+				assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+			}
+			for _, filename := range test.expectedMissing {
+				assert.NoFileExistsf(t, filepath.Join(repoPath, filename), "testdata file %s should not exist", filename)
+				assert.NoDirExistsf(t, filepath.Join(repoPath, filename), "testdata directory %s should not exist", filename)
+			}
+			assert.Equal(t, test.expectedDetails, test.updater.Details(repoPath))
+		})
+	}
+
+	t.Run("github files are cached", func(t *testing.T) {
+		t.Parallel()
+		updater := &FilesUpdater{
+			Operation: OperationCopy,
+			Source:    "scripts/*.sh",
+			Dest:      "tools",
+			Repo:      "org/templates",
+			Ref:       "main",
+		}
+		for i := 0; i < 2; i++ {
+			repoPath := filepath.Join("testdata", "github-cache", fmt.Sprintf("repo-%d", i))
+			require.NoError(t, os.RemoveAll(repoPath))
+			require.NoError(t, os.MkdirAll(repoPath, 0755))
+
+			before := atomic.LoadInt32(&requests)
+			actual, err := updater.Update(ctx, repoPath)
+			require.NoError(t, err)
+			assert.True(t, actual)
+			if i > 0 {
+				assert.Equal(t, before, atomic.LoadInt32(&requests), "the files should not be retrieved again")
+			}
+
+			fileInfo, err := os.Stat(filepath.Join(repoPath, "tools", "lint.sh"))
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0755), fileInfo.Mode().Perm())
+		}
+	})
+}
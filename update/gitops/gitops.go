@@ -0,0 +1,313 @@
+// Package gitops provides an updater that updates the revision of Argo CD and Flux resources.
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mikefarah/yq/v4/pkg/yqlib"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	internalyaml "github.com/dailymotion-oss/octopilot/internal/yaml"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+	yamlupdater "github.com/dailymotion-oss/octopilot/update/yaml"
+)
+
+// Kind is a kind of GitOps resource.
+type Kind struct {
+	// Group is the API group of the resource - such as argoproj.io
+	Group string
+	// Paths are the paths of the revision fields, relative to the resource
+	Paths []string
+}
+
+// Kinds are the supported kinds of resources, with the paths of their revision fields.
+var Kinds = map[string]Kind{
+	// Argo CD
+	"Application": {
+		Group: "argoproj.io",
+		Paths: []string{".spec.source.targetRevision", ".spec.sources[].targetRevision"},
+	},
+	"ApplicationSet": {
+		Group: "argoproj.io",
+		Paths: []string{".spec.template.spec.source.targetRevision", ".spec.template.spec.sources[].targetRevision"},
+	},
+	// Flux
+	"HelmRelease": {
+		Group: "helm.toolkit.fluxcd.io",
+		Paths: []string{".spec.chart.spec.version"},
+	},
+	"GitRepository": {
+		Group: "source.toolkit.fluxcd.io",
+		Paths: []string{".spec.ref.tag"},
+	},
+	"OCIRepository": {
+		Group: "source.toolkit.fluxcd.io",
+		Paths: []string{".spec.ref.tag"},
+	},
+	// a Flux Kustomization has no revision of its own - it is defined by its source - so the path parameter is mandatory
+	"Kustomization": {
+		Group: "kustomize.toolkit.fluxcd.io",
+	},
+}
+
+// GitOpsUpdater is an updater that updates the revision of Argo CD and Flux resources - such as the target revision of an Argo CD application,
+// or the chart version of a Flux Helm release - defined in (multi-documents) YAML files.
+type GitOpsUpdater struct {
+	FilePath  string
+	Kind      string
+	Name      string
+	Namespace string
+	// Path is the path of the revision field, relative to the resource - it overrides the default paths of the kind
+	Path   string
+	Indent int
+	Valuer value.Valuer
+
+	change.Recorder
+}
+
+// NewUpdater builds a new GitOps updater from the given parameters and valuer
+func NewUpdater(params map[string]string, valuer value.Valuer) (*GitOpsUpdater, error) {
+	updater := &GitOpsUpdater{}
+
+	updater.FilePath = params["file"]
+	if len(updater.FilePath) == 0 {
+		return nil, errors.New("missing file parameter")
+	}
+
+	updater.Kind = params["kind"]
+	if len(updater.Kind) == 0 {
+		return nil, errors.New("missing kind parameter")
+	}
+	kind, ok := Kinds[updater.Kind]
+	if !ok {
+		return nil, fmt.Errorf("invalid kind parameter %s: must be one of %s", updater.Kind, strings.Join(kindNames(), ", "))
+	}
+
+	updater.Name = params["name"]
+	updater.Namespace = params["namespace"]
+
+	updater.Path = params["path"]
+	if len(updater.Path) == 0 && len(kind.Paths) == 0 {
+		return nil, fmt.Errorf("missing path parameter: the %s kind has no default revision field", updater.Kind)
+	}
+	if len(updater.Path) > 0 && !strings.HasPrefix(updater.Path, ".") {
+		updater.Path = "." + updater.Path
+	}
+
+	updater.Indent, _ = strconv.Atoi(params["indent"])
+	if updater.Indent <= 0 {
+		updater.Indent = 2
+	}
+
+	updater.Valuer = valuer
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *GitOpsUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	u.Reset(repoPath)
+
+	value, err := u.Valuer.Value(ctx, repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get value: %w", err)
+	}
+
+	readExpression := u.revisionsExpression()
+	updateExpression := fmt.Sprintf(`(%s) ref $x | $x = %q`, readExpression, value)
+	updateExpressionNode, err := yqlib.ExpressionParser.ParseExpression(updateExpression)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse yq expression %s: %w", updateExpression, err)
+	}
+
+	filePaths, err := glob.ExpandGlobPattern(repoPath, u.FilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to expand glob pattern %s: %w", u.FilePath, err)
+	}
+
+	const (
+		yamlColorise           = false
+		yamlPrintDocSeparators = true
+		yamlUnwrapScalar       = false
+	)
+	var (
+		yamlEncoder     = yqlib.NewYamlEncoder(u.Indent, yamlColorise, yamlPrintDocSeparators, yamlUnwrapScalar)
+		streamEvaluator = yqlib.NewStreamEvaluator()
+		updated         = false
+	)
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		}
+
+		fileData, err := os.ReadFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+		}
+
+		revisions, err := readRevisions(fileData, readExpression)
+		if err != nil {
+			return false, fmt.Errorf("failed to read revisions from file %s: %w", relFilePath, err)
+		}
+		var changes []change.Change
+		for _, revision := range revisions {
+			if revision.value != value {
+				changes = append(changes, change.Change{File: filepath.ToSlash(relFilePath), Name: revision.name, Old: revision.value, New: value})
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		reader, leadingContent, err := internalyaml.ExtractLeadingContentForYQ(bytes.NewReader(fileData))
+		if err != nil {
+			return false, fmt.Errorf("failed to extract leading content from file %s: %w", relFilePath, err)
+		}
+
+		buffer := new(bytes.Buffer)
+		printer := yqlib.NewPrinter(yamlEncoder, yqlib.NewSinglePrinterWriter(buffer))
+		_, err = streamEvaluator.Evaluate(relFilePath, reader, updateExpressionNode, printer, leadingContent, yqlib.NewYamlDecoder())
+		if err != nil {
+			return false, fmt.Errorf("failed to evaluate expression `%s` for file %s: %w", updateExpression, relFilePath, err)
+		}
+
+		if bytes.Equal(fileData, buffer.Bytes()) {
+			continue
+		}
+
+		err = os.WriteFile(filePath, buffer.Bytes(), fileInfo.Mode())
+		if err != nil {
+			return false, fmt.Errorf("failed to write file %s: %w", relFilePath, err)
+		}
+		u.Record(repoPath, changes...)
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *GitOpsUpdater) Message() (title, body string) {
+	if len(u.Name) > 0 {
+		title = fmt.Sprintf("Update %s %s", u.Kind, u.Name)
+		body = fmt.Sprintf("Updating the revision of %s `%s` in file(s) `%s`", u.Kind, u.Name, u.FilePath)
+		return title, body
+	}
+	title = fmt.Sprintf("Update %s resources in %s", u.Kind, u.FilePath)
+	body = fmt.Sprintf("Updating the revision of all %s resources in file(s) `%s`", u.Kind, u.FilePath)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *GitOpsUpdater) String() string {
+	return fmt.Sprintf("GitOps[file=%s,kind=%s,name=%s,namespace=%s,path=%s,indent=%v]", u.FilePath, u.Kind, u.Name, u.Namespace, u.Path, u.Indent)
+}
+
+// revisionsExpression returns the yq expression selecting the revision fields of the matching resources.
+// Resources are matched by API group, kind, and - optionally - name and namespace.
+func (u *GitOpsUpdater) revisionsExpression() string {
+	kind := Kinds[u.Kind]
+	conditions := []string{
+		fmt.Sprintf(`.apiVersion == %q`, kind.Group+"/*"),
+		fmt.Sprintf(`.kind == %q`, u.Kind),
+	}
+	if len(u.Name) > 0 {
+		conditions = append(conditions, fmt.Sprintf(`.metadata.name == %q`, u.Name))
+	}
+	if len(u.Namespace) > 0 {
+		conditions = append(conditions, fmt.Sprintf(`.metadata.namespace == %q`, u.Namespace))
+	}
+
+	paths := kind.Paths
+	if len(u.Path) > 0 {
+		paths = []string{yamlupdater.PathExpression(u.Path)}
+	}
+
+	return fmt.Sprintf(`select(%s) | (%s)`, strings.Join(conditions, " and "), strings.Join(paths, ", "))
+}
+
+// revision is the current value of a revision field.
+type revision struct {
+	// name is the name of the resource - prefixed by its namespace if it has one
+	name  string
+	value string
+}
+
+// readRevisions returns the current values of the revision fields matching the given expression
+// - ignoring the missing fields, such as the single source of a multi-sources Argo CD application.
+func readRevisions(data []byte, expression string) ([]revision, error) {
+	var (
+		evaluator = yqlib.NewAllAtOnceEvaluator()
+		decoder   = yaml.NewDecoder(bytes.NewReader(data))
+		revisions []revision
+	)
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+
+		matches, err := evaluator.EvaluateNodes(expression, &doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate expression `%s`: %w", expression, err)
+		}
+		if matches.Len() == 0 {
+			continue
+		}
+
+		name, err := resourceName(evaluator, &doc)
+		if err != nil {
+			return nil, err
+		}
+		for e := matches.Front(); e != nil; e = e.Next() {
+			node := e.Value.(*yqlib.CandidateNode).Node
+			if node.Kind != yaml.ScalarNode || node.Tag == "!!null" {
+				continue
+			}
+			revisions = append(revisions, revision{name: name, value: node.Value})
+		}
+	}
+	return revisions, nil
+}
+
+// resourceName returns the name of the resource defined in the given document - prefixed by its namespace if it has one.
+func resourceName(evaluator yqlib.Evaluator, doc *yaml.Node) (string, error) {
+	const expression = `[.metadata.namespace // "", .metadata.name // ""] | join("/")`
+	matches, err := evaluator.EvaluateNodes(expression, doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to evaluate expression `%s`: %w", expression, err)
+	}
+	if matches.Len() == 0 {
+		return "", nil
+	}
+	return strings.TrimPrefix(matches.Front().Value.(*yqlib.CandidateNode).Node.Value, "/"), nil
+}
+
+func kindNames() []string {
+	names := make([]string, 0, len(Kinds))
+	for name := range Kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,463 @@
+package gitops
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *GitOpsUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params",
+			params: map[string]string{
+				"file":      "apps/*.yaml",
+				"kind":      "Application",
+				"name":      "my-app",
+				"namespace": "argocd",
+				"indent":    "4",
+			},
+			expected: &GitOpsUpdater{
+				FilePath:  "apps/*.yaml",
+				Kind:      "Application",
+				Name:      "my-app",
+				Namespace: "argocd",
+				Indent:    4,
+			},
+		},
+		{
+			name: "kustomization with path",
+			params: map[string]string{
+				"file": "clusters/prod/apps.yaml",
+				"kind": "Kustomization",
+				"path": "spec.postBuild.substitute.version",
+			},
+			expected: &GitOpsUpdater{
+				FilePath: "clusters/prod/apps.yaml",
+				Kind:     "Kustomization",
+				Path:     ".spec.postBuild.substitute.version",
+				Indent:   2,
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing file parameter",
+		},
+		{
+			name: "missing kind",
+			params: map[string]string{
+				"file": "apps.yaml",
+			},
+			expectedErrorMsg: "missing kind parameter",
+		},
+		{
+			name: "invalid kind",
+			params: map[string]string{
+				"file": "apps.yaml",
+				"kind": "Deployment",
+			},
+			expectedErrorMsg: "invalid kind parameter Deployment: must be one of Application, ApplicationSet, GitRepository, HelmRelease, Kustomization, OCIRepository",
+		},
+		{
+			name: "kustomization without path",
+			params: map[string]string{
+				"file": "apps.yaml",
+				"kind": "Kustomization",
+			},
+			expectedErrorMsg: "missing path parameter: the Kustomization kind has no default revision field",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params, nil)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		files           map[string]string
+		updater         *GitOpsUpdater
+		expected        bool
+		expectedFiles   map[string]string
+		expectedChanges []change.Change
+	}{
+		{
+			name: "argo cd application by name and namespace",
+			files: map[string]string{
+				"application/apps.yaml": `# Argo CD applications
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: my-app
+  namespace: argocd
+spec:
+  source:
+    repoURL: https://charts.example.com
+    chart: my-app
+    targetRevision: 1.0.0
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: my-app
+  namespace: staging
+spec:
+  source:
+    repoURL: https://charts.example.com
+    chart: my-app
+    targetRevision: 1.0.0
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: other-app
+  namespace: argocd
+spec:
+  source:
+    targetRevision: 1.0.0
+`,
+			},
+			updater: &GitOpsUpdater{
+				FilePath:  "application/apps.yaml",
+				Kind:      "Application",
+				Name:      "my-app",
+				Namespace: "argocd",
+				Indent:    2,
+				Valuer:    value.StringValuer("1.1.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"application/apps.yaml": `# Argo CD applications
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: my-app
+  namespace: argocd
+spec:
+  source:
+    repoURL: https://charts.example.com
+    chart: my-app
+    targetRevision: 1.1.0
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: my-app
+  namespace: staging
+spec:
+  source:
+    repoURL: https://charts.example.com
+    chart: my-app
+    targetRevision: 1.0.0
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: other-app
+  namespace: argocd
+spec:
+  source:
+    targetRevision: 1.0.0
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "application/apps.yaml", Name: "argocd/my-app", Old: "1.0.0", New: "1.1.0"},
+			},
+		},
+		{
+			name: "argo cd multi-sources application",
+			files: map[string]string{
+				"multi-sources/app.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: my-app
+spec:
+  sources:
+    - repoURL: https://charts.example.com
+      chart: my-app
+      targetRevision: 1.0.0
+    - repoURL: https://github.com/org/values.git
+      targetRevision: main
+      ref: values
+`,
+			},
+			updater: &GitOpsUpdater{
+				FilePath: "multi-sources/app.yaml",
+				Kind:     "Application",
+				Path:     ".spec.sources[0].targetRevision",
+				Indent:   2,
+				Valuer:   value.StringValuer("1.1.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"multi-sources/app.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: my-app
+spec:
+  sources:
+    - repoURL: https://charts.example.com
+      chart: my-app
+      targetRevision: 1.1.0
+    - repoURL: https://github.com/org/values.git
+      targetRevision: main
+      ref: values
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "multi-sources/app.yaml", Name: "my-app", Old: "1.0.0", New: "1.1.0"},
+			},
+		},
+		{
+			name: "argo cd application set",
+			files: map[string]string{
+				"application-set/appset.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: my-app
+spec:
+  template:
+    spec:
+      source:
+        targetRevision: v1.0.0
+`,
+			},
+			updater: &GitOpsUpdater{
+				FilePath: "application-set/appset.yaml",
+				Kind:     "ApplicationSet",
+				Name:     "my-app",
+				Indent:   2,
+				Valuer:   value.StringValuer("v1.1.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"application-set/appset.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: my-app
+spec:
+  template:
+    spec:
+      source:
+        targetRevision: v1.1.0
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "application-set/appset.yaml", Name: "my-app", Old: "v1.0.0", New: "v1.1.0"},
+			},
+		},
+		{
+			name: "flux resources in multiple files",
+			files: map[string]string{
+				"flux/app/release.yaml": `apiVersion: helm.toolkit.fluxcd.io/v2beta1
+kind: HelmRelease
+metadata:
+  name: my-app
+  namespace: apps
+spec:
+  chart:
+    spec:
+      chart: my-app
+      version: "1.0.0"
+`,
+				"flux/other/release.yaml": `apiVersion: helm.toolkit.fluxcd.io/v2beta1
+kind: HelmRelease
+metadata:
+  name: other-app
+spec:
+  chart:
+    spec:
+      version: 1.0.0
+---
+apiVersion: source.toolkit.fluxcd.io/v1
+kind: GitRepository
+metadata:
+  name: my-app
+spec:
+  ref:
+    tag: v1.0.0
+`,
+			},
+			updater: &GitOpsUpdater{
+				FilePath: "flux/**/*.yaml",
+				Kind:     "HelmRelease",
+				Name:     "my-app",
+				Indent:   2,
+				Valuer:   value.StringValuer("1.1.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"flux/app/release.yaml": `apiVersion: helm.toolkit.fluxcd.io/v2beta1
+kind: HelmRelease
+metadata:
+  name: my-app
+  namespace: apps
+spec:
+  chart:
+    spec:
+      chart: my-app
+      version: "1.1.0"
+`,
+				"flux/other/release.yaml": `apiVersion: helm.toolkit.fluxcd.io/v2beta1
+kind: HelmRelease
+metadata:
+  name: other-app
+spec:
+  chart:
+    spec:
+      version: 1.0.0
+---
+apiVersion: source.toolkit.fluxcd.io/v1
+kind: GitRepository
+metadata:
+  name: my-app
+spec:
+  ref:
+    tag: v1.0.0
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "flux/app/release.yaml", Name: "apps/my-app", Old: "1.0.0", New: "1.1.0"},
+			},
+		},
+		{
+			name: "flux git repository",
+			files: map[string]string{
+				"git-repository/source.yaml": `apiVersion: source.toolkit.fluxcd.io/v1
+kind: GitRepository
+metadata:
+  name: my-app
+spec:
+  url: https://github.com/org/my-app
+  ref:
+    tag: v1.0.0
+`,
+			},
+			updater: &GitOpsUpdater{
+				FilePath: "git-repository/source.yaml",
+				Kind:     "GitRepository",
+				Name:     "my-app",
+				Indent:   2,
+				Valuer:   value.StringValuer("v1.1.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"git-repository/source.yaml": `apiVersion: source.toolkit.fluxcd.io/v1
+kind: GitRepository
+metadata:
+  name: my-app
+spec:
+  url: https://github.com/org/my-app
+  ref:
+    tag: v1.1.0
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "git-repository/source.yaml", Name: "my-app", Old: "v1.0.0", New: "v1.1.0"},
+			},
+		},
+		{
+			name: "already up to date",
+			files: map[string]string{
+				"up-to-date/release.yaml": `apiVersion: helm.toolkit.fluxcd.io/v2beta1
+kind: HelmRelease
+metadata:
+  name: my-app
+spec:
+  chart:
+    spec:
+      version: 1.1.0
+`,
+			},
+			updater: &GitOpsUpdater{
+				FilePath: "up-to-date/release.yaml",
+				Kind:     "HelmRelease",
+				Indent:   2,
+				Valuer:   value.StringValuer("1.1.0"),
+			},
+			expected: false,
+		},
+		{
+			name: "other api group",
+			files: map[string]string{
+				"other-group/app.yaml": `apiVersion: app.k8s.io/v1beta1
+kind: Application
+metadata:
+  name: my-app
+spec:
+  source:
+    targetRevision: 1.0.0
+`,
+			},
+			updater: &GitOpsUpdater{
+				FilePath: "other-group/app.yaml",
+				Kind:     "Application",
+				Name:     "my-app",
+				Indent:   2,
+				Valuer:   value.StringValuer("1.1.0"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"other-group/app.yaml": `apiVersion: app.k8s.io/v1beta1
+kind: Application
+metadata:
+  name: my-app
+spec:
+  source:
+    targetRevision: 1.0.0
+`,
+			},
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(context.Background(), "testdata")
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+			for filename, expectedContent := range test.expectedFiles {
+				actualContent, err := os.ReadFile(filepath.Join("testdata", filename))
+				require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+				assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+			}
+			assert.Equal(t, test.expectedChanges, test.updater.Changes("testdata"))
+		})
+	}
+}
@@ -0,0 +1,301 @@
+// Package gomod provides an updater that updates the version of a Go module dependency in go.mod files.
+package gomod
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// definition of the different modes to handle the go.sum files
+const (
+	SumKeep       = "keep"
+	SumDrop       = "drop"
+	SumRegenerate = "regenerate"
+)
+
+// GoModUpdater is an updater that updates the version of a Go module dependency in go.mod files:
+// both in the require directives, and in the replace directives using the module as replacement.
+type GoModUpdater struct {
+	Module   string
+	FilePath string
+	Sum      string
+	Valuer   value.Valuer
+
+	change.Recorder
+}
+
+// NewUpdater builds a new Go module updater from the given parameters and valuer
+func NewUpdater(params map[string]string, valuer value.Valuer) (*GoModUpdater, error) {
+	updater := &GoModUpdater{}
+
+	updater.Module = params["module"]
+	if len(updater.Module) == 0 {
+		return nil, errors.New("missing module parameter")
+	}
+	if err := module.CheckImportPath(updater.Module); err != nil {
+		return nil, fmt.Errorf("invalid module parameter: %w", err)
+	}
+
+	updater.FilePath = params["file"]
+	if len(updater.FilePath) == 0 {
+		updater.FilePath = "go.mod"
+	}
+
+	updater.Sum = params["sum"]
+	switch updater.Sum {
+	case "":
+		updater.Sum = SumKeep
+	case SumKeep, SumDrop, SumRegenerate:
+	default:
+		return nil, fmt.Errorf("invalid sum parameter %s: expected one of %s, %s or %s", updater.Sum, SumKeep, SumDrop, SumRegenerate)
+	}
+
+	updater.Valuer = valuer
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *GoModUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	u.Reset(repoPath)
+
+	version, err := u.Valuer.Value(ctx, repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get value: %w", err)
+	}
+	if !strings.HasPrefix(version, "v") && semver.IsValid("v"+version) {
+		version = "v" + version
+	}
+	if !semver.IsValid(version) {
+		return false, fmt.Errorf("invalid version %s for module %s: expected a semantic version such as v1.2.3", version, u.Module)
+	}
+
+	filePaths, err := glob.ExpandGlobPattern(repoPath, u.FilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to expand glob pattern %s: %w", u.FilePath, err)
+	}
+
+	var updated bool
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+		}
+
+		modFile, err := modfile.Parse(relFilePath, content, nil)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse go.mod file %s: %w", relFilePath, err)
+		}
+
+		changes, oldVersions, err := u.updateModFile(modFile, relFilePath, version)
+		if err != nil {
+			return false, fmt.Errorf("failed to update go.mod file %s: %w", relFilePath, err)
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		updatedContent, err := modFile.Format()
+		if err != nil {
+			return false, fmt.Errorf("failed to format go.mod file %s: %w", relFilePath, err)
+		}
+		if err = os.WriteFile(filePath, updatedContent, fileInfo.Mode()); err != nil {
+			return false, fmt.Errorf("failed to write updated content to file %s: %w", relFilePath, err)
+		}
+
+		if u.Sum != SumKeep {
+			sumFilePath := filepath.Join(filepath.Dir(filePath), "go.sum")
+			if err = u.updateSumFile(ctx, sumFilePath, oldVersions, version); err != nil {
+				return false, fmt.Errorf("failed to update go.sum file for %s: %w", relFilePath, err)
+			}
+		}
+
+		u.Record(repoPath, changes...)
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// updateModFile updates the require and replace directives for the module,
+// and returns the changes - and the old versions of the module.
+func (u *GoModUpdater) updateModFile(modFile *modfile.File, relFilePath, version string) ([]change.Change, []string, error) {
+	var (
+		changes     []change.Change
+		oldVersions []string
+		replaces    []*modfile.Replace
+	)
+
+	for _, require := range modFile.Require {
+		if require.Mod.Path == u.Module && require.Mod.Version != version {
+			changes = append(changes, change.Change{File: filepath.ToSlash(relFilePath), Name: u.Module, Old: require.Mod.Version, New: version})
+			oldVersions = append(oldVersions, require.Mod.Version)
+		}
+	}
+	if len(changes) > 0 {
+		if err := modFile.AddRequire(u.Module, version); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, replace := range modFile.Replace {
+		// a replacement without version is a local directory
+		if replace.New.Path == u.Module && len(replace.New.Version) > 0 && replace.New.Version != version {
+			replaces = append(replaces, replace)
+		}
+	}
+	for _, replace := range replaces {
+		changes = append(changes, change.Change{File: filepath.ToSlash(relFilePath), Name: fmt.Sprintf("%s (replace)", u.Module), Old: replace.New.Version, New: version})
+		oldVersions = append(oldVersions, replace.New.Version)
+		if err := modFile.AddReplace(replace.Old.Path, replace.Old.Version, replace.New.Path, version); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	modFile.Cleanup()
+	return changes, oldVersions, nil
+}
+
+// updateSumFile drops the lines of the go.sum file for the old versions of the module,
+// and adds the lines for the new version if the sums should be regenerated.
+func (u *GoModUpdater) updateSumFile(ctx context.Context, sumFilePath string, oldVersions []string, version string) error {
+	// a missing go.sum file is created when the sums are regenerated
+	fileMode := os.FileMode(0644)
+	fileInfo, err := os.Stat(sumFilePath)
+	switch {
+	case err == nil:
+		fileMode = fileInfo.Mode()
+	case !os.IsNotExist(err):
+		return fmt.Errorf("failed to access file %s: %w", sumFilePath, err)
+	}
+
+	content, err := os.ReadFile(sumFilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read file %s: %w", sumFilePath, err)
+	}
+
+	var (
+		versions []module.Version
+		hashes   = make(map[module.Version]string)
+	)
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		mod := module.Version{Path: fields[0], Version: fields[1]}
+		if mod.Path == u.Module {
+			modVersion := strings.TrimSuffix(mod.Version, "/go.mod")
+			if modVersion == version || slices.Contains(oldVersions, modVersion) {
+				continue
+			}
+		}
+		if _, found := hashes[mod]; !found {
+			versions = append(versions, mod)
+		}
+		hashes[mod] = fields[2]
+	}
+
+	if u.Sum == SumRegenerate {
+		sum, goModSum, err := downloadModule(ctx, u.Module, version)
+		if err != nil {
+			return err
+		}
+		for mod, hash := range map[module.Version]string{
+			{Path: u.Module, Version: version}:             sum,
+			{Path: u.Module, Version: version + "/go.mod"}: goModSum,
+		} {
+			versions = append(versions, mod)
+			hashes[mod] = hash
+		}
+	}
+
+	module.Sort(versions)
+	var buffer bytes.Buffer
+	for _, mod := range versions {
+		fmt.Fprintf(&buffer, "%s %s %s\n", mod.Path, mod.Version, hashes[mod])
+	}
+
+	if bytes.Equal(content, buffer.Bytes()) {
+		return nil
+	}
+	if err = os.WriteFile(sumFilePath, buffer.Bytes(), fileMode); err != nil {
+		return fmt.Errorf("failed to write updated content to file %s: %w", sumFilePath, err)
+	}
+	return nil
+}
+
+// downloadModule downloads the given version of a module using the go command
+// - so that it uses the same settings (proxy, private modules, ...) as a developer would -
+// and returns the hashes of its content and its go.mod file.
+func downloadModule(ctx context.Context, modulePath, version string) (sum, goModSum string, err error) {
+	// run outside of any module, to avoid changing the go.mod/go.sum files of the repository
+	tmpDir, err := os.MkdirTemp("", "octopilot-gomod-")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var (
+		stdout bytes.Buffer
+		stderr bytes.Buffer
+		target = fmt.Sprintf("%s@%s", modulePath, version)
+	)
+	cmd := exec.CommandContext(ctx, "go", "mod", "download", "-json", target)
+	cmd.Dir = tmpDir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("failed to run cmd 'go mod download -json %s' - got stdout [%s] and stderr [%s]: %w", target, strings.TrimSpace(stdout.String()), strings.TrimSpace(stderr.String()), err)
+	}
+
+	var result struct {
+		Sum      string
+		GoModSum string
+	}
+	if err = json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		return "", "", fmt.Errorf("failed to decode output of 'go mod download -json %s': %w", target, err)
+	}
+	if len(result.Sum) == 0 || len(result.GoModSum) == 0 {
+		return "", "", fmt.Errorf("missing sums in output of 'go mod download -json %s': %s", target, strings.TrimSpace(stdout.String()))
+	}
+	return result.Sum, result.GoModSum, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *GoModUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Update Go module %s", u.Module)
+	body = fmt.Sprintf("Updating Go module `%s` in file(s) `%s`", u.Module, u.FilePath)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *GoModUpdater) String() string {
+	return fmt.Sprintf("GoMod[module=%s,file=%s,sum=%s]", u.Module, u.FilePath, u.Sum)
+}
@@ -0,0 +1,324 @@
+package gomod
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *GoModUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params with default values",
+			params: map[string]string{
+				"module": "github.com/org/lib",
+			},
+			expected: &GoModUpdater{
+				Module:   "github.com/org/lib",
+				FilePath: "go.mod",
+				Sum:      SumKeep,
+			},
+		},
+		{
+			name: "valid params with multiple files using a glob pattern",
+			params: map[string]string{
+				"module": "github.com/org/lib/v2",
+				"file":   "**/go.mod",
+				"sum":    "drop",
+			},
+			expected: &GoModUpdater{
+				Module:   "github.com/org/lib/v2",
+				FilePath: "**/go.mod",
+				Sum:      SumDrop,
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing module parameter",
+		},
+		{
+			name: "invalid module",
+			params: map[string]string{
+				"module": "github.com/org/lib@v1",
+			},
+			expectedErrorMsg: `invalid module parameter: malformed import path "github.com/org/lib@v1": invalid char '@'`,
+		},
+		{
+			name: "invalid sum param",
+			params: map[string]string{
+				"module": "github.com/org/lib",
+				"sum":    "tidy",
+			},
+			expectedErrorMsg: "invalid sum parameter tidy: expected one of keep, drop or regenerate",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params, nil)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		files            map[string]string
+		updater          *GoModUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+		expectedChanges  []change.Change
+	}{
+		{
+			name: "update require directive and keep go.sum",
+			files: map[string]string{
+				"require/go.mod": `module github.com/org/app
+
+go 1.21
+
+require (
+	github.com/org/lib v1.0.0 // our lib
+	github.com/other/lib v0.1.0
+)
+`,
+				"require/go.sum": `github.com/org/lib v1.0.0 h1:abc=
+github.com/org/lib v1.0.0/go.mod h1:def=
+`,
+			},
+			updater: &GoModUpdater{
+				Module:   "github.com/org/lib",
+				FilePath: "require/go.mod",
+				Sum:      SumKeep,
+				Valuer:   value.StringValuer("1.1.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"require/go.mod": `module github.com/org/app
+
+go 1.21
+
+require (
+	github.com/org/lib v1.1.0 // our lib
+	github.com/other/lib v0.1.0
+)
+`,
+				"require/go.sum": `github.com/org/lib v1.0.0 h1:abc=
+github.com/org/lib v1.0.0/go.mod h1:def=
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "require/go.mod", Name: "github.com/org/lib", Old: "v1.0.0", New: "v1.1.0"},
+			},
+		},
+		{
+			name: "update require and replace directives and drop go.sum lines",
+			files: map[string]string{
+				"replace/go.mod": `module github.com/org/app
+
+go 1.21
+
+require github.com/org/lib v1.0.0 // indirect
+
+replace (
+	github.com/org/lib => github.com/org/lib v1.0.0
+	github.com/upstream/lib v0.1.0 => github.com/org/lib v1.0.0
+	github.com/org/tools => ../tools
+)
+`,
+				"replace/go.sum": `github.com/org/lib v1.0.0 h1:abc=
+github.com/org/lib v1.0.0/go.mod h1:def=
+github.com/other/lib v0.1.0 h1:ghi=
+github.com/other/lib v0.1.0/go.mod h1:jkl=
+`,
+			},
+			updater: &GoModUpdater{
+				Module:   "github.com/org/lib",
+				FilePath: "replace/go.mod",
+				Sum:      SumDrop,
+				Valuer:   value.StringValuer("v1.1.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"replace/go.mod": `module github.com/org/app
+
+go 1.21
+
+require github.com/org/lib v1.1.0 // indirect
+
+replace (
+	github.com/org/lib => github.com/org/lib v1.1.0
+	github.com/upstream/lib v0.1.0 => github.com/org/lib v1.1.0
+	github.com/org/tools => ../tools
+)
+`,
+				"replace/go.sum": `github.com/other/lib v0.1.0 h1:ghi=
+github.com/other/lib v0.1.0/go.mod h1:jkl=
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "replace/go.mod", Name: "github.com/org/lib", Old: "v1.0.0", New: "v1.1.0"},
+				{File: "replace/go.mod", Name: "github.com/org/lib (replace)", Old: "v1.0.0", New: "v1.1.0"},
+				{File: "replace/go.mod", Name: "github.com/org/lib (replace)", Old: "v1.0.0", New: "v1.1.0"},
+			},
+		},
+		{
+			name: "update multiple modules and regenerate go.sum lines",
+			files: map[string]string{
+				"multi/first/go.mod": `module github.com/org/first
+
+go 1.21
+
+require golang.org/x/mod v0.17.0
+`,
+				"multi/first/go.sum": `github.com/other/lib v0.1.0 h1:ghi=
+golang.org/x/mod v0.17.0 h1:abc=
+golang.org/x/mod v0.17.0/go.mod h1:def=
+`,
+				"multi/second/go.mod": `module github.com/org/second
+
+go 1.21
+
+require golang.org/x/mod v0.18.0
+`,
+			},
+			updater: &GoModUpdater{
+				Module:   "golang.org/x/mod",
+				FilePath: "multi/**/go.mod",
+				Sum:      SumRegenerate,
+				Valuer:   value.StringValuer("v0.18.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"multi/first/go.mod": `module github.com/org/first
+
+go 1.21
+
+require golang.org/x/mod v0.18.0
+`,
+				"multi/first/go.sum": `github.com/other/lib v0.1.0 h1:ghi=
+golang.org/x/mod v0.18.0 h1:5+9lSbEzPSdWkH32vYPBwEpX8KwDbM52Ud9xBUvNlb0=
+golang.org/x/mod v0.18.0/go.mod h1:hTbmBsO62+eylJbnUtE2MGJUyE7QWk4xUqPFrRgJ+7c=
+`,
+				"multi/second/go.mod": `module github.com/org/second
+
+go 1.21
+
+require golang.org/x/mod v0.18.0
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "multi/first/go.mod", Name: "golang.org/x/mod", Old: "v0.17.0", New: "v0.18.0"},
+			},
+		},
+		{
+			name: "no changes if the module is not a dependency",
+			files: map[string]string{
+				"no-dependency/go.mod": `module github.com/org/app
+
+go 1.21
+
+require github.com/other/lib v0.1.0
+`,
+			},
+			updater: &GoModUpdater{
+				Module:   "github.com/org/lib",
+				FilePath: "no-dependency/go.mod",
+				Sum:      SumKeep,
+				Valuer:   value.StringValuer("v1.1.0"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-dependency/go.mod": `module github.com/org/app
+
+go 1.21
+
+require github.com/other/lib v0.1.0
+`,
+			},
+		},
+		{
+			name: "invalid version",
+			files: map[string]string{
+				"invalid-version/go.mod": `module github.com/org/app
+`,
+			},
+			updater: &GoModUpdater{
+				Module:   "github.com/org/lib",
+				FilePath: "invalid-version/go.mod",
+				Sum:      SumKeep,
+				Valuer:   value.StringValuer("main"),
+			},
+			expectedErrorMsg: "invalid version main for module github.com/org/lib: expected a semantic version such as v1.2.3",
+		},
+		{
+			name: "invalid go.mod file",
+			files: map[string]string{
+				"invalid-file/go.mod": `module github.com/org/app
+
+require github.com/org/lib
+`,
+			},
+			updater: &GoModUpdater{
+				Module:   "github.com/org/lib",
+				FilePath: "invalid-file/go.mod",
+				Sum:      SumKeep,
+				Valuer:   value.StringValuer("v1.1.0"),
+			},
+			expectedErrorMsg: "failed to parse go.mod file invalid-file/go.mod: invalid-file/go.mod:3: usage: require module/path v1.2.3",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(context.Background(), "testdata")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+				for filename, expectedContent := range test.expectedFiles {
+					actualContent, err := os.ReadFile(filepath.Join("testdata", filename))
+					require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+					assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+				}
+				assert.Equal(t, test.expectedChanges, test.updater.Changes("testdata"))
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+// Package hcl provides an updater that updates HCL files - such as Terraform files - while preserving the formatting and the comments.
+package hcl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// HclUpdater is an updater that updates the value of an attribute of one or more blocks, in HCL files.
+type HclUpdater struct {
+	FilePath  string
+	Block     string
+	Attribute string
+	Valuer    value.Valuer
+
+	change.Recorder
+}
+
+// NewUpdater builds a new HCL updater from the given parameters and valuer
+func NewUpdater(params map[string]string, valuer value.Valuer) (*HclUpdater, error) {
+	updater := &HclUpdater{}
+
+	updater.FilePath = params["file"]
+	if len(updater.FilePath) == 0 {
+		return nil, errors.New("missing file parameter")
+	}
+
+	updater.Block = params["block"]
+	for _, segment := range splitPath(updater.Block) {
+		if len(segment) == 0 {
+			return nil, fmt.Errorf("invalid block parameter %s: empty segment", updater.Block)
+		}
+	}
+
+	updater.Attribute = params["attribute"]
+	if len(updater.Attribute) == 0 {
+		return nil, errors.New("missing attribute parameter")
+	}
+	for _, segment := range splitPath(updater.Attribute) {
+		if len(segment) == 0 {
+			return nil, fmt.Errorf("invalid attribute parameter %s: empty segment", updater.Attribute)
+		}
+	}
+
+	updater.Valuer = valuer
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *HclUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	u.Reset(repoPath)
+
+	value, err := u.Valuer.Value(ctx, repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get value: %w", err)
+	}
+
+	filePaths, err := glob.ExpandGlobPattern(repoPath, u.FilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to expand glob pattern %s: %w", u.FilePath, err)
+	}
+
+	var updated bool
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+		}
+
+		file, diags := hclwrite.ParseConfig(content, relFilePath, hcl.InitialPos)
+		if diags.HasErrors() {
+			return false, fmt.Errorf("failed to parse file %s: %w", relFilePath, diags)
+		}
+
+		var changes []change.Change
+		for _, match := range findBlocks(file.Body(), splitPath(u.Block), nil) {
+			if c, ok := u.updateAttribute(match, value); ok {
+				c.File = filepath.ToSlash(relFilePath)
+				changes = append(changes, c)
+			}
+		}
+
+		newContent := file.Bytes()
+		if bytes.Equal(content, newContent) {
+			continue
+		}
+
+		if err = os.WriteFile(filePath, newContent, fileInfo.Mode()); err != nil {
+			return false, fmt.Errorf("failed to write updated content to file %s: %w", relFilePath, err)
+		}
+
+		u.Record(repoPath, changes...)
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *HclUpdater) Message() (title, body string) {
+	path := u.Attribute
+	if len(u.Block) > 0 {
+		path = u.Block + "." + u.Attribute
+	}
+	title = fmt.Sprintf("Update %s", path)
+	body = fmt.Sprintf("Updating attribute `%s` in file(s) `%s`", path, u.FilePath)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *HclUpdater) String() string {
+	return fmt.Sprintf("HCL[block=%s,attribute=%s,file=%s]", u.Block, u.Attribute, u.FilePath)
+}
+
+// blockMatch is a body matching the block path, with the actual path of the block - such as module.vpc
+type blockMatch struct {
+	body *hclwrite.Body
+	path []string
+}
+
+// findBlocks returns the bodies of the nested blocks matching the given path, made of the type and labels of each block - such as
+// module.vpc or terraform.required_providers. A "*" segment matches any block type or label.
+func findBlocks(body *hclwrite.Body, path, parentPath []string) []blockMatch {
+	if len(path) == 0 {
+		return []blockMatch{{body: body, path: parentPath}}
+	}
+
+	var matches []blockMatch
+	for _, block := range body.Blocks() {
+		segments := append([]string{block.Type()}, block.Labels()...)
+		if len(segments) > len(path) {
+			continue
+		}
+		matching := true
+		for i, segment := range segments {
+			if path[i] != "*" && path[i] != segment {
+				matching = false
+				break
+			}
+		}
+		if matching {
+			blockPath := append(append([]string{}, parentPath...), segments...)
+			matches = append(matches, findBlocks(block.Body(), path[len(segments):], blockPath)...)
+		}
+	}
+	return matches
+}
+
+// updateAttribute updates the attribute in the given block - or a key of its value if it's an object, such as aws.version
+// for the providers in a required_providers block.
+func (u *HclUpdater) updateAttribute(match blockMatch, value string) (change.Change, bool) {
+	var (
+		keys = splitPath(u.Attribute)
+		attr = match.body.GetAttribute(keys[0])
+	)
+	if attr == nil {
+		return change.Change{}, false
+	}
+
+	tokens := attr.Expr().BuildTokens(nil)
+	start, end, found := findObjectValue(tokens, keys[1:])
+	if !found {
+		return change.Change{}, false
+	}
+
+	oldValue := tokensValue(tokens[start:end])
+	if oldValue == value {
+		return change.Change{}, false
+	}
+
+	newTokens := valueTokens(tokens[start:end], value)
+	newTokens[0].SpacesBefore = tokens[start].SpacesBefore
+	updatedTokens := append(append(append(hclwrite.Tokens{}, tokens[:start]...), newTokens...), tokens[end:]...)
+	match.body.SetAttributeRaw(keys[0], updatedTokens)
+
+	return change.Change{
+		Name: strings.Join(append(append([]string{}, match.path...), keys...), "."),
+		Old:  oldValue,
+		New:  value,
+	}, true
+}
+
+// findObjectValue returns the range of the tokens of the value for the given keys, in the tokens of an object
+// - such as { source = "hashicorp/aws", version = "~> 5.0" }. Without keys, the range is all the tokens.
+func findObjectValue(tokens hclwrite.Tokens, keys []string) (int, int, bool) {
+	start, end := 0, len(tokens)
+	for _, key := range keys {
+		s, e, found := objectMember(tokens[start:end], key)
+		if !found {
+			return 0, 0, false
+		}
+		start, end = start+s, start+e
+	}
+	return start, end, end > start
+}
+
+// objectMember returns the range of the tokens of the value for the given key, in the tokens of an object.
+func objectMember(tokens hclwrite.Tokens, key string) (int, int, bool) {
+	if len(tokens) < 2 || tokens[0].Type != hclsyntax.TokenOBrace {
+		return 0, 0, false
+	}
+
+	depth := 0
+	for i := 1; i < len(tokens); i++ {
+		switch tokens[i].Type {
+		case hclsyntax.TokenOBrace, hclsyntax.TokenOBrack, hclsyntax.TokenOParen, hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl:
+			depth++
+			continue
+		case hclsyntax.TokenCBrace, hclsyntax.TokenCBrack, hclsyntax.TokenCParen, hclsyntax.TokenTemplateSeqEnd:
+			depth--
+			continue
+		}
+		if depth != 0 {
+			continue
+		}
+
+		// a key is either an identifier or a quoted string, followed by "=" or ":"
+		var name string
+		next := i + 1
+		switch {
+		case tokens[i].Type == hclsyntax.TokenIdent:
+			name = string(tokens[i].Bytes)
+		case tokens[i].Type == hclsyntax.TokenOQuote && i+2 < len(tokens) && tokens[i+1].Type == hclsyntax.TokenQuotedLit && tokens[i+2].Type == hclsyntax.TokenCQuote:
+			name = string(tokens[i+1].Bytes)
+			next = i + 3
+		default:
+			continue
+		}
+		if next >= len(tokens) || (tokens[next].Type != hclsyntax.TokenEqual && tokens[next].Type != hclsyntax.TokenColon) {
+			continue
+		}
+
+		// the value ends at the end of the line, at the next member, or at the end of the object
+		start, end, valueDepth := next+1, next+1, 0
+	value:
+		for ; end < len(tokens); end++ {
+			switch tokens[end].Type {
+			case hclsyntax.TokenOBrace, hclsyntax.TokenOBrack, hclsyntax.TokenOParen, hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl:
+				valueDepth++
+			case hclsyntax.TokenCBrace, hclsyntax.TokenCBrack, hclsyntax.TokenCParen, hclsyntax.TokenTemplateSeqEnd:
+				if valueDepth == 0 {
+					break value
+				}
+				valueDepth--
+			case hclsyntax.TokenNewline, hclsyntax.TokenComma, hclsyntax.TokenComment:
+				if valueDepth == 0 {
+					break value
+				}
+			}
+		}
+		if name == key {
+			return start, end, end > start
+		}
+		i = end - 1
+	}
+	return 0, 0, false
+}
+
+// tokensValue returns the value of a literal string - or the raw representation of any other expression.
+func tokensValue(tokens hclwrite.Tokens) string {
+	switch {
+	case len(tokens) == 2 && tokens[0].Type == hclsyntax.TokenOQuote && tokens[1].Type == hclsyntax.TokenCQuote:
+		return ""
+	case len(tokens) == 3 && tokens[0].Type == hclsyntax.TokenOQuote && tokens[1].Type == hclsyntax.TokenQuotedLit && tokens[2].Type == hclsyntax.TokenCQuote:
+		if unquoted, err := strconv.Unquote(`"` + string(tokens[1].Bytes) + `"`); err == nil {
+			return unquoted
+		}
+		return string(tokens[1].Bytes)
+	}
+	return strings.TrimSpace(string(tokens.Bytes()))
+}
+
+// valueTokens returns the tokens for the new value: a number or a bool if the old value was a number or a bool
+// - and the new value is compatible - or a string otherwise.
+func valueTokens(oldTokens hclwrite.Tokens, value string) hclwrite.Tokens {
+	if len(oldTokens) == 1 {
+		switch oldTokens[0].Type {
+		case hclsyntax.TokenNumberLit:
+			if number, err := cty.ParseNumberVal(value); err == nil {
+				return hclwrite.TokensForValue(number)
+			}
+		case hclsyntax.TokenIdent:
+			if boolean, err := strconv.ParseBool(value); err == nil && (value == "true" || value == "false") {
+				return hclwrite.TokensForValue(cty.BoolVal(boolean))
+			}
+		}
+	}
+	return hclwrite.TokensForValue(cty.StringVal(value))
+}
+
+func splitPath(path string) []string {
+	if len(path) == 0 {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
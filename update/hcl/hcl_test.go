@@ -0,0 +1,343 @@
+package hcl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *HclUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params",
+			params: map[string]string{
+				"file":      "**/*.tf",
+				"block":     "module.vpc",
+				"attribute": "version",
+			},
+			expected: &HclUpdater{
+				FilePath:  "**/*.tf",
+				Block:     "module.vpc",
+				Attribute: "version",
+			},
+		},
+		{
+			name: "valid params without block",
+			params: map[string]string{
+				"file":      "terragrunt.hcl",
+				"attribute": "inputs.image_tag",
+			},
+			expected: &HclUpdater{
+				FilePath:  "terragrunt.hcl",
+				Attribute: "inputs.image_tag",
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing file parameter",
+		},
+		{
+			name: "missing attribute",
+			params: map[string]string{
+				"file":  "main.tf",
+				"block": "module.vpc",
+			},
+			expectedErrorMsg: "missing attribute parameter",
+		},
+		{
+			name: "invalid block",
+			params: map[string]string{
+				"file":      "main.tf",
+				"block":     "module..vpc",
+				"attribute": "version",
+			},
+			expectedErrorMsg: "invalid block parameter module..vpc: empty segment",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params, nil)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		files            map[string]string
+		updater          *HclUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+		expectedChanges  []change.Change
+	}{
+		{
+			name: "update module version",
+			files: map[string]string{
+				"module/main.tf": `# the network
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.0" # pinned
+
+  name = "my-vpc"
+  cidr = "10.0.0.0/16"
+}
+
+module "other" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.0"
+}
+`,
+			},
+			updater: &HclUpdater{
+				FilePath:  "module/*.tf",
+				Block:     "module.vpc",
+				Attribute: "version",
+				Valuer:    value.StringValuer("5.2.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"module/main.tf": `# the network
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.2.0" # pinned
+
+  name = "my-vpc"
+  cidr = "10.0.0.0/16"
+}
+
+module "other" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.0"
+}
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "module/main.tf", Name: "module.vpc.version", Old: "5.1.0", New: "5.2.0"},
+			},
+		},
+		{
+			name: "update required providers",
+			files: map[string]string{
+				"providers/versions.tf": `terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 4.0" # major
+    }
+    random = { source = "hashicorp/random", version = "3.5.1" }
+  }
+}
+`,
+			},
+			updater: &HclUpdater{
+				FilePath:  "providers/versions.tf",
+				Block:     "terraform.required_providers",
+				Attribute: "aws.version",
+				Valuer:    value.StringValuer("~> 5.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"providers/versions.tf": `terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0" # major
+    }
+    random = { source = "hashicorp/random", version = "3.5.1" }
+  }
+}
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "providers/versions.tf", Name: "terraform.required_providers.aws.version", Old: "~> 4.0", New: "~> 5.0"},
+			},
+		},
+		{
+			name: "update wildcard labels",
+			files: map[string]string{
+				"wildcard/resources.tf": `resource "aws_instance" "web" {
+  count = 1
+}
+
+resource "aws_instance" "worker" {
+  count = 2
+}
+
+resource "aws_s3_bucket" "logs" {
+  count = 1
+}
+`,
+			},
+			updater: &HclUpdater{
+				FilePath:  "wildcard/*.tf",
+				Block:     "*.aws_instance.*",
+				Attribute: "count",
+				Valuer:    value.StringValuer("3"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"wildcard/resources.tf": `resource "aws_instance" "web" {
+  count = 3
+}
+
+resource "aws_instance" "worker" {
+  count = 3
+}
+
+resource "aws_s3_bucket" "logs" {
+  count = 1
+}
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "wildcard/resources.tf", Name: "resource.aws_instance.web.count", Old: "1", New: "3"},
+				{File: "wildcard/resources.tf", Name: "resource.aws_instance.worker.count", Old: "2", New: "3"},
+			},
+		},
+		{
+			name: "update single-line object",
+			files: map[string]string{
+				"inline/versions.tf": `terraform {
+  required_providers {
+    random = { source = "hashicorp/random", version = "3.5.1" }
+  }
+}
+`,
+			},
+			updater: &HclUpdater{
+				FilePath:  "inline/versions.tf",
+				Block:     "terraform.required_providers",
+				Attribute: "random.version",
+				Valuer:    value.StringValuer("3.6.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"inline/versions.tf": `terraform {
+  required_providers {
+    random = { source = "hashicorp/random", version = "3.6.0" }
+  }
+}
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "inline/versions.tf", Name: "terraform.required_providers.random.version", Old: "3.5.1", New: "3.6.0"},
+			},
+		},
+		{
+			name: "update root attribute with quoted key",
+			files: map[string]string{
+				"root/terragrunt.hcl": `inputs = {
+  image = "app:${local.tag}"
+  "tag" = "1.0.0"
+}
+`,
+			},
+			updater: &HclUpdater{
+				FilePath:  "root/terragrunt.hcl",
+				Attribute: "inputs.tag",
+				Valuer:    value.StringValuer("${not-a-template}"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"root/terragrunt.hcl": `inputs = {
+  image = "app:${local.tag}"
+  "tag" = "$${not-a-template}"
+}
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "root/terragrunt.hcl", Name: "inputs.tag", Old: "1.0.0", New: "${not-a-template}"},
+			},
+		},
+		{
+			name: "no changes",
+			files: map[string]string{
+				"no-changes/main.tf": `module "vpc" {
+  version = "5.1.0"
+}
+`,
+			},
+			updater: &HclUpdater{
+				FilePath:  "no-changes/main.tf",
+				Block:     "module.vpc",
+				Attribute: "version",
+				Valuer:    value.StringValuer("5.1.0"),
+			},
+			expected: false,
+		},
+		{
+			name: "invalid file",
+			files: map[string]string{
+				"invalid/main.tf": `module "vpc" {
+  version = 
+`,
+			},
+			updater: &HclUpdater{
+				FilePath:  "invalid/main.tf",
+				Block:     "module.vpc",
+				Attribute: "version",
+				Valuer:    value.StringValuer("5.1.0"),
+			},
+			expectedErrorMsg: "failed to parse file invalid/main.tf: invalid/main.tf:2,13-3,1: Invalid expression; Expected the start of an expression, but found an invalid expression token.",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(context.Background(), "testdata")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+				for filename, expectedContent := range test.expectedFiles {
+					actualContent, err := os.ReadFile(filepath.Join("testdata", filename))
+					require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+					assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+				}
+				assert.Equal(t, test.expectedChanges, test.updater.Changes("testdata"))
+			}
+		})
+	}
+}
@@ -0,0 +1,411 @@
+// Package image provides an updater that updates the container images used in YAML files - such as Kubernetes manifests, Kustomize files or Helm values.
+package image
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	internalimage "github.com/dailymotion-oss/octopilot/internal/image"
+	internalyaml "github.com/dailymotion-oss/octopilot/internal/yaml"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/mikefarah/yq/v4/pkg/yqlib"
+	gologging "gopkg.in/op/go-logging.v1"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	gologging.SetLevel(gologging.CRITICAL, "yq-lib")
+}
+
+// ImageUpdater is an updater that updates the tag and/or digest of a container image in YAML files. It supports:
+//   - the `image` fields of the containers and init containers of the Kubernetes workloads (Deployments, StatefulSets, Jobs, CronJobs, ...)
+//   - the `images` entries of the Kustomize files
+//   - the common Helm values shapes, such as `image.repository` and `image.tag`
+type ImageUpdater struct {
+	FilePath string
+	Name     string
+	Indent   int
+	Valuer   value.Valuer
+
+	change.Recorder
+}
+
+// NewUpdater builds a new image updater from the given parameters and valuer
+func NewUpdater(params map[string]string, valuer value.Valuer) (*ImageUpdater, error) {
+	updater := &ImageUpdater{}
+
+	updater.FilePath = params["file"]
+	if len(updater.FilePath) == 0 {
+		return nil, errors.New("missing file parameter")
+	}
+
+	updater.Name = params["name"]
+	if len(updater.Name) == 0 {
+		return nil, errors.New("missing name parameter")
+	}
+	if name, tag, digest := internalimage.ParseReference(updater.Name); len(tag) > 0 || len(digest) > 0 {
+		return nil, fmt.Errorf("invalid name parameter %s: it must not contain a tag or a digest - you can use %s instead", updater.Name, name)
+	}
+
+	updater.Indent, _ = strconv.Atoi(params["indent"])
+	if updater.Indent <= 0 {
+		updater.Indent = 2
+	}
+
+	updater.Valuer = valuer
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *ImageUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	u.Reset(repoPath)
+
+	value, err := u.Valuer.Value(ctx, repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get value: %w", err)
+	}
+	newTag, newDigest := internalimage.ParseVersion(value)
+	if len(newTag) == 0 && len(newDigest) == 0 {
+		return false, fmt.Errorf("invalid value %q: expected a tag and/or a digest", value)
+	}
+
+	filePaths, err := glob.ExpandGlobPattern(repoPath, u.FilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to expand glob pattern %s: %w", u.FilePath, err)
+	}
+
+	var updated bool
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		}
+
+		fileData, err := os.ReadFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+		}
+
+		content, changes, err := u.updateFile(relFilePath, fileData, newTag, newDigest)
+		if err != nil {
+			return false, fmt.Errorf("failed to update file %s: %w", relFilePath, err)
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		if err = os.WriteFile(filePath, content, fileInfo.Mode()); err != nil {
+			return false, fmt.Errorf("failed to write updated content to file %s: %w", relFilePath, err)
+		}
+
+		u.Record(repoPath, changes...)
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *ImageUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Update image %s", u.Name)
+	body = fmt.Sprintf("Updating image `%s` in file(s) `%s`", u.Name, u.FilePath)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *ImageUpdater) String() string {
+	return fmt.Sprintf("Image[name=%s,file=%s,indent=%v]", u.Name, u.FilePath, u.Indent)
+}
+
+// updateFile updates all the documents of the given YAML file, and returns the new content of the file, with the changes made.
+// It uses the yq lib to read and write the documents, the same way the yaml updater does - so that the comments and the documents separators are kept.
+func (u *ImageUpdater) updateFile(relFilePath string, fileData []byte, newTag, newDigest string) ([]byte, []change.Change, error) {
+	const (
+		yamlColorise           = false
+		yamlPrintDocSeparators = true
+		yamlUnwrapScalar       = false
+	)
+	reader, leadingContent, err := internalyaml.ExtractLeadingContentForYQ(bytes.NewReader(fileData))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to extract leading content: %w", err)
+	}
+
+	var (
+		decoder       = yqlib.NewYamlDecoder()
+		buffer        = new(bytes.Buffer)
+		printer       = yqlib.NewPrinter(yqlib.NewYamlEncoder(u.Indent, yamlColorise, yamlPrintDocSeparators, yamlUnwrapScalar), yqlib.NewSinglePrinterWriter(buffer))
+		kustomization = isKustomizationFile(relFilePath)
+		changes       []change.Change
+	)
+	decoder.Init(reader)
+	for index := uint(0); ; index++ {
+		var node yaml.Node
+		err = decoder.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode document %d: %w", index, err)
+		}
+
+		candidate := &yqlib.CandidateNode{
+			Document:        index,
+			Filename:        relFilePath,
+			Node:            &node,
+			TrailingContent: node.FootComment,
+		}
+		// same as the yq lib: move the document comments into the candidate node, otherwise they are dropped
+		node.FootComment = ""
+		if index == 0 {
+			candidate.LeadingContent = leadingContent
+		}
+
+		documentUpdater := &documentUpdater{
+			name:          u.Name,
+			newTag:        newTag,
+			newDigest:     newDigest,
+			kustomization: kustomization || isKustomizationDocument(&node),
+		}
+		documentUpdater.walk(&node)
+		for _, c := range documentUpdater.changes {
+			c.File = filepath.ToSlash(relFilePath)
+			if !slices.Contains(changes, c) {
+				changes = append(changes, c)
+			}
+		}
+
+		if err = printer.PrintResults(candidate.AsList()); err != nil {
+			return nil, nil, fmt.Errorf("failed to encode document %d: %w", index, err)
+		}
+	}
+
+	return buffer.Bytes(), changes, nil
+}
+
+// documentUpdater updates the references to an image in a single YAML document.
+type documentUpdater struct {
+	name          string
+	newTag        string
+	newDigest     string
+	kustomization bool
+	changes       []change.Change
+}
+
+func (d *documentUpdater) walk(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			d.walk(child)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			switch {
+			case key.Value == "image" && value.Kind == yaml.ScalarNode:
+				// containers of the Kubernetes workloads, or Helm values such as `image: registry/app:tag`
+				d.updateReference(value)
+			case key.Value == "image" && value.Kind == yaml.MappingNode:
+				// Helm values such as `image: {repository: registry/app, tag: 1.0.0}`
+				d.updateHelmImage(value)
+			case key.Value == "images" && value.Kind == yaml.SequenceNode && d.kustomization:
+				for _, entry := range value.Content {
+					if entry.Kind == yaml.MappingNode {
+						d.updateKustomizeImage(entry)
+					}
+				}
+			}
+			d.walk(value)
+		}
+	}
+}
+
+// updateReference updates a full image reference, such as `registry/app:tag@sha256:...`
+func (d *documentUpdater) updateReference(node *yaml.Node) {
+	name, tag, digest := internalimage.ParseReference(node.Value)
+	if !internalimage.SameName(name, d.name) {
+		return
+	}
+
+	updatedTag, updatedDigest := d.updatedVersion(tag, digest)
+	if ref := internalimage.FormatReference(name, updatedTag, updatedDigest); ref != node.Value {
+		setValue(node, ref)
+	}
+	d.record(tag, digest, updatedTag, updatedDigest)
+}
+
+// updateHelmImage updates an image defined by its repository - and optionally its registry - in a map, with its tag and optional digest.
+func (d *documentUpdater) updateHelmImage(node *yaml.Node) {
+	var (
+		registry   = mapValue(node, "registry")
+		repository = mapValue(node, "repository")
+		tag        = mapValue(node, "tag")
+		digest     = mapValue(node, "digest")
+	)
+	if repository == nil || repository.Kind != yaml.ScalarNode {
+		return
+	}
+	name := repository.Value
+	if registry != nil && len(registry.Value) > 0 {
+		name = registry.Value + "/" + name
+	}
+	if !internalimage.SameName(name, d.name) {
+		return
+	}
+
+	var oldTag, oldDigest string
+	if tag != nil {
+		oldTag = tag.Value
+	}
+	if digest != nil {
+		oldDigest = digest.Value
+	} else {
+		// no dedicated digest field: the digest might be appended to the tag
+		oldTag, oldDigest, _ = strings.Cut(oldTag, "@")
+	}
+	updatedTag, updatedDigest := d.updatedVersion(oldTag, oldDigest)
+
+	switch {
+	case digest == nil && len(updatedDigest) > 0 && len(updatedTag) > 0:
+		// no dedicated digest field: the digest is appended to the tag
+		setMapValue(node, "tag", internalimage.FormatVersion(updatedTag, updatedDigest))
+	case digest == nil && len(updatedDigest) > 0:
+		// without a tag, the digest can't be written in the tag field - `repository:sha256:...` is not a valid reference -
+		// so it gets its own field, as in the common charts
+		if tag != nil {
+			setMapValue(node, "tag", updatedTag)
+		}
+		setMapValue(node, "digest", updatedDigest)
+	default:
+		setMapValue(node, "tag", updatedTag)
+		if digest != nil && digest.Value != updatedDigest {
+			setValue(digest, updatedDigest)
+		}
+	}
+	d.record(oldTag, oldDigest, updatedTag, updatedDigest)
+}
+
+// updateKustomizeImage updates an entry of the `images` list of a Kustomize file:
+// its `newTag` and `digest` fields, if its `newName` - or its `name` if there is no new name - is the image.
+func (d *documentUpdater) updateKustomizeImage(node *yaml.Node) {
+	name := mapValue(node, "newName")
+	if name == nil {
+		name = mapValue(node, "name")
+	}
+	if name == nil || !internalimage.SameName(name.Value, d.name) {
+		return
+	}
+
+	var oldTag, oldDigest string
+	if tag := mapValue(node, "newTag"); tag != nil {
+		oldTag = tag.Value
+	}
+	if digest := mapValue(node, "digest"); digest != nil {
+		oldDigest = digest.Value
+	}
+	updatedTag, updatedDigest := d.updatedVersion(oldTag, oldDigest)
+
+	if len(updatedTag) > 0 {
+		setMapValue(node, "newTag", updatedTag)
+	}
+	if len(updatedDigest) > 0 {
+		setMapValue(node, "digest", updatedDigest)
+	} else {
+		// kustomize uses the digest instead of the tag, so an existing digest would override the new tag
+		deleteMapValue(node, "digest")
+	}
+	d.record(oldTag, oldDigest, updatedTag, updatedDigest)
+}
+
+// updatedVersion returns the tag and digest the image should have: a new tag drops the existing digest, because it wouldn't match anymore.
+func (d *documentUpdater) updatedVersion(tag, digest string) (string, string) {
+	if len(d.newTag) > 0 {
+		tag, digest = d.newTag, ""
+	}
+	if len(d.newDigest) > 0 {
+		digest = d.newDigest
+	}
+	return tag, digest
+}
+
+func (d *documentUpdater) record(oldTag, oldDigest, newTag, newDigest string) {
+	oldVersion, newVersion := internalimage.FormatVersion(oldTag, oldDigest), internalimage.FormatVersion(newTag, newDigest)
+	if oldVersion != newVersion {
+		d.changes = append(d.changes, change.Change{Name: d.name, Old: oldVersion, New: newVersion})
+	}
+}
+
+func isKustomizationFile(filePath string) bool {
+	switch filepath.Base(filePath) {
+	case "kustomization.yaml", "kustomization.yml", "Kustomization":
+		return true
+	}
+	return false
+}
+
+func isKustomizationDocument(node *yaml.Node) bool {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	kind := mapValue(node, "kind")
+	return kind != nil && kind.Value == "Kustomization"
+}
+
+// mapValue returns the value for the given key, or nil if the node is not a map or doesn't have the key.
+func mapValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMapValue sets the value for the given key, and adds it at the end of the map if it doesn't exist yet.
+func setMapValue(node *yaml.Node, key, value string) {
+	if existing := mapValue(node, key); existing != nil {
+		if existing.Value != value {
+			setValue(existing, value)
+		}
+		return
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	)
+}
+
+func deleteMapValue(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// setValue sets the value of a scalar node, as a string - so that a tag such as 1.10 is quoted and not read as a number.
+func setValue(node *yaml.Node, value string) {
+	node.Kind = yaml.ScalarNode
+	node.Tag = "!!str"
+	node.Value = value
+}
@@ -0,0 +1,419 @@
+package image
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *ImageUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params",
+			params: map[string]string{
+				"file":   "k8s/**/*.yaml",
+				"name":   "registry.example.com/org/app",
+				"indent": "4",
+			},
+			expected: &ImageUpdater{
+				FilePath: "k8s/**/*.yaml",
+				Name:     "registry.example.com/org/app",
+				Indent:   4,
+			},
+		},
+		{
+			name: "invalid indent",
+			params: map[string]string{
+				"file":   "values.yaml",
+				"name":   "nginx",
+				"indent": "not-an-int",
+			},
+			expected: &ImageUpdater{
+				FilePath: "values.yaml",
+				Name:     "nginx",
+				Indent:   2,
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing file parameter",
+		},
+		{
+			name: "missing name",
+			params: map[string]string{
+				"file": "values.yaml",
+			},
+			expectedErrorMsg: "missing name parameter",
+		},
+		{
+			name: "name with digest",
+			params: map[string]string{
+				"file": "values.yaml",
+				"name": "localhost:5000/app@sha256:0123456789abcdef",
+			},
+			expectedErrorMsg: "invalid name parameter localhost:5000/app@sha256:0123456789abcdef: it must not contain a tag or a digest - you can use localhost:5000/app instead",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params, nil)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		files            map[string]string
+		updater          *ImageUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+		expectedChanges  []change.Change
+	}{
+		{
+			name: "update multi-documents manifests",
+			files: map[string]string{
+				"manifests/app.yaml": `# the app
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: registry.example.com/org/app:1.0.0 # same image
+      containers:
+        - name: app
+          image: "registry.example.com/org/app:1.0.0"
+        - name: sidecar
+          image: registry.example.com/org/sidecar:1.0.0
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: cleanup
+              image: registry.example.com/org/app:0.9.0@sha256:0123456789abcdef
+`,
+			},
+			updater: &ImageUpdater{
+				FilePath: "manifests/*.yaml",
+				Name:     "registry.example.com/org/app",
+				Indent:   2,
+				Valuer:   value.StringValuer("1.1.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"manifests/app.yaml": `# the app
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: registry.example.com/org/app:1.1.0 # same image
+      containers:
+        - name: app
+          image: "registry.example.com/org/app:1.1.0"
+        - name: sidecar
+          image: registry.example.com/org/sidecar:1.0.0
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: cleanup
+              image: registry.example.com/org/app:1.1.0
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "manifests/app.yaml", Name: "registry.example.com/org/app", Old: "1.0.0", New: "1.1.0"},
+				{File: "manifests/app.yaml", Name: "registry.example.com/org/app", Old: "0.9.0@sha256:0123456789abcdef", New: "1.1.0"},
+			},
+		},
+		{
+			name: "update kustomization images",
+			files: map[string]string{
+				"kustomize/kustomization.yaml": `resources:
+  - deployment.yaml
+images:
+  - name: app
+    newName: registry.example.com/org/app
+    newTag: 1.0.0
+    digest: sha256:0123456789abcdef
+  - name: nginx
+    newTag: "1.25"
+`,
+			},
+			updater: &ImageUpdater{
+				FilePath: "kustomize/kustomization.yaml",
+				Name:     "registry.example.com/org/app",
+				Indent:   2,
+				Valuer:   value.StringValuer("1.1.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"kustomize/kustomization.yaml": `resources:
+  - deployment.yaml
+images:
+  - name: app
+    newName: registry.example.com/org/app
+    newTag: 1.1.0
+  - name: nginx
+    newTag: "1.25"
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "kustomize/kustomization.yaml", Name: "registry.example.com/org/app", Old: "1.0.0@sha256:0123456789abcdef", New: "1.1.0"},
+			},
+		},
+		{
+			name: "update digest in kustomization with docker hub image",
+			files: map[string]string{
+				"kustomize-digest/kustomize.yaml": `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+images:
+  - name: docker.io/library/nginx
+    newTag: "1.25"
+`,
+			},
+			updater: &ImageUpdater{
+				FilePath: "kustomize-digest/kustomize.yaml",
+				Name:     "nginx",
+				Indent:   2,
+				Valuer:   value.StringValuer("sha256:fedcba9876543210"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"kustomize-digest/kustomize.yaml": `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+images:
+  - name: docker.io/library/nginx
+    newTag: "1.25"
+    digest: sha256:fedcba9876543210
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "kustomize-digest/kustomize.yaml", Name: "nginx", Old: "1.25", New: "1.25@sha256:fedcba9876543210"},
+			},
+		},
+		{
+			name: "update helm values",
+			files: map[string]string{
+				"helm/values.yaml": `replicaCount: 1
+image:
+  registry: registry.example.com
+  repository: org/app
+  # the tag of the image
+  tag: 1.0.0
+  pullPolicy: IfNotPresent
+worker:
+  image:
+    repository: registry.example.com/org/app
+    tag: ""
+    digest: sha256:0123456789abcdef
+images:
+  - name: registry.example.com/org/app
+    newTag: 1.0.0
+`,
+			},
+			updater: &ImageUpdater{
+				FilePath: "helm/values.yaml",
+				Name:     "registry.example.com/org/app",
+				Indent:   2,
+				Valuer:   value.StringValuer("1.10"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"helm/values.yaml": `replicaCount: 1
+image:
+  registry: registry.example.com
+  repository: org/app
+  # the tag of the image
+  tag: "1.10"
+  pullPolicy: IfNotPresent
+worker:
+  image:
+    repository: registry.example.com/org/app
+    tag: "1.10"
+    digest: ""
+images:
+  - name: registry.example.com/org/app
+    newTag: 1.0.0
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "helm/values.yaml", Name: "registry.example.com/org/app", Old: "1.0.0", New: "1.10"},
+				{File: "helm/values.yaml", Name: "registry.example.com/org/app", Old: "sha256:0123456789abcdef", New: "1.10"},
+			},
+		},
+		{
+			name: "update helm values with tag and digest",
+			files: map[string]string{
+				"helm-digest/values.yaml": `image:
+  repository: localhost:5000/app
+`,
+			},
+			updater: &ImageUpdater{
+				FilePath: "helm-digest/values.yaml",
+				Name:     "localhost:5000/app",
+				Indent:   2,
+				Valuer:   value.StringValuer("2.0@sha256:fedcba9876543210"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"helm-digest/values.yaml": `image:
+  repository: localhost:5000/app
+  tag: 2.0@sha256:fedcba9876543210
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "helm-digest/values.yaml", Name: "localhost:5000/app", New: "2.0@sha256:fedcba9876543210"},
+			},
+		},
+		{
+			name: "update helm values with digest only",
+			files: map[string]string{
+				"helm-digest-only/values.yaml": `image:
+  repository: localhost:5000/app
+worker:
+  image:
+    repository: localhost:5000/app
+    tag: 1.0@sha256:0123456789abcdef
+`,
+			},
+			updater: &ImageUpdater{
+				FilePath: "helm-digest-only/values.yaml",
+				Name:     "localhost:5000/app",
+				Indent:   2,
+				Valuer:   value.StringValuer("sha256:fedcba9876543210"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"helm-digest-only/values.yaml": `image:
+  repository: localhost:5000/app
+  digest: sha256:fedcba9876543210
+worker:
+  image:
+    repository: localhost:5000/app
+    tag: 1.0@sha256:fedcba9876543210
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "helm-digest-only/values.yaml", Name: "localhost:5000/app", New: "sha256:fedcba9876543210"},
+				{File: "helm-digest-only/values.yaml", Name: "localhost:5000/app", Old: "1.0@sha256:0123456789abcdef", New: "1.0@sha256:fedcba9876543210"},
+			},
+		},
+		{
+			name: "no changes",
+			files: map[string]string{
+				"no-changes/first.yaml": `containers:
+    - image:   registry.example.com/org/app:1.0.0
+`,
+				"no-changes/second.yaml": `containers:
+    - image: registry.example.com/org/other:0.1.0
+`,
+			},
+			updater: &ImageUpdater{
+				FilePath: "no-changes/*.yaml",
+				Name:     "registry.example.com/org/app",
+				Indent:   2,
+				Valuer:   value.StringValuer("1.0.0"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-changes/first.yaml": `containers:
+    - image:   registry.example.com/org/app:1.0.0
+`,
+				"no-changes/second.yaml": `containers:
+    - image: registry.example.com/org/other:0.1.0
+`,
+			},
+		},
+		{
+			name: "invalid yaml",
+			files: map[string]string{
+				"invalid/values.yaml": "image: [unclosed\n",
+			},
+			updater: &ImageUpdater{
+				FilePath: "invalid/values.yaml",
+				Name:     "registry.example.com/org/app",
+				Indent:   2,
+				Valuer:   value.StringValuer("1.0.0"),
+			},
+			expectedErrorMsg: "failed to update file invalid/values.yaml: failed to decode document 0: yaml: line 1: did not find expected ',' or ']'",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(context.Background(), "testdata")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+				for filename, expectedContent := range test.expectedFiles {
+					actualContent, err := os.ReadFile(filepath.Join("testdata", filename))
+					require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+					assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+				}
+				assert.Equal(t, test.expectedChanges, test.updater.Changes("testdata"))
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Package json provides an updater that updates JSON files, while preserving their formatting.
+package json
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	"github.com/dailymotion-oss/octopilot/internal/json"
+	"github.com/dailymotion-oss/octopilot/update/value"
+)
+
+// JsonUpdater is an updater that updates JSON files, while preserving their formatting.
+type JsonUpdater struct {
+	FilePath   string
+	Path       string
+	Selector   json.Selector
+	Type       *json.Kind
+	AutoCreate bool
+	Valuer     value.Valuer
+}
+
+// NewUpdater builds a new JSON updater from the given parameters and valuer
+func NewUpdater(params map[string]string, valuer value.Valuer) (*JsonUpdater, error) {
+	updater := &JsonUpdater{}
+
+	updater.FilePath = params["file"]
+	if len(updater.FilePath) == 0 {
+		return nil, errors.New("missing file parameter")
+	}
+
+	updater.Path = params["path"]
+	if len(updater.Path) == 0 {
+		return nil, errors.New("missing path parameter")
+	}
+
+	var err error
+	updater.Selector, err = json.ParseSelector(updater.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if typeName := params["type"]; len(typeName) > 0 {
+		kind, err := json.ParseKind(typeName)
+		if err != nil {
+			return nil, err
+		}
+		updater.Type = &kind
+	}
+
+	updater.AutoCreate, _ = strconv.ParseBool(params["create"])
+
+	updater.Valuer = valuer
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *JsonUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	value, err := u.Valuer.Value(ctx, repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get value: %w", err)
+	}
+
+	filePaths, err := glob.ExpandGlobPattern(repoPath, u.FilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to expand glob pattern %s: %w", u.FilePath, err)
+	}
+
+	var updated bool
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+		}
+
+		doc, err := json.Parse(content)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse JSON file %s: %w", relFilePath, err)
+		}
+
+		updatedContent, changed, err := doc.Set(u.Selector, value, u.Type, u.AutoCreate)
+		if err != nil {
+			return false, fmt.Errorf("failed to update JSON file %s: %w", relFilePath, err)
+		}
+		if !changed {
+			continue
+		}
+
+		if err = os.WriteFile(filePath, updatedContent, fileInfo.Mode()); err != nil {
+			return false, fmt.Errorf("failed to write updated content to file %s: %w", relFilePath, err)
+		}
+
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *JsonUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Update %s", u.FilePath)
+	body = fmt.Sprintf("Updating path `%s` in file(s) `%s`", u.Path, u.FilePath)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *JsonUpdater) String() string {
+	var typeName string
+	if u.Type != nil {
+		typeName = u.Type.String()
+	}
+	return fmt.Sprintf("JSON[path=%s,file=%s,type=%s,create=%v]", u.Path, u.FilePath, typeName, u.AutoCreate)
+}
@@ -0,0 +1,293 @@
+package json
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/internal/json"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	number := json.Number
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *JsonUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params with single file",
+			params: map[string]string{
+				"file":   "package.json",
+				"path":   "$.version",
+				"create": "true",
+			},
+			expected: &JsonUpdater{
+				FilePath:   "package.json",
+				Path:       "$.version",
+				Selector:   mustParseSelector(t, "$.version"),
+				AutoCreate: true,
+			},
+		},
+		{
+			name: "valid params with multiple files using a glob pattern and a type",
+			params: map[string]string{
+				"file": "**/renovate.json",
+				"path": "packageRules[?(@.groupName=='go')].minimumReleaseAge",
+				"type": "number",
+			},
+			expected: &JsonUpdater{
+				FilePath: "**/renovate.json",
+				Path:     "packageRules[?(@.groupName=='go')].minimumReleaseAge",
+				Selector: mustParseSelector(t, "packageRules[?(@.groupName=='go')].minimumReleaseAge"),
+				Type:     &number,
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing file parameter",
+		},
+		{
+			name: "missing mandatory path param",
+			params: map[string]string{
+				"file": "package.json",
+			},
+			expectedErrorMsg: "missing path parameter",
+		},
+		{
+			name: "invalid path",
+			params: map[string]string{
+				"file": "package.json",
+				"path": "files[first]",
+			},
+			expectedErrorMsg: `invalid selector "files[first]": invalid array index "first"`,
+		},
+		{
+			name: "invalid type",
+			params: map[string]string{
+				"file": "package.json",
+				"path": "version",
+				"type": "integer",
+			},
+			expectedErrorMsg: `invalid type "integer": expected one of string, number, bool or null`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params, nil)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	boolean := json.Bool
+	tests := []struct {
+		name             string
+		files            map[string]string
+		updater          *JsonUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+	}{
+		{
+			name: "update simple value in a single file",
+			files: map[string]string{
+				"package.json": `{
+    "name": "my-app",
+    "version": "1.0.0",
+    "dependencies": {"lodash": "4.17.21"}
+}
+`,
+			},
+			updater: &JsonUpdater{
+				FilePath: "package.json",
+				Path:     "version",
+				Selector: mustParseSelector(t, "version"),
+				Valuer:   value.StringValuer("1.1.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"package.json": `{
+    "name": "my-app",
+    "version": "1.1.0",
+    "dependencies": {"lodash": "4.17.21"}
+}
+`,
+			},
+		},
+		{
+			name: "update values matching a filter",
+			files: map[string]string{
+				"renovate.json": `{
+  "packageRules": [
+    { "groupName": "go", "enabled": false },
+    { "groupName": "npm", "enabled": false }
+  ]
+}`,
+			},
+			updater: &JsonUpdater{
+				FilePath: "renovate.json",
+				Path:     "$.packageRules[?(@.groupName == 'go')].enabled",
+				Selector: mustParseSelector(t, "$.packageRules[?(@.groupName == 'go')].enabled"),
+				Valuer:   value.StringValuer("true"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"renovate.json": `{
+  "packageRules": [
+    { "groupName": "go", "enabled": true },
+    { "groupName": "npm", "enabled": false }
+  ]
+}`,
+			},
+		},
+		{
+			name: "update multiple files with a typed value",
+			files: map[string]string{
+				"multiple-files-1.json": `{"enabled": "false"}`,
+				"multiple-files-2.json": `{"enabled": false}`,
+			},
+			updater: &JsonUpdater{
+				FilePath: "multiple-files-*.json",
+				Path:     "enabled",
+				Selector: mustParseSelector(t, "enabled"),
+				Type:     &boolean,
+				Valuer:   value.StringValuer("true"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"multiple-files-1.json": `{"enabled": true}`,
+				"multiple-files-2.json": `{"enabled": true}`,
+			},
+		},
+		{
+			name: "create missing key/value",
+			files: map[string]string{
+				"missing-key.json": `{
+  "name": "my-app"
+}
+`,
+			},
+			updater: &JsonUpdater{
+				FilePath:   "missing-key.json",
+				Path:       "version",
+				Selector:   mustParseSelector(t, "version"),
+				AutoCreate: true,
+				Valuer:     value.StringValuer("1.0.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"missing-key.json": `{
+  "name": "my-app",
+  "version": "1.0.0"
+}
+`,
+			},
+		},
+		{
+			name: "no changes if new key but no auto-create",
+			files: map[string]string{
+				"no-changes-without-auto-create.json": `{"name": "my-app"}`,
+			},
+			updater: &JsonUpdater{
+				FilePath: "no-changes-without-auto-create.json",
+				Path:     "version",
+				Selector: mustParseSelector(t, "version"),
+				Valuer:   value.StringValuer("1.0.0"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-changes-without-auto-create.json": `{"name": "my-app"}`,
+			},
+		},
+		{
+			name: "no changes",
+			files: map[string]string{
+				"no-changes.json": `{"version": "1.0.0"}`,
+			},
+			updater: &JsonUpdater{
+				FilePath: "no-changes.json",
+				Path:     "version",
+				Selector: mustParseSelector(t, "version"),
+				Valuer:   value.StringValuer("1.0.0"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-changes.json": `{"version": "1.0.0"}`,
+			},
+		},
+		{
+			name: "invalid JSON file",
+			files: map[string]string{
+				"invalid.json": `{
+  "version": "1.0.0",
+}`,
+			},
+			updater: &JsonUpdater{
+				FilePath: "invalid.json",
+				Path:     "version",
+				Selector: mustParseSelector(t, "version"),
+				Valuer:   value.StringValuer("1.1.0"),
+			},
+			expectedErrorMsg: "failed to parse JSON file invalid.json: line 3, column 1: expected a quoted key",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(context.Background(), "testdata")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+				actualFilePaths, err := filepath.Glob(filepath.Join("testdata", test.updater.FilePath))
+				require.NoError(t, err, "can't expand glob pattern for actual testdata file")
+				for _, actualFilePath := range actualFilePaths {
+					actualRelFilePath, err := filepath.Rel("testdata", actualFilePath)
+					require.NoErrorf(t, err, "can't get relative path for actual testdata file %s", actualFilePath)
+					actualFileContent, err := os.ReadFile(actualFilePath)
+					require.NoErrorf(t, err, "can't read actual testdata file %s", actualFilePath)
+					expectedFileContent := test.expectedFiles[actualRelFilePath]
+					assert.Equalf(t, expectedFileContent, string(actualFileContent), "testdata file %s doesn't match", actualFilePath)
+				}
+			}
+		})
+	}
+}
+
+func mustParseSelector(t *testing.T, str string) json.Selector {
+	selector, err := json.ParseSelector(str)
+	require.NoError(t, err)
+	return selector
+}
@@ -0,0 +1,410 @@
+// Package keyvalue provides an updater that updates the values defined in "key=value" files - such as dotenv, INI or shell files.
+package keyvalue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+)
+
+// Format is the format of a key/value file
+type Format string
+
+// Formats
+const (
+	// FormatEnv is the format of the dotenv files: KEY=value, with optional "export" prefixes and quotes
+	FormatEnv Format = "env"
+	// FormatIni is the format of the INI files: key = value, grouped in [sections]
+	FormatIni Format = "ini"
+	// FormatShell is the format of the shell variables assignments: KEY=value, without spaces around the "=" sign
+	FormatShell Format = "shell"
+)
+
+var (
+	// [export] KEY = value
+	envLineRegexp = regexp.MustCompile(`^(\s*(?:export\s+)?)([A-Za-z_][A-Za-z0-9_.-]*)(\s*=\s*)(.*)$`)
+	// [export|readonly] KEY=value
+	shellLineRegexp = regexp.MustCompile(`^(\s*(?:(?:export|readonly|declare(?:\s+-[a-zA-Z]+)*)\s+)?)([A-Za-z_][A-Za-z0-9_]*)(=)(.*)$`)
+	// key = value or key: value
+	iniLineRegexp = regexp.MustCompile(`^(\s*)([^\s=:;#\[][^=:]*?)(\s*[=:]\s*)(.*)$`)
+	// [section]
+	iniSectionRegexp = regexp.MustCompile(`^\s*\[([^\]]*)\]`)
+)
+
+// KeyValueUpdater is an updater that updates the value of a key in dotenv, INI or shell files - while preserving the other lines.
+type KeyValueUpdater struct {
+	FilePath   string
+	Key        string
+	Section    string
+	Format     Format
+	AutoCreate bool
+	Valuer     value.Valuer
+
+	change.Recorder
+}
+
+// NewUpdater builds a new key/value updater from the given parameters and valuer
+func NewUpdater(params map[string]string, valuer value.Valuer) (*KeyValueUpdater, error) {
+	updater := &KeyValueUpdater{}
+
+	updater.FilePath = params["file"]
+	if len(updater.FilePath) == 0 {
+		return nil, errors.New("missing file parameter")
+	}
+
+	updater.Key = params["key"]
+	if len(updater.Key) == 0 {
+		return nil, errors.New("missing key parameter")
+	}
+
+	updater.Format = Format(params["format"])
+	switch updater.Format {
+	case "":
+		updater.Format = FormatEnv
+	case FormatEnv, FormatIni, FormatShell:
+	default:
+		return nil, fmt.Errorf("invalid format parameter %s: must be one of %s, %s or %s", updater.Format, FormatEnv, FormatIni, FormatShell)
+	}
+
+	updater.Section = params["section"]
+	if len(updater.Section) > 0 && updater.Format != FormatIni {
+		return nil, fmt.Errorf("invalid section parameter %s: sections are only supported by the %s format", updater.Section, FormatIni)
+	}
+
+	updater.AutoCreate, _ = strconv.ParseBool(params["create"])
+
+	updater.Valuer = valuer
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *KeyValueUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	u.Reset(repoPath)
+
+	value, err := u.Valuer.Value(ctx, repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get value: %w", err)
+	}
+
+	filePaths, err := glob.ExpandGlobPattern(repoPath, u.FilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to expand glob pattern %s: %w", u.FilePath, err)
+	}
+
+	var updated bool
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+		}
+
+		newContent, changes := u.updateContent(string(content), value)
+		if newContent == string(content) {
+			continue
+		}
+		for i := range changes {
+			changes[i].File = filepath.ToSlash(relFilePath)
+		}
+
+		if err = os.WriteFile(filePath, []byte(newContent), fileInfo.Mode()); err != nil {
+			return false, fmt.Errorf("failed to write updated content to file %s: %w", relFilePath, err)
+		}
+
+		u.Record(repoPath, changes...)
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *KeyValueUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Update %s", u.name())
+	body = fmt.Sprintf("Updating key `%s` in file(s) `%s`", u.name(), u.FilePath)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *KeyValueUpdater) String() string {
+	return fmt.Sprintf("KeyValue[key=%s,section=%s,file=%s,format=%s,create=%v]", u.Key, u.Section, u.FilePath, u.Format, u.AutoCreate)
+}
+
+// name returns the name of the key, prefixed by its section if any
+func (u *KeyValueUpdater) name() string {
+	if len(u.Section) > 0 {
+		return u.Section + "." + u.Key
+	}
+	return u.Key
+}
+
+// assignment is a line defining a value for a key
+type assignment struct {
+	prefix    string
+	key       string
+	separator string
+	quote     string
+	raw       string
+	suffix    string
+}
+
+// updateContent updates the value of the key on all the lines defining it - line by line, so that the other lines are kept as-is.
+// If the key is not defined and the auto-create mode is enabled, it is appended at the end of the file - or of its section.
+func (u *KeyValueUpdater) updateContent(content, value string) (string, []change.Change) {
+	var (
+		lines       = strings.SplitAfter(content, "\n")
+		eol         = lineEnding(content)
+		changes     []change.Change
+		found       bool
+		section     string
+		sectionEnd  = -1 // the index of the line after which a new key should be added in the section
+		separator   string
+		exportFirst bool
+	)
+	if u.Format == FormatIni {
+		sectionEnd = 0
+		if len(u.Section) > 0 {
+			sectionEnd = -1
+		}
+	}
+	for i, line := range lines {
+		text := strings.TrimRight(line, "\r\n")
+		if u.Format == FormatIni {
+			if matches := iniSectionRegexp.FindStringSubmatch(text); len(matches) > 0 {
+				if len(section) == 0 && len(u.Section) == 0 && sectionEnd == 0 {
+					// no keys in the global section: a new key should be added before the first section
+					sectionEnd = i
+				}
+				section = strings.TrimSpace(matches[1])
+				if section == u.Section {
+					sectionEnd = i + 1
+				}
+				continue
+			}
+		}
+		a, ok := u.parseLine(text)
+		if !ok {
+			continue
+		}
+		if len(separator) == 0 {
+			separator = a.separator
+			exportFirst = strings.HasPrefix(strings.TrimSpace(a.prefix), "export")
+		}
+		if u.Format == FormatIni && section != u.Section {
+			continue
+		}
+		if u.Format == FormatIni {
+			sectionEnd = i + 1
+		}
+		if a.key != u.Key {
+			continue
+		}
+		found = true
+
+		oldValue := u.decode(a.quote, a.raw)
+		if oldValue == value {
+			continue
+		}
+		lines[i] = a.prefix + a.key + a.separator + u.encode(value, a.quote) + a.suffix + line[len(text):]
+		if c := (change.Change{Name: u.name(), Old: oldValue, New: value}); !slices.Contains(changes, c) {
+			changes = append(changes, c)
+		}
+	}
+
+	if !found && u.AutoCreate {
+		lines = u.appendKey(lines, value, eol, sectionEnd, separator, exportFirst)
+		changes = append(changes, change.Change{Name: u.name(), New: value})
+	}
+	return strings.Join(lines, ""), changes
+}
+
+// appendKey adds the key at the end of its section - or at the end of the file.
+func (u *KeyValueUpdater) appendKey(lines []string, value, eol string, sectionEnd int, separator string, export bool) []string {
+	if len(separator) == 0 {
+		separator = "="
+		if u.Format == FormatIni {
+			separator = " = "
+		}
+	}
+	var prefix string
+	if export && u.Format != FormatIni {
+		prefix = "export "
+	}
+	newLine := prefix + u.Key + separator + u.encode(value, "") + eol
+
+	if sectionEnd < 0 {
+		// a new section at the end of the file
+		if u.Format == FormatIni && len(u.Section) > 0 {
+			newLine = "[" + u.Section + "]" + eol + newLine
+			if last := strings.Join(lines, ""); len(strings.TrimSpace(last)) > 0 {
+				newLine = eol + newLine
+			}
+		}
+		sectionEnd = len(lines)
+	}
+	if sectionEnd == len(lines) && lines[sectionEnd-1] == "" {
+		// the content ends with a line break
+		sectionEnd--
+	}
+
+	if sectionEnd > 0 && sectionEnd <= len(lines) && !strings.HasSuffix(lines[sectionEnd-1], "\n") {
+		lines[sectionEnd-1] += eol
+	}
+	result := append([]string{}, lines[:sectionEnd]...)
+	result = append(result, newLine)
+	return append(result, lines[sectionEnd:]...)
+}
+
+// parseLine returns the assignment defined in the given line, if any
+func (u *KeyValueUpdater) parseLine(line string) (assignment, bool) {
+	var matches []string
+	switch u.Format {
+	case FormatEnv:
+		matches = envLineRegexp.FindStringSubmatch(line)
+	case FormatShell:
+		matches = shellLineRegexp.FindStringSubmatch(line)
+	case FormatIni:
+		matches = iniLineRegexp.FindStringSubmatch(line)
+	}
+	if len(matches) == 0 {
+		return assignment{}, false
+	}
+
+	a := assignment{prefix: matches[1], key: matches[2], separator: matches[3]}
+	rest := matches[4]
+	switch {
+	case strings.HasPrefix(rest, `"`), strings.HasPrefix(rest, `'`):
+		a.quote = rest[:1]
+		end := closingQuote(rest, a.quote)
+		if end < 0 {
+			// multi-line values are not supported
+			return assignment{}, false
+		}
+		a.raw = rest[1:end]
+		a.suffix = rest[end+1:]
+	default:
+		a.raw = rest
+		if i := inlineCommentIndex(rest, u.Format); i >= 0 {
+			a.raw, a.suffix = rest[:i], rest[i:]
+		}
+		trimmed := strings.TrimRight(a.raw, " \t")
+		a.raw, a.suffix = trimmed, a.raw[len(trimmed):]+a.suffix
+	}
+	return a, true
+}
+
+// closingQuote returns the index of the quote closing the quoted value - or -1 if it's not closed on the same line
+func closingQuote(str, quote string) int {
+	for i := 1; i < len(str); i++ {
+		switch {
+		case str[i] == '\\' && quote == `"`:
+			i++
+		case str[i:i+1] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// inlineCommentIndex returns the index of the whitespace before an inline comment in an unquoted value - or -1 if there is none
+func inlineCommentIndex(str string, format Format) int {
+	markers := "#"
+	if format == FormatIni {
+		markers = "#;"
+	}
+	for i := 1; i < len(str); i++ {
+		if strings.ContainsRune(markers, rune(str[i])) && (str[i-1] == ' ' || str[i-1] == '\t') {
+			return i - 1
+		}
+	}
+	return -1
+}
+
+// decode returns the actual value of a raw - possibly quoted - value
+func (u *KeyValueUpdater) decode(quote, raw string) string {
+	if quote != `"` {
+		return raw
+	}
+	var sb strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' || i+1 >= len(raw) {
+			sb.WriteByte(raw[i])
+			continue
+		}
+		i++
+		switch c := raw[i]; {
+		case c == 'n' && u.Format == FormatEnv:
+			sb.WriteByte('\n')
+		case c == '"' || c == '\\' || c == '$' || c == '`':
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// encode returns the raw representation of the given value: using the same quotes as the old value, or double quotes if it's required.
+func (u *KeyValueUpdater) encode(value, quote string) string {
+	if quote == "'" && !strings.Contains(value, "'") {
+		return "'" + value + "'"
+	}
+	if quote == "" && !u.needsQuotes(value) {
+		return value
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, c := range value {
+		switch {
+		case c == '"' || c == '\\':
+			sb.WriteRune('\\')
+			sb.WriteRune(c)
+		case (c == '$' || c == '`') && u.Format != FormatIni:
+			sb.WriteRune('\\')
+			sb.WriteRune(c)
+		case c == '\n' && u.Format == FormatEnv:
+			sb.WriteString(`\n`)
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// needsQuotes returns true if the given value can't be written without quotes
+func (u *KeyValueUpdater) needsQuotes(value string) bool {
+	if u.Format == FormatIni {
+		return value != strings.TrimSpace(value) || strings.ContainsAny(value, "\n;#\"'")
+	}
+	return strings.ContainsAny(value, " \t\n#\"'$`\\;&|<>(){}*?!")
+}
+
+func lineEnding(content string) string {
+	if strings.Contains(content, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
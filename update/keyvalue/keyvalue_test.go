@@ -0,0 +1,389 @@
+package keyvalue
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *KeyValueUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params with default format",
+			params: map[string]string{
+				"file": ".env",
+				"key":  "FOO_VERSION",
+			},
+			expected: &KeyValueUpdater{
+				FilePath: ".env",
+				Key:      "FOO_VERSION",
+				Format:   FormatEnv,
+			},
+		},
+		{
+			name: "valid params with ini format and section",
+			params: map[string]string{
+				"file":    "**/setup.ini",
+				"key":     "version",
+				"section": "metadata",
+				"format":  "ini",
+				"create":  "true",
+			},
+			expected: &KeyValueUpdater{
+				FilePath:   "**/setup.ini",
+				Key:        "version",
+				Section:    "metadata",
+				Format:     FormatIni,
+				AutoCreate: true,
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing file parameter",
+		},
+		{
+			name: "missing mandatory key param",
+			params: map[string]string{
+				"file": ".env",
+			},
+			expectedErrorMsg: "missing key parameter",
+		},
+		{
+			name: "invalid format",
+			params: map[string]string{
+				"file":   ".env",
+				"key":    "FOO_VERSION",
+				"format": "yaml",
+			},
+			expectedErrorMsg: "invalid format parameter yaml: must be one of env, ini or shell",
+		},
+		{
+			name: "section without ini format",
+			params: map[string]string{
+				"file":    "versions.sh",
+				"key":     "FOO_VERSION",
+				"section": "versions",
+				"format":  "shell",
+			},
+			expectedErrorMsg: "invalid section parameter versions: sections are only supported by the ini format",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params, nil)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		files            map[string]string
+		updater          *KeyValueUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+		expectedChanges  []change.Change
+	}{
+		{
+			name: "update dotenv file and keep quotes, prefixes and comments",
+			files: map[string]string{
+				"env/.env": `# the versions
+FOO_VERSION=1.2.3 # pinned
+export BAR_VERSION="1.0.0"
+  FOO_VERSION = '1.2.3'
+#FOO_VERSION=1.0.0
+FOO_VERSION_SUFFIX=-rc
+`,
+			},
+			updater: &KeyValueUpdater{
+				FilePath: "env/.env",
+				Key:      "FOO_VERSION",
+				Format:   FormatEnv,
+				Valuer:   value.StringValuer("1.3.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"env/.env": `# the versions
+FOO_VERSION=1.3.0 # pinned
+export BAR_VERSION="1.0.0"
+  FOO_VERSION = '1.3.0'
+#FOO_VERSION=1.0.0
+FOO_VERSION_SUFFIX=-rc
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "env/.env", Name: "FOO_VERSION", Old: "1.2.3", New: "1.3.0"},
+			},
+		},
+		{
+			name: "update exported dotenv value with special characters",
+			files: map[string]string{
+				"env-special/.env": "export BAR_VERSION=\"1.0.0\"\r\nDESCRIPTION=none\r\n",
+			},
+			updater: &KeyValueUpdater{
+				FilePath: "env-special/.env",
+				Key:      "DESCRIPTION",
+				Format:   FormatEnv,
+				Valuer:   value.StringValuer(`the "new" $VERSION`),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"env-special/.env": "export BAR_VERSION=\"1.0.0\"\r\nDESCRIPTION=\"the \\\"new\\\" \\$VERSION\"\r\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "env-special/.env", Name: "DESCRIPTION", Old: "none", New: `the "new" $VERSION`},
+			},
+		},
+		{
+			name: "update shell file",
+			files: map[string]string{
+				"shell/versions.sh": `#!/usr/bin/env bash
+readonly FOO_VERSION="v1.2.3"
+BAR_VERSION=v1.0.0
+echo "FOO_VERSION=$FOO_VERSION"
+FOO_VERSION = v1.0.0
+`,
+			},
+			updater: &KeyValueUpdater{
+				FilePath: "shell/versions.sh",
+				Key:      "FOO_VERSION",
+				Format:   FormatShell,
+				Valuer:   value.StringValuer("v1.3.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"shell/versions.sh": `#!/usr/bin/env bash
+readonly FOO_VERSION="v1.3.0"
+BAR_VERSION=v1.0.0
+echo "FOO_VERSION=$FOO_VERSION"
+FOO_VERSION = v1.0.0
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "shell/versions.sh", Name: "FOO_VERSION", Old: "v1.2.3", New: "v1.3.0"},
+			},
+		},
+		{
+			name: "update ini file in section",
+			files: map[string]string{
+				"ini/setup.cfg": `; the metadata
+version = 0.1.0
+[metadata]
+name = my-lib
+version = 1.0.0 ; the current version
+
+[options]
+version: 2.0.0
+`,
+			},
+			updater: &KeyValueUpdater{
+				FilePath: "ini/setup.cfg",
+				Key:      "version",
+				Section:  "metadata",
+				Format:   FormatIni,
+				Valuer:   value.StringValuer("1.1.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"ini/setup.cfg": `; the metadata
+version = 0.1.0
+[metadata]
+name = my-lib
+version = 1.1.0 ; the current version
+
+[options]
+version: 2.0.0
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "ini/setup.cfg", Name: "metadata.version", Old: "1.0.0", New: "1.1.0"},
+			},
+		},
+		{
+			name: "update ini file in global section",
+			files: map[string]string{
+				"ini-global/app.ini": `version=0.1.0
+[metadata]
+version = 1.0.0
+`,
+			},
+			updater: &KeyValueUpdater{
+				FilePath: "ini-global/app.ini",
+				Key:      "version",
+				Format:   FormatIni,
+				Valuer:   value.StringValuer("0.2.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"ini-global/app.ini": `version=0.2.0
+[metadata]
+version = 1.0.0
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "ini-global/app.ini", Name: "version", Old: "0.1.0", New: "0.2.0"},
+			},
+		},
+		{
+			name: "create missing key in existing ini section",
+			files: map[string]string{
+				"ini-create/app.ini": `[metadata]
+name=my-lib
+
+[options]
+zip_safe=false
+`,
+			},
+			updater: &KeyValueUpdater{
+				FilePath:   "ini-create/app.ini",
+				Key:        "version",
+				Section:    "metadata",
+				Format:     FormatIni,
+				AutoCreate: true,
+				Valuer:     value.StringValuer("1.0.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"ini-create/app.ini": `[metadata]
+name=my-lib
+version=1.0.0
+
+[options]
+zip_safe=false
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "ini-create/app.ini", Name: "metadata.version", New: "1.0.0"},
+			},
+		},
+		{
+			name: "create missing key in new ini section",
+			files: map[string]string{
+				"ini-create-section/app.ini": "[metadata]\nname = my-lib",
+			},
+			updater: &KeyValueUpdater{
+				FilePath:   "ini-create-section/app.ini",
+				Key:        "version",
+				Section:    "tool",
+				Format:     FormatIni,
+				AutoCreate: true,
+				Valuer:     value.StringValuer("1.0.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"ini-create-section/app.ini": "[metadata]\nname = my-lib\n\n[tool]\nversion = 1.0.0\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "ini-create-section/app.ini", Name: "tool.version", New: "1.0.0"},
+			},
+		},
+		{
+			name: "create missing key in dotenv file with export prefix",
+			files: map[string]string{
+				"env-create/.env": "export FOO_VERSION=1.0.0\n",
+			},
+			updater: &KeyValueUpdater{
+				FilePath:   "env-create/.env",
+				Key:        "BAR_VERSION",
+				Format:     FormatEnv,
+				AutoCreate: true,
+				Valuer:     value.StringValuer("2.0.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"env-create/.env": "export FOO_VERSION=1.0.0\nexport BAR_VERSION=2.0.0\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "env-create/.env", Name: "BAR_VERSION", New: "2.0.0"},
+			},
+		},
+		{
+			name: "missing key without create",
+			files: map[string]string{
+				"no-create/.env": "FOO_VERSION=1.0.0\n",
+			},
+			updater: &KeyValueUpdater{
+				FilePath: "no-create/.env",
+				Key:      "BAR_VERSION",
+				Format:   FormatEnv,
+				Valuer:   value.StringValuer("2.0.0"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-create/.env": "FOO_VERSION=1.0.0\n",
+			},
+		},
+		{
+			name: "no changes",
+			files: map[string]string{
+				"no-changes/.env": "FOO_VERSION=\"1.0.0\"\n",
+			},
+			updater: &KeyValueUpdater{
+				FilePath:   "no-changes/.env",
+				Key:        "FOO_VERSION",
+				Format:     FormatEnv,
+				AutoCreate: true,
+				Valuer:     value.StringValuer("1.0.0"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-changes/.env": "FOO_VERSION=\"1.0.0\"\n",
+			},
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(context.Background(), "testdata")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+				for filename, expectedContent := range test.expectedFiles {
+					actualContent, err := os.ReadFile(filepath.Join("testdata", filename))
+					require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+					assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+				}
+				assert.Equal(t, test.expectedChanges, test.updater.Changes("testdata"))
+			}
+		})
+	}
+}
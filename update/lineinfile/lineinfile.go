@@ -0,0 +1,306 @@
+// Package lineinfile provides an updater that ensures a line is present in - or absent from - text files.
+package lineinfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	"github.com/dailymotion-oss/octopilot/update/change"
+)
+
+// State is the expected state of the line.
+type State string
+
+// The supported states
+const (
+	// StatePresent ensures the line is present in the files
+	StatePresent State = "present"
+	// StateAbsent ensures the line is absent from the files
+	StateAbsent State = "absent"
+)
+
+// The special values of the after and before parameters
+const (
+	// EOF inserts the line at the end of the file
+	EOF = "EOF"
+	// BOF inserts the line at the beginning of the file
+	BOF = "BOF"
+)
+
+// LineInFileUpdater is an updater that ensures a line is present in - or absent from - text files,
+// with the same semantics as the lineinfile module of Ansible.
+// It is idempotent: running it again on an updated file doesn't change anything.
+type LineInFileUpdater struct {
+	FilePath string
+	Line     string
+	// Pattern is the regexp matching the line to replace - or to remove
+	Pattern string
+	Regexp  *regexp.Regexp
+	// After and Before are the regexps matching the line after - or before - which the new line is inserted, or EOF / BOF
+	After        string
+	AfterRegexp  *regexp.Regexp
+	Before       string
+	BeforeRegexp *regexp.Regexp
+	State        State
+	AutoCreate   bool
+
+	change.Recorder
+}
+
+// NewUpdater builds a new lineinfile updater from the given parameters
+func NewUpdater(params map[string]string) (*LineInFileUpdater, error) {
+	updater := &LineInFileUpdater{}
+
+	updater.FilePath = params["file"]
+	if len(updater.FilePath) == 0 {
+		return nil, errors.New("missing file parameter")
+	}
+
+	updater.State = State(params["state"])
+	switch updater.State {
+	case "":
+		updater.State = StatePresent
+	case StatePresent, StateAbsent:
+	default:
+		return nil, fmt.Errorf("invalid state parameter %s: must be one of %s or %s", updater.State, StatePresent, StateAbsent)
+	}
+
+	var err error
+	updater.Line = params["line"]
+	updater.Pattern = params["regexp"]
+	if len(updater.Pattern) > 0 {
+		updater.Regexp, err = regexp.Compile(updater.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp parameter %s: %w", updater.Pattern, err)
+		}
+	}
+	switch {
+	case updater.State == StatePresent && len(updater.Line) == 0:
+		return nil, errors.New("missing line parameter")
+	case updater.State == StateAbsent && len(updater.Line) == 0 && updater.Regexp == nil:
+		return nil, errors.New("missing line or regexp parameter")
+	}
+
+	updater.After = params["after"]
+	updater.Before = params["before"]
+	switch {
+	case len(updater.After) > 0 && len(updater.Before) > 0:
+		return nil, errors.New("invalid parameters: after and before can't be used together")
+	case updater.State == StateAbsent && (len(updater.After) > 0 || len(updater.Before) > 0):
+		return nil, fmt.Errorf("invalid parameters: after and before are only supported by the %s state", StatePresent)
+	}
+	if len(updater.After) > 0 && updater.After != EOF {
+		updater.AfterRegexp, err = regexp.Compile(updater.After)
+		if err != nil {
+			return nil, fmt.Errorf("invalid after parameter %s: %w", updater.After, err)
+		}
+	}
+	if len(updater.Before) > 0 && updater.Before != BOF {
+		updater.BeforeRegexp, err = regexp.Compile(updater.Before)
+		if err != nil {
+			return nil, fmt.Errorf("invalid before parameter %s: %w", updater.Before, err)
+		}
+	}
+
+	updater.AutoCreate, _ = strconv.ParseBool(params["create"])
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *LineInFileUpdater) Update(_ context.Context, repoPath string) (bool, error) {
+	u.Reset(repoPath)
+
+	filePaths, err := glob.ExpandGlobPattern(repoPath, u.FilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to expand glob pattern %s: %w", u.FilePath, err)
+	}
+	if len(filePaths) == 0 && u.AutoCreate && u.State == StatePresent {
+		filePaths = []string{filepath.Join(repoPath, u.FilePath)}
+	}
+
+	var updated bool
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		var (
+			content []byte
+			mode    os.FileMode = 0644
+		)
+		if fileInfo, err := os.Stat(filePath); err == nil {
+			mode = fileInfo.Mode()
+			if content, err = os.ReadFile(filePath); err != nil {
+				return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		} else if err = os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return false, fmt.Errorf("failed to create directories for file %s: %w", relFilePath, err)
+		}
+
+		f := parseFile(string(content))
+		var changes []change.Change
+		if u.State == StateAbsent {
+			changes = u.removeLines(f)
+		} else {
+			changes = u.ensureLine(f)
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		if err = os.WriteFile(filePath, []byte(f.String()), mode); err != nil {
+			return false, fmt.Errorf("failed to write updated content to file %s: %w", relFilePath, err)
+		}
+		for i := range changes {
+			changes[i].File = filepath.ToSlash(relFilePath)
+		}
+		u.Record(repoPath, changes...)
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *LineInFileUpdater) Message() (title, body string) {
+	if u.State == StateAbsent {
+		title = fmt.Sprintf("Remove line from %s", u.FilePath)
+		body = fmt.Sprintf("Removing the lines matching `%s` from file(s) `%s`", u.lineDescription(), u.FilePath)
+		return title, body
+	}
+	title = fmt.Sprintf("Update %s", u.FilePath)
+	body = fmt.Sprintf("Ensuring line `%s` is present in file(s) `%s`", u.Line, u.FilePath)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *LineInFileUpdater) String() string {
+	return fmt.Sprintf("LineInFile[file=%s,line=%s,regexp=%s,after=%s,before=%s,state=%s,create=%v]", u.FilePath, u.Line, u.Pattern, u.After, u.Before, u.State, u.AutoCreate)
+}
+
+func (u *LineInFileUpdater) lineDescription() string {
+	if u.Regexp != nil {
+		return u.Pattern
+	}
+	return u.Line
+}
+
+// ensureLine ensures the line is present in the file, and returns the changes.
+// If the regexp matches, the last matching line is replaced. Otherwise - unless the line already exists - it is inserted.
+func (u *LineInFileUpdater) ensureLine(f *file) []change.Change {
+	if u.Regexp != nil {
+		if i := f.lastMatch(u.Regexp); i >= 0 {
+			if f.lines[i] == u.Line {
+				return nil
+			}
+			old := f.lines[i]
+			f.lines[i] = u.Line
+			return []change.Change{{Name: fmt.Sprintf("line %d", i+1), Old: old, New: u.Line}}
+		}
+	}
+	for _, line := range f.lines {
+		if line == u.Line {
+			return nil
+		}
+	}
+
+	// by default - or if the after/before regexps don't match - the line is inserted at the end of the file
+	index := len(f.lines)
+	switch {
+	case u.Before == BOF:
+		index = 0
+	case u.BeforeRegexp != nil:
+		if i := f.lastMatch(u.BeforeRegexp); i >= 0 {
+			index = i
+		}
+	case u.AfterRegexp != nil:
+		if i := f.lastMatch(u.AfterRegexp); i >= 0 {
+			index = i + 1
+		}
+	}
+	f.insert(index, u.Line)
+	return []change.Change{{Name: fmt.Sprintf("line %d", index+1), New: u.Line}}
+}
+
+// removeLines removes all the lines matching the regexp - or equal to the line - and returns the changes.
+func (u *LineInFileUpdater) removeLines(f *file) []change.Change {
+	var (
+		kept    []string
+		changes []change.Change
+	)
+	for i, line := range f.lines {
+		if (u.Regexp != nil && u.Regexp.MatchString(line)) || (u.Regexp == nil && line == u.Line) {
+			changes = append(changes, change.Change{Name: fmt.Sprintf("line %d", i+1), Old: line})
+			continue
+		}
+		kept = append(kept, line)
+	}
+	f.lines = kept
+	return changes
+}
+
+// file is a text file, as a list of lines without their line terminator.
+type file struct {
+	lines []string
+	// the line terminator used by the file - \n or \r\n
+	eol string
+	// whether the last line has a line terminator
+	finalEOL bool
+}
+
+func parseFile(content string) *file {
+	f := &file{eol: "\n", finalEOL: true}
+	if strings.Contains(content, "\r\n") {
+		f.eol = "\r\n"
+	}
+	if len(content) == 0 {
+		return f
+	}
+	f.finalEOL = strings.HasSuffix(content, "\n")
+	content = strings.TrimSuffix(content, "\n")
+	for _, line := range strings.Split(content, "\n") {
+		f.lines = append(f.lines, strings.TrimSuffix(line, "\r"))
+	}
+	return f
+}
+
+// lastMatch returns the index of the last line matching the given regexp, or -1.
+func (f *file) lastMatch(re *regexp.Regexp) int {
+	for i := len(f.lines) - 1; i >= 0; i-- {
+		if re.MatchString(f.lines[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *file) insert(index int, line string) {
+	if index == len(f.lines) {
+		// the previous last line needs a line terminator now
+		f.finalEOL = true
+	}
+	f.lines = append(f.lines[:index], append([]string{line}, f.lines[index:]...)...)
+}
+
+// String returns the content of the file.
+func (f *file) String() string {
+	if len(f.lines) == 0 {
+		return ""
+	}
+	content := strings.Join(f.lines, f.eol)
+	if f.finalEOL {
+		content += f.eol
+	}
+	return content
+}
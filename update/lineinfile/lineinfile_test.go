@@ -0,0 +1,368 @@
+package lineinfile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/update/change"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *LineInFileUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "present line after regexp",
+			params: map[string]string{
+				"file":  ".golangci.yml",
+				"line":  "    - gosec",
+				"after": `^\s+enable:`,
+			},
+			expected: &LineInFileUpdater{
+				FilePath:    ".golangci.yml",
+				Line:        "    - gosec",
+				After:       `^\s+enable:`,
+				AfterRegexp: regexp.MustCompile(`^\s+enable:`),
+				State:       StatePresent,
+			},
+		},
+		{
+			name: "present line replacing regexp at the beginning of the file",
+			params: map[string]string{
+				"file":   "**/.gitignore",
+				"line":   "/vendor/",
+				"regexp": `^/?vendor/?$`,
+				"before": "BOF",
+				"state":  "present",
+				"create": "true",
+			},
+			expected: &LineInFileUpdater{
+				FilePath:   "**/.gitignore",
+				Line:       "/vendor/",
+				Pattern:    `^/?vendor/?$`,
+				Regexp:     regexp.MustCompile(`^/?vendor/?$`),
+				Before:     BOF,
+				State:      StatePresent,
+				AutoCreate: true,
+			},
+		},
+		{
+			name: "absent regexp",
+			params: map[string]string{
+				"file":   ".gitignore",
+				"regexp": `^\*\.bak$`,
+				"state":  "absent",
+			},
+			expected: &LineInFileUpdater{
+				FilePath: ".gitignore",
+				Pattern:  `^\*\.bak$`,
+				Regexp:   regexp.MustCompile(`^\*\.bak$`),
+				State:    StateAbsent,
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing file parameter",
+		},
+		{
+			name: "invalid state",
+			params: map[string]string{
+				"file":  ".gitignore",
+				"line":  "*.bak",
+				"state": "missing",
+			},
+			expectedErrorMsg: "invalid state parameter missing: must be one of present or absent",
+		},
+		{
+			name: "missing line",
+			params: map[string]string{
+				"file":   ".gitignore",
+				"regexp": `^\*\.bak$`,
+			},
+			expectedErrorMsg: "missing line parameter",
+		},
+		{
+			name: "missing line and regexp",
+			params: map[string]string{
+				"file":  ".gitignore",
+				"state": "absent",
+			},
+			expectedErrorMsg: "missing line or regexp parameter",
+		},
+		{
+			name: "after and before",
+			params: map[string]string{
+				"file":   ".gitignore",
+				"line":   "*.bak",
+				"after":  "EOF",
+				"before": "BOF",
+			},
+			expectedErrorMsg: "invalid parameters: after and before can't be used together",
+		},
+		{
+			name: "after with absent state",
+			params: map[string]string{
+				"file":  ".gitignore",
+				"line":  "*.bak",
+				"after": "EOF",
+				"state": "absent",
+			},
+			expectedErrorMsg: "invalid parameters: after and before are only supported by the present state",
+		},
+		{
+			name: "invalid before regexp",
+			params: map[string]string{
+				"file":   ".gitignore",
+				"line":   "*.bak",
+				"before": "[",
+			},
+			expectedErrorMsg: "invalid before parameter [: error parsing regexp: missing closing ]: `[`",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	// the file created by the updater must not exist before the test
+	require.NoError(t, os.RemoveAll(filepath.Join("testdata", "create")))
+
+	tests := []struct {
+		name            string
+		files           map[string]string
+		updater         *LineInFileUpdater
+		expected        bool
+		expectedFiles   map[string]string
+		expectedChanges []change.Change
+	}{
+		{
+			name: "insert line after the last match",
+			files: map[string]string{
+				"after/.golangci.yml": "linters:\n  enable:\n    - govet\n  disable:\n    - lll\n",
+			},
+			updater: &LineInFileUpdater{
+				FilePath:    "after/.golangci.yml",
+				Line:        "    - gosec",
+				AfterRegexp: regexp.MustCompile(`^\s+enable:`),
+				State:       StatePresent,
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"after/.golangci.yml": "linters:\n  enable:\n    - gosec\n    - govet\n  disable:\n    - lll\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "after/.golangci.yml", Name: "line 3", New: "    - gosec"},
+			},
+		},
+		{
+			name: "insert line before the last match",
+			files: map[string]string{
+				"before/Makefile": "build:\n\tgo build ./...\n\ninclude common.mk\n",
+			},
+			updater: &LineInFileUpdater{
+				FilePath:     "before/Makefile",
+				Line:         "include lint.mk",
+				BeforeRegexp: regexp.MustCompile(`^include `),
+				State:        StatePresent,
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"before/Makefile": "build:\n\tgo build ./...\n\ninclude lint.mk\ninclude common.mk\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "before/Makefile", Name: "line 4", New: "include lint.mk"},
+			},
+		},
+		{
+			name: "insert line at the end of multiple files",
+			files: map[string]string{
+				"eof/a/.gitignore": "*.log",
+				"eof/b/.gitignore": "*.log\r\n/bin/\r\n",
+				"eof/c/.gitignore": "*.log\n*.bak\n",
+			},
+			updater: &LineInFileUpdater{
+				FilePath:     "eof/**/.gitignore",
+				Line:         "*.bak",
+				BeforeRegexp: regexp.MustCompile(`^no match$`),
+				State:        StatePresent,
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"eof/a/.gitignore": "*.log\n*.bak\n",
+				"eof/b/.gitignore": "*.log\r\n/bin/\r\n*.bak\r\n",
+				"eof/c/.gitignore": "*.log\n*.bak\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "eof/a/.gitignore", Name: "line 2", New: "*.bak"},
+				{File: "eof/b/.gitignore", Name: "line 3", New: "*.bak"},
+			},
+		},
+		{
+			name: "insert line at the beginning of the file",
+			files: map[string]string{
+				"bof/main.go": "package main\n",
+			},
+			updater: &LineInFileUpdater{
+				FilePath: "bof/main.go",
+				Line:     "// Code generated by octopilot. DO NOT EDIT.",
+				Before:   BOF,
+				State:    StatePresent,
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"bof/main.go": "// Code generated by octopilot. DO NOT EDIT.\npackage main\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "bof/main.go", Name: "line 1", New: "// Code generated by octopilot. DO NOT EDIT."},
+			},
+		},
+		{
+			name: "replace the last matching line",
+			files: map[string]string{
+				"replace/.tool-versions": "golang 1.20.1\nnodejs 20.0.0\ngolang 1.20.2\n",
+			},
+			updater: &LineInFileUpdater{
+				FilePath: "replace/.tool-versions",
+				Line:     "golang 1.22.0",
+				Regexp:   regexp.MustCompile(`^golang `),
+				State:    StatePresent,
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"replace/.tool-versions": "golang 1.20.1\nnodejs 20.0.0\ngolang 1.22.0\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "replace/.tool-versions", Name: "line 3", Old: "golang 1.20.2", New: "golang 1.22.0"},
+			},
+		},
+		{
+			name: "line already present",
+			files: map[string]string{
+				"present/.gitignore": "*.bak\n*.log\n",
+			},
+			updater: &LineInFileUpdater{
+				FilePath:    "present/.gitignore",
+				Line:        "*.bak",
+				AfterRegexp: regexp.MustCompile(`^\*\.log$`),
+				State:       StatePresent,
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"present/.gitignore": "*.bak\n*.log\n",
+			},
+		},
+		{
+			name: "create missing file",
+			updater: &LineInFileUpdater{
+				FilePath:   "create/config/.gitignore",
+				Line:       "*.bak",
+				State:      StatePresent,
+				AutoCreate: true,
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"create/config/.gitignore": "*.bak\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "create/config/.gitignore", Name: "line 1", New: "*.bak"},
+			},
+		},
+		{
+			name: "missing file",
+			updater: &LineInFileUpdater{
+				FilePath: "missing/.gitignore",
+				Line:     "*.bak",
+				State:    StatePresent,
+			},
+			expected: false,
+		},
+		{
+			name: "remove matching lines",
+			files: map[string]string{
+				"absent/.gitignore": "*.bak\n*.log\n*.BAK\n",
+			},
+			updater: &LineInFileUpdater{
+				FilePath: "absent/.gitignore",
+				Regexp:   regexp.MustCompile(`(?i)^\*\.bak$`),
+				State:    StateAbsent,
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"absent/.gitignore": "*.log\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "absent/.gitignore", Name: "line 1", Old: "*.bak"},
+				{File: "absent/.gitignore", Name: "line 3", Old: "*.BAK"},
+			},
+		},
+		{
+			name: "line already absent",
+			files: map[string]string{
+				"already-absent/.gitignore": "*.log\n",
+			},
+			updater: &LineInFileUpdater{
+				FilePath: "already-absent/.gitignore",
+				Line:     "*.bak",
+				State:    StateAbsent,
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"already-absent/.gitignore": "*.log\n",
+			},
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(context.Background(), "testdata")
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+			for filename, expectedContent := range test.expectedFiles {
+				actualContent, err := os.ReadFile(filepath.Join("testdata", filename))
+				require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+				assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+			}
+			assert.Equal(t, test.expectedChanges, test.updater.Changes("testdata"))
+
+			// running the updater again must not change anything
+			actual, err = test.updater.Update(context.Background(), "testdata")
+			require.NoError(t, err)
+			assert.False(t, actual)
+		})
+	}
+}
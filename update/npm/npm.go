@@ -0,0 +1,302 @@
+// Package npm provides an updater that updates the version of a dependency in npm package.json files.
+package npm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	"github.com/dailymotion-oss/octopilot/internal/json"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// the sections of a package.json file in which the dependencies are defined
+	dependenciesSections = []string{"dependencies", "devDependencies", "peerDependencies", "optionalDependencies"}
+
+	// a simple version range, such as ^1.2.3, ~1.2, >=1.0.0 or 1.x
+	versionRangeRegexp = regexp.MustCompile(`^(\^|~|>=|<=|>|<|=)?\s*v?(\d+(?:\.(?:\d+|x|X|\*)){0,2}(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?)$`)
+)
+
+// NpmUpdater is an updater that updates the version of a dependency in npm package.json files - including the workspaces.
+type NpmUpdater struct {
+	FilePath string
+	Package  string
+	Valuer   value.Valuer
+
+	change.Recorder
+}
+
+// NewUpdater builds a new npm updater from the given parameters and valuer
+func NewUpdater(params map[string]string, valuer value.Valuer) (*NpmUpdater, error) {
+	updater := &NpmUpdater{}
+
+	updater.FilePath = params["file"]
+	if len(updater.FilePath) == 0 {
+		updater.FilePath = "**/package.json"
+	}
+
+	updater.Package = params["package"]
+	if len(updater.Package) == 0 {
+		return nil, errors.New("missing package parameter")
+	}
+
+	updater.Valuer = valuer
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *NpmUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	u.Reset(repoPath)
+
+	version, err := u.Valuer.Value(ctx, repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get value: %w", err)
+	}
+	version = strings.TrimSpace(version)
+	if !versionRangeRegexp.MatchString(version) {
+		return false, fmt.Errorf("invalid value %q: expected a version, such as 1.2.3 or ^1.2.3", version)
+	}
+
+	filePaths, err := u.filePaths(repoPath)
+	if err != nil {
+		return false, err
+	}
+
+	var updated bool
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+		}
+
+		doc, err := json.Parse(content)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse file %s: %w", relFilePath, err)
+		}
+
+		replacements, changes := u.updateDependencies(doc, version)
+		if len(replacements) == 0 {
+			continue
+		}
+		for i := range changes {
+			changes[i].File = filepath.ToSlash(relFilePath)
+		}
+
+		if err = os.WriteFile(filePath, doc.Replace(replacements...), fileInfo.Mode()); err != nil {
+			return false, fmt.Errorf("failed to write updated content to file %s: %w", relFilePath, err)
+		}
+
+		u.Record(repoPath, changes...)
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *NpmUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Update npm package %s", u.Package)
+	body = fmt.Sprintf("Updating npm package `%s` in file(s) `%s`", u.Package, u.FilePath)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *NpmUpdater) String() string {
+	return fmt.Sprintf("Npm[package=%s,file=%s]", u.Package, u.FilePath)
+}
+
+// filePaths returns the paths of the package.json files matching the file pattern,
+// and the ones of their workspaces - defined either in the package.json file, or in a pnpm-workspace.yaml file.
+func (u *NpmUpdater) filePaths(repoPath string) ([]string, error) {
+	filePaths, err := glob.ExpandGlobPattern(repoPath, u.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand glob pattern %s: %w", u.FilePath, err)
+	}
+
+	var (
+		result []string
+		seen   = make(map[string]bool)
+	)
+	for i := 0; i < len(filePaths); i++ {
+		filePath := filepath.Clean(filePaths[i])
+		if seen[filePath] || strings.Contains(filepath.ToSlash(filePath), "/node_modules/") {
+			continue
+		}
+		seen[filePath] = true
+		result = append(result, filePath)
+
+		patterns, err := workspacesPatterns(repoPath, filePath)
+		if err != nil {
+			return nil, err
+		}
+		workspaces, err := expandWorkspaces(filepath.Dir(filePath), patterns)
+		if err != nil {
+			return nil, err
+		}
+		filePaths = append(filePaths, workspaces...)
+	}
+	return result, nil
+}
+
+// workspacesPatterns returns the workspaces patterns defined for the given package.json file:
+// either in its "workspaces" field - as an array or in a "packages" array for yarn - or in a pnpm-workspace.yaml file next to it.
+func workspacesPatterns(repoPath, filePath string) ([]string, error) {
+	var patterns []string
+
+	relFilePath, err := filepath.Rel(repoPath, filePath)
+	if err != nil {
+		relFilePath = filePath
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+	}
+	doc, err := json.Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file %s: %w", relFilePath, err)
+	}
+	if workspaces, found := doc.Root().Member("workspaces"); found {
+		if packages, found := workspaces.Member("packages"); found {
+			workspaces = packages
+		}
+		if workspaces.Kind == json.Array {
+			for _, member := range workspaces.Members {
+				if pattern, err := doc.String(member); err == nil && member.Kind == json.String {
+					patterns = append(patterns, pattern)
+				}
+			}
+		}
+	}
+
+	pnpmWorkspace, err := os.ReadFile(filepath.Join(filepath.Dir(filePath), "pnpm-workspace.yaml"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read pnpm workspace file for %s: %w", relFilePath, err)
+	}
+	if len(pnpmWorkspace) > 0 {
+		var workspace struct {
+			Packages []string `yaml:"packages"`
+		}
+		if err = yaml.Unmarshal(pnpmWorkspace, &workspace); err != nil {
+			return nil, fmt.Errorf("failed to parse pnpm workspace file for %s: %w", relFilePath, err)
+		}
+		patterns = append(patterns, workspace.Packages...)
+	}
+
+	return patterns, nil
+}
+
+// expandWorkspaces returns the paths of the package.json files of the workspaces matching the given patterns
+// - excluding the ones matching a negated pattern, such as "!packages/legacy".
+func expandWorkspaces(dir string, patterns []string) ([]string, error) {
+	var (
+		filePaths []string
+		excluded  = make(map[string]bool)
+	)
+	for _, pattern := range patterns {
+		exclude := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		matches, err := glob.ExpandGlobPattern(dir, filepath.Join(pattern, "package.json"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand workspaces pattern %s: %w", pattern, err)
+		}
+		for _, match := range matches {
+			if exclude {
+				excluded[filepath.Clean(match)] = true
+			} else {
+				filePaths = append(filePaths, filepath.Clean(match))
+			}
+		}
+	}
+
+	var result []string
+	for _, filePath := range filePaths {
+		if !excluded[filePath] {
+			result = append(result, filePath)
+		}
+	}
+	return result, nil
+}
+
+// updateDependencies returns the replacements required to update the dependency in all the sections of the package.json file.
+func (u *NpmUpdater) updateDependencies(doc *json.Document, version string) ([]json.Replacement, []change.Change) {
+	var (
+		replacements []json.Replacement
+		changes      []change.Change
+	)
+	for _, section := range dependenciesSections {
+		dependencies, found := doc.Root().Member(section)
+		if !found || dependencies.Kind != json.Object {
+			continue
+		}
+		for _, dependency := range dependencies.Members {
+			if dependency.Kind != json.String {
+				continue
+			}
+			oldSpec, err := doc.String(dependency)
+			if err != nil {
+				continue
+			}
+
+			// the dependency can be an alias, such as "ui": "npm:@org/ui@^1.0.0"
+			var prefix string
+			switch alias := "npm:" + u.Package + "@"; {
+			case strings.HasPrefix(oldSpec, alias):
+				prefix = alias
+			case dependency.Key == u.Package && !strings.HasPrefix(oldSpec, "npm:"):
+			default:
+				continue
+			}
+			// the version can use the workspace protocol, such as "workspace:^1.0.0"
+			if strings.HasPrefix(oldSpec[len(prefix):], "workspace:") {
+				prefix += "workspace:"
+			}
+
+			newRange, ok := updateRange(oldSpec[len(prefix):], version)
+			if !ok || prefix+newRange == oldSpec {
+				continue
+			}
+			newSpec := prefix + newRange
+
+			replacements = append(replacements, json.Replacement{Value: dependency, Content: json.Quote(newSpec)})
+			name := u.Package
+			if section != "dependencies" {
+				name = fmt.Sprintf("%s (%s)", u.Package, section)
+			}
+			changes = append(changes, change.Change{Name: name, Old: oldSpec, New: newSpec})
+		}
+	}
+	return replacements, changes
+}
+
+// updateRange returns the new version range for the dependency: the new version, with the same range prefix - such as ^ or ~ - as the old range.
+// If the new version already has a prefix, it is used as-is. Complex ranges - such as "1.x || 2.x" - and non-version specs - such as git URLs or tags - are not updated.
+func updateRange(oldRange, version string) (string, bool) {
+	matches := versionRangeRegexp.FindStringSubmatch(strings.TrimSpace(oldRange))
+	if len(matches) == 0 {
+		return "", false
+	}
+	if newMatches := versionRangeRegexp.FindStringSubmatch(version); len(newMatches[1]) > 0 {
+		return newMatches[1] + newMatches[2], true
+	}
+	return matches[1] + strings.TrimPrefix(version, "v"), true
+}
@@ -0,0 +1,278 @@
+package npm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *NpmUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params with default file",
+			params: map[string]string{
+				"package": "@org/ui",
+			},
+			expected: &NpmUpdater{
+				FilePath: "**/package.json",
+				Package:  "@org/ui",
+			},
+		},
+		{
+			name: "valid params with custom file",
+			params: map[string]string{
+				"file":    "package.json",
+				"package": "lodash",
+			},
+			expected: &NpmUpdater{
+				FilePath: "package.json",
+				Package:  "lodash",
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing package parameter",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params, nil)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		files            map[string]string
+		updater          *NpmUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+		expectedChanges  []change.Change
+	}{
+		{
+			name: "update all sections and keep range prefixes",
+			files: map[string]string{
+				"sections/package.json": `{
+    "name": "my-app",
+    "dependencies": {
+        "react": "^18.2.0",
+        "@org/ui": "^2.1.0"
+    },
+    "devDependencies": {"@org/ui": "~2.1.0", "@org/ui-extra": "1.0.0"},
+    "peerDependencies": {
+        "@org/ui": ">=2.0.0"
+    },
+    "optionalDependencies": {
+        "@org/ui": "2.x || 3.x"
+    }
+}
+`,
+			},
+			updater: &NpmUpdater{
+				FilePath: "sections/package.json",
+				Package:  "@org/ui",
+				Valuer:   value.StringValuer("2.3.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"sections/package.json": `{
+    "name": "my-app",
+    "dependencies": {
+        "react": "^18.2.0",
+        "@org/ui": "^2.3.0"
+    },
+    "devDependencies": {"@org/ui": "~2.3.0", "@org/ui-extra": "1.0.0"},
+    "peerDependencies": {
+        "@org/ui": ">=2.3.0"
+    },
+    "optionalDependencies": {
+        "@org/ui": "2.x || 3.x"
+    }
+}
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "sections/package.json", Name: "@org/ui", Old: "^2.1.0", New: "^2.3.0"},
+				{File: "sections/package.json", Name: "@org/ui (devDependencies)", Old: "~2.1.0", New: "~2.3.0"},
+				{File: "sections/package.json", Name: "@org/ui (peerDependencies)", Old: ">=2.0.0", New: ">=2.3.0"},
+			},
+		},
+		{
+			name: "update workspaces",
+			files: map[string]string{
+				"workspaces/package.json": `{
+  "private": true,
+  "workspaces": ["packages/*", "!packages/legacy"]
+}`,
+				"workspaces/packages/app/package.json": `{
+  "name": "app",
+  "dependencies": {
+    "@org/ui": "workspace:^2.1.0",
+    "ui-next": "npm:@org/ui@2.1.0"
+  }
+}`,
+				"workspaces/packages/legacy/package.json": `{
+  "name": "legacy",
+  "dependencies": {
+    "@org/ui": "^1.0.0"
+  }
+}`,
+				"workspaces/packages/local/package.json": `{
+  "name": "local",
+  "dependencies": {
+    "@org/ui": "workspace:*"
+  }
+}`,
+			},
+			updater: &NpmUpdater{
+				FilePath: "workspaces/package.json",
+				Package:  "@org/ui",
+				Valuer:   value.StringValuer("^2.3.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"workspaces/packages/app/package.json": `{
+  "name": "app",
+  "dependencies": {
+    "@org/ui": "workspace:^2.3.0",
+    "ui-next": "npm:@org/ui@^2.3.0"
+  }
+}`,
+				"workspaces/packages/legacy/package.json": `{
+  "name": "legacy",
+  "dependencies": {
+    "@org/ui": "^1.0.0"
+  }
+}`,
+				"workspaces/packages/local/package.json": `{
+  "name": "local",
+  "dependencies": {
+    "@org/ui": "workspace:*"
+  }
+}`,
+			},
+			expectedChanges: []change.Change{
+				{File: "workspaces/packages/app/package.json", Name: "@org/ui", Old: "workspace:^2.1.0", New: "workspace:^2.3.0"},
+				{File: "workspaces/packages/app/package.json", Name: "@org/ui", Old: "npm:@org/ui@2.1.0", New: "npm:@org/ui@^2.3.0"},
+			},
+		},
+		{
+			name: "update pnpm workspaces",
+			files: map[string]string{
+				"pnpm/package.json": `{"name": "root"}`,
+				"pnpm/pnpm-workspace.yaml": `packages:
+  - "apps/**"
+`,
+				"pnpm/apps/web/package.json": "{\r\n\t\"devDependencies\": {\r\n\t\t\"lodash\": \"4.17.20\"\r\n\t}\r\n}\r\n",
+			},
+			updater: &NpmUpdater{
+				FilePath: "pnpm/package.json",
+				Package:  "lodash",
+				Valuer:   value.StringValuer("v4.17.21"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"pnpm/apps/web/package.json": "{\r\n\t\"devDependencies\": {\r\n\t\t\"lodash\": \"4.17.21\"\r\n\t}\r\n}\r\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "pnpm/apps/web/package.json", Name: "lodash (devDependencies)", Old: "4.17.20", New: "4.17.21"},
+			},
+		},
+		{
+			name: "no changes",
+			files: map[string]string{
+				"no-changes/package.json":                  `{"dependencies": {"lodash": "^4.17.21", "other": "github:org/lodash"}}`,
+				"no-changes/node_modules/lib/package.json": `{"dependencies": {"lodash": "^4.17.0"}}`,
+			},
+			updater: &NpmUpdater{
+				FilePath: "no-changes/**/package.json",
+				Package:  "lodash",
+				Valuer:   value.StringValuer("4.17.21"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-changes/node_modules/lib/package.json": `{"dependencies": {"lodash": "^4.17.0"}}`,
+			},
+		},
+		{
+			name: "invalid value",
+			files: map[string]string{
+				"invalid-value/package.json": `{"dependencies": {"lodash": "^4.17.21"}}`,
+			},
+			updater: &NpmUpdater{
+				FilePath: "invalid-value/package.json",
+				Package:  "lodash",
+				Valuer:   value.StringValuer("latest"),
+			},
+			expectedErrorMsg: `invalid value "latest": expected a version, such as 1.2.3 or ^1.2.3`,
+		},
+		{
+			name: "invalid file",
+			files: map[string]string{
+				"invalid-file/package.json": `{"dependencies": {"lodash": "^4.17.21",}}`,
+			},
+			updater: &NpmUpdater{
+				FilePath: "invalid-file/package.json",
+				Package:  "lodash",
+				Valuer:   value.StringValuer("4.17.21"),
+			},
+			expectedErrorMsg: `failed to parse file invalid-file/package.json: line 1, column 40: expected a quoted key`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(context.Background(), "testdata")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+				for filename, expectedContent := range test.expectedFiles {
+					actualContent, err := os.ReadFile(filepath.Join("testdata", filename))
+					require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+					assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+				}
+				assert.Equal(t, test.expectedChanges, test.updater.Changes("testdata"))
+			}
+		})
+	}
+}
@@ -0,0 +1,400 @@
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// @@ -oldStart[,oldLines] +newStart[,newLines] @@
+	hunkHeaderRegexp = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+	errBinaryPatch = errors.New("binary patches are not supported")
+)
+
+// fileDiff is the diff of a single file: a creation, a deletion, a modification and/or a rename.
+type fileDiff struct {
+	// the paths are empty for a created (old) or deleted (new) file
+	oldPath string
+	newPath string
+	// the modes are 0 if they are not changed by the diff
+	oldMode os.FileMode
+	newMode os.FileMode
+	hunks   []*hunk
+}
+
+// hunk is a group of changed lines, with their context.
+type hunk struct {
+	oldStart int
+	oldLines int
+	newStart int
+	newLines int
+	// the lines include their line terminator - except for the last line of a file without a final newline
+	lines []hunkLine
+}
+
+// hunkLine is a line of a hunk, with its operation: ' ' for a context line, '-' for a removed line and '+' for an added line.
+type hunkLine struct {
+	op   byte
+	text string
+}
+
+// parseDiff parses a unified diff - optionally with git extended headers - and returns the diff of each file.
+// The text outside of the diffs - such as the headers and commit messages of a mbox generated by `git format-patch` - is ignored.
+// The given number of leading path components are stripped from the file paths, like with the `-p` flag of the `patch` command.
+func parseDiff(content string, strip int) ([]*fileDiff, error) {
+	lines := splitLines(content)
+	var (
+		diffs []*fileDiff
+		// the current diff, and whether it was started by a "diff --git" line - and so might have extended headers
+		current   *fileDiff
+		gitHeader bool
+	)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			oldPath, newPath, err := parseGitHeaderPaths(strings.TrimPrefix(line, "diff --git "), strip)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			current = &fileDiff{oldPath: oldPath, newPath: newPath}
+			gitHeader = true
+			diffs = append(diffs, current)
+
+		case gitHeader && strings.HasPrefix(line, "new file mode "):
+			current.oldPath = ""
+			current.newMode = parseMode(strings.TrimPrefix(line, "new file mode "))
+		case gitHeader && strings.HasPrefix(line, "deleted file mode "):
+			current.newPath = ""
+			current.oldMode = parseMode(strings.TrimPrefix(line, "deleted file mode "))
+		case gitHeader && strings.HasPrefix(line, "old mode "):
+			current.oldMode = parseMode(strings.TrimPrefix(line, "old mode "))
+		case gitHeader && strings.HasPrefix(line, "new mode "):
+			current.newMode = parseMode(strings.TrimPrefix(line, "new mode "))
+		case gitHeader && strings.HasPrefix(line, "rename from "):
+			// the paths of the rename headers don't have the a/ and b/ prefixes
+			current.oldPath = unquotePath(strings.TrimPrefix(line, "rename from "))
+		case gitHeader && strings.HasPrefix(line, "rename to "):
+			current.newPath = unquotePath(strings.TrimPrefix(line, "rename to "))
+		case gitHeader && (strings.HasPrefix(line, "copy from ") || strings.HasPrefix(line, "copy to ")):
+			return nil, fmt.Errorf("line %d: file copies are not supported", i+1)
+		case gitHeader && (strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch"):
+			return nil, fmt.Errorf("line %d: %w", i+1, errBinaryPatch)
+
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			oldPath, err := parseFilePath(strings.TrimPrefix(line, "--- "), strip)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			newPath, err := parseFilePath(strings.TrimPrefix(strings.TrimRight(lines[i+1], "\r\n"), "+++ "), strip)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+2, err)
+			}
+			// a plain unified diff doesn't have a "diff --git" line to start the diff of a new file
+			if current == nil || !gitHeader || len(current.hunks) > 0 {
+				current = &fileDiff{}
+				diffs = append(diffs, current)
+			}
+			current.oldPath, current.newPath = oldPath, newPath
+			gitHeader = false
+			i++
+
+		case strings.HasPrefix(line, "@@ -") && current != nil:
+			h, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			current.hunks = append(current.hunks, h)
+			gitHeader = false
+			i = next - 1
+
+		case strings.HasPrefix(line, "From ") || line == "---" || line == "-- ":
+			// the start of a new patch in a mbox, the end of a commit message, or the git version signature
+			current = nil
+			gitHeader = false
+		}
+	}
+
+	if len(diffs) == 0 {
+		return nil, errors.New("no file diff found")
+	}
+	return diffs, nil
+}
+
+// parseHunk parses the hunk starting with the header at the given line, and returns it with the index of the line following the hunk.
+func parseHunk(lines []string, start int) (*hunk, int, error) {
+	matches := hunkHeaderRegexp.FindStringSubmatch(lines[start])
+	if matches == nil {
+		return nil, 0, fmt.Errorf("line %d: invalid hunk header %q", start+1, strings.TrimSpace(lines[start]))
+	}
+	h := &hunk{oldLines: 1, newLines: 1}
+	h.oldStart, _ = strconv.Atoi(matches[1])
+	if len(matches[2]) > 0 {
+		h.oldLines, _ = strconv.Atoi(matches[2])
+	}
+	h.newStart, _ = strconv.Atoi(matches[3])
+	if len(matches[4]) > 0 {
+		h.newLines, _ = strconv.Atoi(matches[4])
+	}
+
+	var oldCount, newCount int
+	i := start + 1
+	for ; i < len(lines) && (oldCount < h.oldLines || newCount < h.newLines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file" applies to the previous line
+			if len(h.lines) > 0 {
+				last := &h.lines[len(h.lines)-1]
+				last.text = strings.TrimSuffix(last.text, "\n")
+			}
+			continue
+		case line == "\n" || line == "\r\n":
+			// some editors remove the trailing whitespace of the empty context lines
+			line = " " + line
+		}
+
+		op := line[0]
+		switch op {
+		case ' ':
+			oldCount++
+			newCount++
+		case '-':
+			oldCount++
+		case '+':
+			newCount++
+		default:
+			return nil, 0, fmt.Errorf("line %d: invalid hunk line %q - expected %d old and %d new lines, got %d and %d", i+1, strings.TrimSpace(line), h.oldLines, h.newLines, oldCount, newCount)
+		}
+		h.lines = append(h.lines, hunkLine{op: op, text: line[1:]})
+	}
+	if oldCount != h.oldLines || newCount != h.newLines {
+		return nil, 0, fmt.Errorf("line %d: unexpected end of hunk - expected %d old and %d new lines, got %d and %d", i, h.oldLines, h.newLines, oldCount, newCount)
+	}
+
+	// the marker for the last line of the hunk
+	if i < len(lines) && strings.HasPrefix(lines[i], `\`) {
+		last := &h.lines[len(h.lines)-1]
+		last.text = strings.TrimSuffix(last.text, "\n")
+		i++
+	}
+	return h, i, nil
+}
+
+// parseGitHeaderPaths returns the paths of a "diff --git a/path b/path" line.
+// They are only used if the diff doesn't have "---" and "+++" lines, which is the case for renames and mode changes without content changes.
+func parseGitHeaderPaths(paths string, strip int) (oldPath, newPath string, err error) {
+	if strings.HasPrefix(paths, `"`) {
+		// quoted paths: "a/old path" "b/new path"
+		end := strings.Index(paths[1:], `" `) + 1
+		if end <= 0 {
+			return "", "", fmt.Errorf("invalid diff header paths %s", paths)
+		}
+		oldPath, newPath = paths[:end+1], strings.TrimSpace(paths[end+1:])
+	} else {
+		// without renames - for which the paths are found in the extended headers - both paths have the same length
+		n := len(paths) / 2
+		oldPath, newPath = paths[:n], paths[n+1:]
+	}
+	if oldPath, err = parseFilePath(oldPath, strip); err != nil {
+		return "", "", err
+	}
+	if newPath, err = parseFilePath(newPath, strip); err != nil {
+		return "", "", err
+	}
+	return oldPath, newPath, nil
+}
+
+// parseFilePath returns the path of a "---" or "+++" line - without the timestamp, and with the given number of leading components stripped.
+// It returns an empty path for /dev/null.
+func parseFilePath(p string, strip int) (string, error) {
+	if i := strings.Index(p, "\t"); i >= 0 {
+		p = p[:i]
+	}
+	p = unquotePath(strings.TrimSpace(p))
+	if p == "/dev/null" {
+		return "", nil
+	}
+
+	components := strings.Split(p, "/")
+	if len(components) <= strip {
+		return "", fmt.Errorf("can't strip %d leading components from path %s", strip, p)
+	}
+	return path.Join(components[strip:]...), nil
+}
+
+// unquotePath unquotes a path quoted by git - because it contains special characters.
+func unquotePath(p string) string {
+	if unquoted, err := strconv.Unquote(p); err == nil {
+		return unquoted
+	}
+	return p
+}
+
+func parseMode(mode string) os.FileMode {
+	m, err := strconv.ParseUint(strings.TrimSpace(mode), 8, 32)
+	if err != nil {
+		return 0
+	}
+	return os.FileMode(m).Perm()
+}
+
+// reverse returns the reverse diff - which can be applied to revert the changes of the diff.
+func (d *fileDiff) reverse() *fileDiff {
+	r := &fileDiff{
+		oldPath: d.newPath,
+		newPath: d.oldPath,
+		oldMode: d.newMode,
+		newMode: d.oldMode,
+	}
+	for _, h := range d.hunks {
+		rh := &hunk{
+			oldStart: h.newStart,
+			oldLines: h.newLines,
+			newStart: h.oldStart,
+			newLines: h.oldLines,
+		}
+		for _, line := range h.lines {
+			switch line.op {
+			case '-':
+				line.op = '+'
+			case '+':
+				line.op = '-'
+			}
+			rh.lines = append(rh.lines, line)
+		}
+		r.hunks = append(r.hunks, rh)
+	}
+	return r
+}
+
+// applyHunks applies the hunks to the lines of a file, and returns the new lines.
+// Each hunk is searched near its expected position - taking into account the offset of the previous hunks.
+// If it can't be found, up to fuzz leading and trailing context lines are ignored - like with the `--fuzz` flag of the `patch` command.
+// All the hunks are applied - or returned as errors - so that all the failures are reported at once.
+func applyHunks(filePath string, lines []string, hunks []*hunk, fuzz int) ([]string, error) {
+	var (
+		result []string
+		errs   []error
+		pos    int // the index of the first line which has not been copied to the result yet
+		offset int // the difference between the actual and expected positions of the previous hunk
+	)
+	for i, h := range hunks {
+		at, oldLines, newLines, ok := h.locate(lines, pos, offset, fuzz)
+		if !ok {
+			errs = append(errs, h.failure(filePath, i+1, lines, pos, offset))
+			continue
+		}
+		result = append(result, lines[pos:at]...)
+		result = append(result, newLines...)
+		pos = at + len(oldLines)
+		offset = at - h.expectedIndex()
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return append(result, lines[pos:]...), nil
+}
+
+// expectedIndex returns the index of the first line of the hunk in the original file.
+func (h *hunk) expectedIndex() int {
+	if h.oldLines == 0 {
+		// for a pure addition, the start is the line after which the new lines are inserted
+		return h.oldStart
+	}
+	return h.oldStart - 1
+}
+
+// locate returns the index at which the hunk applies - at or after the given position - with the old and new lines to use.
+func (h *hunk) locate(lines []string, pos, offset, fuzz int) (at int, oldLines, newLines []string, ok bool) {
+	leading, trailing := h.contextLines()
+	for f := 0; f <= fuzz; f++ {
+		front, back := min(f, leading), min(f, trailing)
+		if f > 0 && f > max(leading, trailing) {
+			// ignoring more lines than the context doesn't make any difference
+			break
+		}
+		oldLines, newLines = h.contentLines(front, back)
+		expected := h.expectedIndex() + offset + front
+
+		if len(oldLines) == 0 {
+			return min(max(expected, pos), len(lines)), oldLines, newLines, true
+		}
+		// search the nearest position to the expected one - first after, then before
+		for delta := 0; expected-delta >= pos || expected+delta <= len(lines)-len(oldLines); delta++ {
+			for _, candidate := range []int{expected + delta, expected - delta} {
+				if candidate >= pos && candidate <= len(lines)-len(oldLines) && matchLines(lines[candidate:], oldLines) {
+					return candidate, oldLines, newLines, true
+				}
+			}
+		}
+	}
+	return 0, nil, nil, false
+}
+
+// failure returns an error describing why the hunk doesn't apply at its expected position.
+func (h *hunk) failure(filePath string, number int, lines []string, pos, offset int) error {
+	oldLines, _ := h.contentLines(0, 0)
+	expected := max(h.expectedIndex()+offset, pos)
+	for i, oldLine := range oldLines {
+		index := expected + i
+		if index >= len(lines) {
+			return fmt.Errorf("hunk #%d of %s failed at line %d: expected %q, found the end of the file", number, filePath, index+1, strings.TrimRight(oldLine, "\r\n"))
+		}
+		if lines[index] != oldLine {
+			return fmt.Errorf("hunk #%d of %s failed at line %d: expected %q, found %q", number, filePath, index+1, strings.TrimRight(oldLine, "\r\n"), strings.TrimRight(lines[index], "\r\n"))
+		}
+	}
+	return fmt.Errorf("hunk #%d of %s failed at line %d", number, filePath, expected+1)
+}
+
+// contextLines returns the number of leading and trailing context lines of the hunk.
+func (h *hunk) contextLines() (leading, trailing int) {
+	for leading < len(h.lines) && h.lines[leading].op == ' ' {
+		leading++
+	}
+	for trailing < len(h.lines)-leading && h.lines[len(h.lines)-1-trailing].op == ' ' {
+		trailing++
+	}
+	return leading, trailing
+}
+
+// contentLines returns the old and new lines of the hunk - without the given number of leading and trailing context lines.
+func (h *hunk) contentLines(front, back int) (oldLines, newLines []string) {
+	for _, line := range h.lines[front : len(h.lines)-back] {
+		if line.op != '+' {
+			oldLines = append(oldLines, line.text)
+		}
+		if line.op != '-' {
+			newLines = append(newLines, line.text)
+		}
+	}
+	return oldLines, newLines
+}
+
+func matchLines(lines, expected []string) bool {
+	for i := range expected {
+		if lines[i] != expected[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// splitLines splits the content of a file in lines - including their line terminator.
+func splitLines(content string) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,339 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDiff(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		diff             string
+		strip            int
+		expected         []*fileDiff
+		expectedErrorMsg string
+	}{
+		{
+			name: "git diff",
+			diff: `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-import "log"
++import "log/slog"
+
+diff --git a/old.txt b/new.txt
+similarity index 100%
+rename from old.txt
+rename to new.txt
+diff --git a/run.sh b/run.sh
+old mode 100644
+new mode 100755
+diff --git a/NOTICE b/NOTICE
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/NOTICE
+@@ -0,0 +1 @@
++Copyright
+\ No newline at end of file
+diff --git a/legacy.txt b/legacy.txt
+deleted file mode 100644
+index 4444444..0000000
+--- a/legacy.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-legacy
+`,
+			strip: 1,
+			expected: []*fileDiff{
+				{
+					oldPath: "main.go",
+					newPath: "main.go",
+					hunks: []*hunk{
+						{oldStart: 1, oldLines: 3, newStart: 1, newLines: 3, lines: []hunkLine{
+							{op: ' ', text: "package main\n"},
+							{op: '-', text: "import \"log\"\n"},
+							{op: '+', text: "import \"log/slog\"\n"},
+							{op: ' ', text: "\n"},
+						}},
+					},
+				},
+				{
+					oldPath: "old.txt",
+					newPath: "new.txt",
+				},
+				{
+					oldPath: "run.sh",
+					newPath: "run.sh",
+					oldMode: 0644,
+					newMode: 0755,
+				},
+				{
+					newPath: "NOTICE",
+					newMode: 0644,
+					hunks: []*hunk{
+						{oldStart: 0, oldLines: 0, newStart: 1, newLines: 1, lines: []hunkLine{
+							{op: '+', text: "Copyright"},
+						}},
+					},
+				},
+				{
+					oldPath: "legacy.txt",
+					oldMode: 0644,
+					hunks: []*hunk{
+						{oldStart: 1, oldLines: 1, newStart: 0, newLines: 0, lines: []hunkLine{
+							{op: '-', text: "legacy\n"},
+						}},
+					},
+				},
+			},
+		},
+		{
+			name: "plain unified diff",
+			diff: `Only in new: other.txt
+--- old/docs/README.md	2024-01-01 10:00:00.000000000 +0000
++++ new/docs/README.md	2024-01-02 10:00:00.000000000 +0000
+@@ -2 +2,2 @@
+-old
++new
++line
+--- old/docs/CHANGELOG.md	2024-01-01 10:00:00.000000000 +0000
++++ new/docs/CHANGELOG.md	2024-01-02 10:00:00.000000000 +0000
+@@ -1 +1 @@
+-v1
++v2
+`,
+			strip: 2,
+			expected: []*fileDiff{
+				{
+					oldPath: "README.md",
+					newPath: "README.md",
+					hunks: []*hunk{
+						{oldStart: 2, oldLines: 1, newStart: 2, newLines: 2, lines: []hunkLine{
+							{op: '-', text: "old\n"},
+							{op: '+', text: "new\n"},
+							{op: '+', text: "line\n"},
+						}},
+					},
+				},
+				{
+					oldPath: "CHANGELOG.md",
+					newPath: "CHANGELOG.md",
+					hunks: []*hunk{
+						{oldStart: 1, oldLines: 1, newStart: 1, newLines: 1, lines: []hunkLine{
+							{op: '-', text: "v1\n"},
+							{op: '+', text: "v2\n"},
+						}},
+					},
+				},
+			},
+		},
+		{
+			name: "mbox",
+			diff: `From 1111111111111111111111111111111111111111 Mon Sep 17 00:00:00 2001
+From: Octopilot <octopilot@example.com>
+Subject: [PATCH 1/2] Update the version
+
+--- a/b/c was not a diff
+---
+ VERSION | 2 +-
+ 1 file changed, 1 insertion(+), 1 deletion(-)
+
+diff --git a/VERSION b/VERSION
+--- a/VERSION
++++ b/VERSION
+@@ -1 +1 @@
+-1.0.0
++1.1.0
+--
+2.43.0
+
+From 2222222222222222222222222222222222222222 Mon Sep 17 00:00:00 2001
+Subject: [PATCH 2/2] Update the version again
+
+---
+diff --git a/VERSION b/VERSION
+--- a/VERSION
++++ b/VERSION
+@@ -1 +1 @@
+-1.1.0
++1.2.0
+--
+2.43.0
+`,
+			strip: 1,
+			expected: []*fileDiff{
+				{
+					oldPath: "VERSION",
+					newPath: "VERSION",
+					hunks: []*hunk{
+						{oldStart: 1, oldLines: 1, newStart: 1, newLines: 1, lines: []hunkLine{
+							{op: '-', text: "1.0.0\n"},
+							{op: '+', text: "1.1.0\n"},
+						}},
+					},
+				},
+				{
+					oldPath: "VERSION",
+					newPath: "VERSION",
+					hunks: []*hunk{
+						{oldStart: 1, oldLines: 1, newStart: 1, newLines: 1, lines: []hunkLine{
+							{op: '-', text: "1.1.0\n"},
+							{op: '+', text: "1.2.0\n"},
+						}},
+					},
+				},
+			},
+		},
+		{
+			name: "binary diff",
+			diff: `diff --git a/logo.png b/logo.png
+index 1111111..2222222 100644
+Binary files a/logo.png and b/logo.png differ
+`,
+			strip:            1,
+			expectedErrorMsg: "line 3: binary patches are not supported",
+		},
+		{
+			name: "truncated hunk",
+			diff: `--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-import "log"
+`,
+			strip:            1,
+			expectedErrorMsg: "line 5: unexpected end of hunk - expected 3 old and 3 new lines, got 2 and 1",
+		},
+		{
+			name: "too many components to strip",
+			diff: `--- main.go
++++ main.go
+@@ -1 +1 @@
+-a
++b
+`,
+			strip:            1,
+			expectedErrorMsg: "line 1: can't strip 1 leading components from path main.go",
+		},
+		{
+			name:             "no diff",
+			diff:             "just some text\n",
+			expectedErrorMsg: "no file diff found",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := parseDiff(test.diff, test.strip)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestApplyHunks(t *testing.T) {
+	t.Parallel()
+	// a hunk which replaces c by C, with 3 lines of context
+	replaceC := &hunk{oldStart: 1, oldLines: 5, newStart: 1, newLines: 5, lines: []hunkLine{
+		{op: ' ', text: "a\n"},
+		{op: ' ', text: "b\n"},
+		{op: '-', text: "c\n"},
+		{op: '+', text: "C\n"},
+		{op: ' ', text: "d\n"},
+		{op: ' ', text: "e\n"},
+	}}
+	tests := []struct {
+		name             string
+		lines            []string
+		hunks            []*hunk
+		fuzz             int
+		expected         []string
+		expectedErrorMsg string
+	}{
+		{
+			name:     "exact position",
+			lines:    []string{"a\n", "b\n", "c\n", "d\n", "e\n"},
+			hunks:    []*hunk{replaceC},
+			expected: []string{"a\n", "b\n", "C\n", "d\n", "e\n"},
+		},
+		{
+			name:     "with offset",
+			lines:    []string{"x\n", "y\n", "a\n", "b\n", "c\n", "d\n", "e\n"},
+			hunks:    []*hunk{replaceC},
+			expected: []string{"x\n", "y\n", "a\n", "b\n", "C\n", "d\n", "e\n"},
+		},
+		{
+			name:     "with fuzz",
+			lines:    []string{"A\n", "b\n", "c\n", "d\n", "E\n"},
+			hunks:    []*hunk{replaceC},
+			fuzz:     1,
+			expected: []string{"A\n", "b\n", "C\n", "d\n", "E\n"},
+		},
+		{
+			name:             "without enough fuzz",
+			lines:            []string{"A\n", "b\n", "c\n", "d\n", "E\n"},
+			hunks:            []*hunk{replaceC},
+			expectedErrorMsg: `hunk #1 of file.txt failed at line 1: expected "a", found "A"`,
+		},
+		{
+			name:  "multiple failures",
+			lines: []string{"a\n", "b\n", "c\n"},
+			hunks: []*hunk{
+				{oldStart: 1, oldLines: 1, newStart: 1, newLines: 1, lines: []hunkLine{
+					{op: '-', text: "x\n"},
+					{op: '+', text: "y\n"},
+				}},
+				{oldStart: 3, oldLines: 1, newStart: 3, newLines: 1, lines: []hunkLine{
+					{op: '-', text: "c\n"},
+					{op: '+', text: "C\n"},
+				}},
+				{oldStart: 4, oldLines: 1, newStart: 4, newLines: 1, lines: []hunkLine{
+					{op: '-', text: "d\n"},
+					{op: '+', text: "D\n"},
+				}},
+			},
+			expectedErrorMsg: "hunk #1 of file.txt failed at line 1: expected \"x\", found \"a\"\nhunk #3 of file.txt failed at line 4: expected \"d\", found the end of the file",
+		},
+		{
+			name:  "no newline at end of file",
+			lines: []string{"a\n", "b"},
+			hunks: []*hunk{
+				{oldStart: 2, oldLines: 1, newStart: 2, newLines: 2, lines: []hunkLine{
+					{op: '-', text: "b"},
+					{op: '+', text: "b\n"},
+					{op: '+', text: "c\n"},
+				}},
+			},
+			expected: []string{"a\n", "b\n", "c\n"},
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := applyHunks("file.txt", test.lines, test.hunks, test.fuzz)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+// Package patch provides an updater that applies a unified diff - or a mbox generated by `git format-patch` - to a repository.
+package patch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dailymotion-oss/octopilot/update/change"
+)
+
+// PatchUpdater is an updater that applies a patch file to the repository.
+// The patch is applied in-process - it doesn't require the `patch` or `git` commands.
+type PatchUpdater struct {
+	FilePath string
+	// Fuzz is the maximum number of leading and trailing context lines which can be ignored to apply a hunk
+	Fuzz int
+	// Strip is the number of leading components stripped from the file paths of the patch
+	Strip int
+	// SkipApplied skips the repositories on which the patch has already been applied - instead of failing
+	SkipApplied bool
+
+	change.DetailsRecorder
+}
+
+// fileState is the state of a file while the patch is applied.
+type fileState struct {
+	exists bool
+	lines  []string
+	mode   os.FileMode
+	// the state of the file before the patch - to find out what changed
+	existed    bool
+	oldContent string
+	oldMode    os.FileMode
+	// the new path of a renamed file, which is described with its old path
+	renamedTo string
+	renamed   bool
+}
+
+// NewUpdater builds a new patch updater from the given parameters
+func NewUpdater(params map[string]string) (*PatchUpdater, error) {
+	updater := &PatchUpdater{}
+
+	updater.FilePath = params["file"]
+	if len(updater.FilePath) == 0 {
+		return nil, errors.New("missing file parameter")
+	}
+
+	var err error
+	if fuzz := params["fuzz"]; len(fuzz) > 0 {
+		updater.Fuzz, err = strconv.Atoi(fuzz)
+		if err != nil || updater.Fuzz < 0 {
+			return nil, fmt.Errorf("invalid fuzz parameter %s: expected a positive integer", fuzz)
+		}
+	}
+
+	updater.Strip = 1
+	if strip := params["strip"]; len(strip) > 0 {
+		updater.Strip, err = strconv.Atoi(strip)
+		if err != nil || updater.Strip < 0 {
+			return nil, fmt.Errorf("invalid strip parameter %s: expected a positive integer", strip)
+		}
+	}
+
+	updater.SkipApplied, _ = strconv.ParseBool(params["skipapplied"])
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *PatchUpdater) Update(_ context.Context, repoPath string) (bool, error) {
+	u.SetDetails(repoPath, "")
+
+	patchPath := u.FilePath
+	if !filepath.IsAbs(patchPath) {
+		patchPath = filepath.Join(repoPath, patchPath)
+	}
+	content, err := os.ReadFile(patchPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read patch %s: %w", u.FilePath, err)
+	}
+	diffs, err := parseDiff(string(content), u.Strip)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse patch %s: %w", u.FilePath, err)
+	}
+
+	files, err := applyDiffs(repoPath, diffs, u.Fuzz)
+	if err != nil {
+		if u.SkipApplied && isApplied(repoPath, diffs) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to apply patch %s: %w", u.FilePath, err)
+	}
+
+	// all the changes are written at the end, so that a patch which doesn't apply doesn't leave the repository half-patched
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var lines []string
+	for _, p := range paths {
+		line, err := files[p].write(repoPath, p)
+		if err != nil {
+			return false, err
+		}
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return false, nil
+	}
+
+	u.SetDetails(repoPath, strings.Join(lines, "\n"))
+	return true, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *PatchUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Apply patch %s", filepath.Base(u.FilePath))
+	body = fmt.Sprintf("Applying patch `%s`", filepath.Base(u.FilePath))
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *PatchUpdater) String() string {
+	return fmt.Sprintf("Patch[file=%s,fuzz=%d,strip=%d,skipapplied=%v]", u.FilePath, u.Fuzz, u.Strip, u.SkipApplied)
+}
+
+// applyDiffs applies the diffs - in order - to the files of the repository, and returns the new state of the files.
+// Nothing is written to the repository.
+func applyDiffs(repoPath string, diffs []*fileDiff, fuzz int) (map[string]*fileState, error) {
+	files := make(map[string]*fileState)
+	load := func(p string) (*fileState, error) {
+		if state, ok := files[p]; ok {
+			return state, nil
+		}
+		if !filepath.IsLocal(p) {
+			return nil, fmt.Errorf("invalid path %s: it must be a relative path inside the repository", p)
+		}
+		state := &fileState{}
+		fileInfo, err := os.Lstat(filepath.Join(repoPath, filepath.FromSlash(p)))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("failed to access file %s: %w", p, err)
+		case !fileInfo.Mode().IsRegular():
+			return nil, fmt.Errorf("failed to patch file %s: it is not a regular file", p)
+		default:
+			content, err := os.ReadFile(filepath.Join(repoPath, filepath.FromSlash(p)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read file %s: %w", p, err)
+			}
+			state.exists, state.existed = true, true
+			state.oldContent = string(content)
+			state.lines = splitLines(state.oldContent)
+			state.mode, state.oldMode = fileInfo.Mode().Perm(), fileInfo.Mode().Perm()
+		}
+		files[p] = state
+		return state, nil
+	}
+
+	var errs []error
+	for _, diff := range diffs {
+		if err := applyDiff(diff, load, fuzz); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return files, nil
+}
+
+// applyDiff applies the diff of a single file, using the given function to load the current state of the files.
+func applyDiff(diff *fileDiff, load func(string) (*fileState, error), fuzz int) error {
+	var (
+		source, target *fileState
+		err            error
+	)
+	if len(diff.oldPath) > 0 {
+		if source, err = load(diff.oldPath); err != nil {
+			return err
+		}
+		if !source.exists {
+			return fmt.Errorf("can't patch file %s: it doesn't exist", diff.oldPath)
+		}
+	}
+	if len(diff.newPath) > 0 {
+		if target, err = load(diff.newPath); err != nil {
+			return err
+		}
+		if target != source && target.exists {
+			return fmt.Errorf("can't create file %s: it already exists", diff.newPath)
+		}
+	}
+
+	var lines []string
+	filePath := diff.newPath
+	if source != nil {
+		lines = source.lines
+		filePath = diff.oldPath
+	}
+	lines, err = applyHunks(filePath, lines, diff.hunks, fuzz)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case target == nil:
+		if len(lines) > 0 {
+			return fmt.Errorf("can't delete file %s: it still has %d lines after applying the patch", diff.oldPath, len(lines))
+		}
+		source.exists, source.lines = false, nil
+	case source == nil:
+		target.exists, target.lines, target.mode = true, lines, 0644
+	default:
+		mode := source.mode
+		if source != target {
+			source.exists, source.lines, source.renamedTo = false, nil, diff.newPath
+			target.renamed = true
+		}
+		target.exists, target.lines, target.mode = true, lines, mode
+	}
+	if target != nil && diff.newMode != 0 {
+		target.mode = diff.newMode
+	}
+	return nil
+}
+
+// isApplied returns true if the patch has already been applied to the repository - which means that it can be reverted.
+func isApplied(repoPath string, diffs []*fileDiff) bool {
+	reversed := make([]*fileDiff, 0, len(diffs))
+	for i := len(diffs) - 1; i >= 0; i-- {
+		reversed = append(reversed, diffs[i].reverse())
+	}
+	_, err := applyDiffs(repoPath, reversed, 0)
+	return err == nil
+}
+
+// write writes the new state of the file to the repository, and returns a description of the change - or an empty string if it didn't change.
+func (f *fileState) write(repoPath, p string) (string, error) {
+	fullPath := filepath.Join(repoPath, filepath.FromSlash(p))
+	if !f.exists {
+		if !f.existed {
+			return "", nil
+		}
+		if err := os.Remove(fullPath); err != nil {
+			return "", fmt.Errorf("failed to delete file %s: %w", p, err)
+		}
+		removeEmptyParents(repoPath, p)
+		if len(f.renamedTo) > 0 {
+			return fmt.Sprintf("- `%s`: moved to `%s`", p, f.renamedTo), nil
+		}
+		return fmt.Sprintf("- `%s`: deleted", p), nil
+	}
+
+	content := strings.Join(f.lines, "")
+	if f.existed && content == f.oldContent && f.mode == f.oldMode {
+		return "", nil
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directories for file %s: %w", p, err)
+	}
+	if err := os.WriteFile(fullPath, []byte(content), f.mode); err != nil {
+		return "", fmt.Errorf("failed to write file %s: %w", p, err)
+	}
+	if err := os.Chmod(fullPath, f.mode); err != nil {
+		return "", fmt.Errorf("failed to change the permissions of file %s: %w", p, err)
+	}
+
+	switch {
+	case f.renamed:
+		return "", nil
+	case f.existed:
+		return fmt.Sprintf("- `%s`: updated", p), nil
+	default:
+		return fmt.Sprintf("- `%s`: created", p), nil
+	}
+}
+
+// removeEmptyParents removes the parent directories of the given file, if they are empty - up to the root of the repository.
+func removeEmptyParents(repoPath, file string) {
+	for dir := path.Dir(file); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if err := os.Remove(filepath.Join(repoPath, filepath.FromSlash(dir))); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package patch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *PatchUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params with defaults",
+			params: map[string]string{
+				"file": "/tmp/changes.patch",
+			},
+			expected: &PatchUpdater{
+				FilePath: "/tmp/changes.patch",
+				Strip:    1,
+			},
+		},
+		{
+			name: "valid params",
+			params: map[string]string{
+				"file":        "/tmp/changes.patch",
+				"fuzz":        "2",
+				"strip":       "0",
+				"skipapplied": "true",
+			},
+			expected: &PatchUpdater{
+				FilePath:    "/tmp/changes.patch",
+				Fuzz:        2,
+				Strip:       0,
+				SkipApplied: true,
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing file parameter",
+		},
+		{
+			name: "invalid fuzz",
+			params: map[string]string{
+				"file": "/tmp/changes.patch",
+				"fuzz": "-1",
+			},
+			expectedErrorMsg: "invalid fuzz parameter -1: expected a positive integer",
+		},
+		{
+			name: "invalid strip",
+			params: map[string]string{
+				"file":  "/tmp/changes.patch",
+				"strip": "one",
+			},
+			expectedErrorMsg: "invalid strip parameter one: expected a positive integer",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		repoPath         string
+		files            map[string]string
+		patch            string
+		updater          *PatchUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+		expectedMissing  []string
+		expectedModes    map[string]os.FileMode
+		expectedDetails  string
+	}{
+		{
+			name:     "apply git diff",
+			repoPath: "git-diff",
+			files: map[string]string{
+				"main.go":           "// Package main\npackage main\n\nimport \"log\"\n\nfunc main() {\n\tlog.Print(\"hello\")\n}\n",
+				"old.txt":           "old\n",
+				"run.sh":            "#!/bin/sh\n",
+				"legacy/legacy.txt": "legacy\n",
+			},
+			patch: `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,7 +1,7 @@
+ package main
+
+-import "log"
++import "log/slog"
+
+ func main() {
+-	log.Print("hello")
++	slog.Info("hello")
+ }
+diff --git a/old.txt b/new.txt
+similarity index 100%
+rename from old.txt
+rename to new.txt
+diff --git a/run.sh b/run.sh
+old mode 100644
+new mode 100755
+diff --git a/NOTICE b/NOTICE
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/NOTICE
+@@ -0,0 +1 @@
++Copyright
+\ No newline at end of file
+diff --git a/legacy/legacy.txt b/legacy/legacy.txt
+deleted file mode 100644
+index 4444444..0000000
+--- a/legacy/legacy.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-legacy
+`,
+			updater: &PatchUpdater{
+				FilePath: "../git-diff.patch",
+				Strip:    1,
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"main.go": "// Package main\npackage main\n\nimport \"log/slog\"\n\nfunc main() {\n\tslog.Info(\"hello\")\n}\n",
+				"new.txt": "old\n",
+				"NOTICE":  "Copyright",
+			},
+			expectedMissing: []string{"old.txt", "legacy"},
+			expectedModes: map[string]os.FileMode{
+				"run.sh": 0755,
+			},
+			expectedDetails: "- `NOTICE`: created\n- `legacy/legacy.txt`: deleted\n- `main.go`: updated\n- `old.txt`: moved to `new.txt`\n- `run.sh`: updated",
+		},
+		{
+			name:     "apply mbox",
+			repoPath: "mbox",
+			files: map[string]string{
+				"VERSION": "1.0.0\n",
+			},
+			patch: `From 1111111111111111111111111111111111111111 Mon Sep 17 00:00:00 2001
+From: Octopilot <octopilot@example.com>
+Subject: [PATCH 1/2] Release 1.1.0
+
+---
+ VERSION | 2 +-
+ 1 file changed, 1 insertion(+), 1 deletion(-)
+
+diff --git a/VERSION b/VERSION
+--- a/VERSION
++++ b/VERSION
+@@ -1 +1 @@
+-1.0.0
++1.1.0
+--
+2.43.0
+
+From 2222222222222222222222222222222222222222 Mon Sep 17 00:00:00 2001
+From: Octopilot <octopilot@example.com>
+Subject: [PATCH 2/2] Release 1.2.0
+
+---
+diff --git a/VERSION b/VERSION
+--- a/VERSION
++++ b/VERSION
+@@ -1 +1 @@
+-1.1.0
++1.2.0
+--
+2.43.0
+`,
+			updater: &PatchUpdater{
+				FilePath: "../mbox.patch",
+				Strip:    1,
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"VERSION": "1.2.0\n",
+			},
+			expectedDetails: "- `VERSION`: updated",
+		},
+		{
+			name:     "apply with fuzz",
+			repoPath: "fuzz",
+			files: map[string]string{
+				"config/app.ini": "[app]\nname = my-app\nport = 8080\ndebug = false\n",
+			},
+			patch: `--- config/app.ini
++++ config/app.ini
+@@ -1,4 +1,4 @@
+ [application]
+ name = my-app
+-port = 8080
++port = 9090
+ debug = false
+`,
+			updater: &PatchUpdater{
+				FilePath: "../fuzz.patch",
+				Fuzz:     1,
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"config/app.ini": "[app]\nname = my-app\nport = 9090\ndebug = false\n",
+			},
+			expectedDetails: "- `config/app.ini`: updated",
+		},
+		{
+			name:     "failing hunk",
+			repoPath: "failing-hunk",
+			files: map[string]string{
+				"VERSION": "1.0.0\n",
+				"go.mod":  "module example.com/app\n\ngo 1.20\n",
+			},
+			patch: `--- a/VERSION
++++ b/VERSION
+@@ -1 +1 @@
+-1.0.0
++1.1.0
+--- a/go.mod
++++ b/go.mod
+@@ -1,3 +1,3 @@
+ module example.com/app
+
+-go 1.21
++go 1.22
+`,
+			updater: &PatchUpdater{
+				FilePath: "../failing-hunk.patch",
+				Strip:    1,
+			},
+			expectedErrorMsg: `failed to apply patch ../failing-hunk.patch: hunk #1 of go.mod failed at line 3: expected "go 1.21", found "go 1.20"`,
+			expectedFiles: map[string]string{
+				"VERSION": "1.0.0\n",
+			},
+		},
+		{
+			name:     "already applied patch",
+			repoPath: "already-applied",
+			files: map[string]string{
+				"VERSION": "1.1.0\n",
+			},
+			patch: `--- a/VERSION
++++ b/VERSION
+@@ -1 +1 @@
+-1.0.0
++1.1.0
+`,
+			updater: &PatchUpdater{
+				FilePath: "../already-applied.patch",
+				Strip:    1,
+			},
+			expectedErrorMsg: `failed to apply patch ../already-applied.patch: hunk #1 of VERSION failed at line 1: expected "1.0.0", found "1.1.0"`,
+		},
+		{
+			name:     "skip already applied patch",
+			repoPath: "skip-already-applied",
+			files: map[string]string{
+				"VERSION": "1.1.0\n",
+				"NOTICE":  "Copyright\n",
+			},
+			patch: `diff --git a/VERSION b/VERSION
+--- a/VERSION
++++ b/VERSION
+@@ -1 +1 @@
+-1.0.0
++1.1.0
+diff --git a/NOTICE b/NOTICE
+new file mode 100644
+--- /dev/null
++++ b/NOTICE
+@@ -0,0 +1 @@
++Copyright
+`,
+			updater: &PatchUpdater{
+				FilePath:    "../skip-already-applied.patch",
+				Strip:       1,
+				SkipApplied: true,
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"VERSION": "1.1.0\n",
+				"NOTICE":  "Copyright\n",
+			},
+		},
+		{
+			name:     "file outside of the repository",
+			repoPath: "outside",
+			patch: `--- a/../secret
++++ b/../secret
+@@ -0,0 +1 @@
++secret
+`,
+			updater: &PatchUpdater{
+				FilePath: "../outside.patch",
+				Strip:    1,
+			},
+			expectedErrorMsg: "failed to apply patch ../outside.patch: invalid path ../secret: it must be a relative path inside the repository",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			repoPath := filepath.Join("testdata", test.repoPath)
+			{
+				err := os.RemoveAll(repoPath)
+				require.NoErrorf(t, err, "can't remove testdata directory %s", repoPath)
+				err = os.MkdirAll(repoPath, 0755)
+				require.NoErrorf(t, err, "can't create testdata directory %s", repoPath)
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join(repoPath, filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join(repoPath, filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+				err = os.WriteFile(filepath.Join("testdata", test.repoPath+".patch"), []byte(test.patch), 0644)
+				require.NoErrorf(t, err, "can't write testdata patch %s", test.repoPath)
+			}
+
+			actual, err := test.updater.Update(context.Background(), repoPath)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+			for filename, expectedContent := range test.expectedFiles {
+				actualContent, err := os.ReadFile(filepath.Join(repoPath, filename))
+				require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+				assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+			}
+			for _, filename := range test.expectedMissing {
+				assert.NoFileExistsf(t, filepath.Join(repoPath, filename), "testdata file %s should not exist", filename)
+				assert.NoDirExistsf(t, filepath.Join(repoPath, filename), "testdata directory %s should not exist", filename)
+			}
+			for filename, expectedMode := range test.expectedModes {
+				fileInfo, err := os.Stat(filepath.Join(repoPath, filename))
+				require.NoErrorf(t, err, "can't stat actual testdata file %s", filename)
+				assert.Equalf(t, expectedMode, fileInfo.Mode().Perm(), "testdata file %s mode doesn't match", filename)
+			}
+			assert.Equal(t, test.expectedDetails, test.updater.Details(repoPath))
+		})
+	}
+}
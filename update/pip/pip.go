@@ -0,0 +1,339 @@
+// Package pip provides an updater that updates the version of a dependency in Python requirements files, setup.cfg files and pyproject.toml files.
+package pip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	"github.com/dailymotion-oss/octopilot/internal/toml"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+)
+
+var (
+	// the default files: the requirements and constraints files, and the setuptools and pyproject configuration files
+	defaultFilePaths = []string{
+		"**/requirements*.txt",
+		"**/constraints*.txt",
+		"**/setup.cfg",
+		"**/pyproject.toml",
+	}
+
+	// the paths of the PEP 508 requirements in a pyproject.toml file - where "*" is any key and "[]" any array index
+	pyprojectRequirementsPaths = [][]string{
+		{"project", "dependencies", "[]"},
+		{"project", "optional-dependencies", "*", "[]"},
+		{"dependency-groups", "*", "[]"},
+		{"build-system", "requires", "[]"},
+	}
+
+	// the paths of the Poetry dependencies tables in a pyproject.toml file
+	poetryDependenciesPaths = [][]string{
+		{"tool", "poetry", "dependencies"},
+		{"tool", "poetry", "dev-dependencies"},
+		{"tool", "poetry", "group", "*", "dependencies"},
+	}
+
+	// the keys of the [options] section of a setup.cfg file which contain requirements
+	setupCfgOptionsKeys = []string{"install_requires", "setup_requires", "tests_require"}
+
+	// [section]
+	setupCfgSectionRegexp = regexp.MustCompile(`^\[([^\]]*)\]`)
+	// key = value
+	setupCfgKeyRegexp = regexp.MustCompile(`^([^\s=:#;][^=:]*?)\s*[=:]\s*`)
+)
+
+// PipUpdater is an updater that updates the version of a Python dependency in requirements files, setup.cfg files and pyproject.toml files.
+type PipUpdater struct {
+	FilePaths []string
+	Package   string
+	Valuer    value.Valuer
+
+	change.Recorder
+}
+
+// NewUpdater builds a new pip updater from the given parameters and valuer
+func NewUpdater(params map[string]string, valuer value.Valuer) (*PipUpdater, error) {
+	updater := &PipUpdater{}
+
+	updater.FilePaths = defaultFilePaths
+	if filePath := params["file"]; len(filePath) > 0 {
+		updater.FilePaths = []string{filePath}
+	}
+
+	updater.Package = params["package"]
+	if len(updater.Package) == 0 {
+		return nil, errors.New("missing package parameter")
+	}
+	if name := nameRegexp.FindString(updater.Package); name != updater.Package {
+		return nil, fmt.Errorf("invalid package parameter %s: it must be a package name - without extras or version", updater.Package)
+	}
+
+	updater.Valuer = valuer
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *PipUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	u.Reset(repoPath)
+
+	version, err := u.Valuer.Value(ctx, repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get value: %w", err)
+	}
+	version = strings.TrimSpace(version)
+	if !versionRegexp.MatchString(version) {
+		return false, fmt.Errorf("invalid value %q: expected a version, such as 1.2.3 or ~=1.2.3", version)
+	}
+
+	var filePaths []string
+	for _, pattern := range u.FilePaths {
+		paths, err := glob.ExpandGlobPattern(repoPath, pattern)
+		if err != nil {
+			return false, fmt.Errorf("failed to expand glob pattern %s: %w", pattern, err)
+		}
+		filePaths = append(filePaths, paths...)
+	}
+
+	var updated bool
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+		}
+
+		var (
+			newContent []byte
+			changes    []change.Change
+		)
+		switch {
+		case strings.HasSuffix(filePath, ".toml"):
+			newContent, changes, err = u.updatePyproject(content, version)
+		case strings.HasSuffix(filePath, ".cfg"):
+			newContent, changes = u.updateSetupCfg(content, version)
+		default:
+			newContent, changes = u.updateRequirements(content, version)
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to update file %s: %w", relFilePath, err)
+		}
+		if string(newContent) == string(content) {
+			continue
+		}
+		for i := range changes {
+			changes[i].File = filepath.ToSlash(relFilePath)
+		}
+
+		if err = os.WriteFile(filePath, newContent, fileInfo.Mode()); err != nil {
+			return false, fmt.Errorf("failed to write updated content to file %s: %w", relFilePath, err)
+		}
+
+		u.Record(repoPath, changes...)
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *PipUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Update Python package %s", u.Package)
+	body = fmt.Sprintf("Updating Python package `%s` in file(s) `%s`", u.Package, strings.Join(u.FilePaths, "`, `"))
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *PipUpdater) String() string {
+	return fmt.Sprintf("Pip[package=%s,file=%s]", u.Package, strings.Join(u.FilePaths, ";"))
+}
+
+// updateRequirements updates a requirements or constraints file, line by line.
+// The lines continuing a previous line - such as the --hash options - and the pip options - such as -r or -e - are ignored.
+func (u *PipUpdater) updateRequirements(content []byte, version string) ([]byte, []change.Change) {
+	var (
+		lines        = strings.SplitAfter(string(content), "\n")
+		changes      []change.Change
+		continuation bool
+	)
+	for i, line := range lines {
+		text := strings.TrimRight(line, "\r\n")
+		previousContinuation := continuation
+		continuation = strings.HasSuffix(text, `\`)
+		if previousContinuation {
+			continue
+		}
+		if newLine, c, ok := u.updateRequirement(text, version); ok {
+			lines[i] = newLine + line[len(text):]
+			changes = append(changes, c)
+		}
+	}
+	return []byte(strings.Join(lines, "")), changes
+}
+
+// updateSetupCfg updates the requirements defined in the [options] and [options.extras_require] sections of a setup.cfg file,
+// line by line - so that the rest of the file is kept as-is.
+func (u *PipUpdater) updateSetupCfg(content []byte, version string) ([]byte, []change.Change) {
+	var (
+		lines    = strings.SplitAfter(string(content), "\n")
+		changes  []change.Change
+		section  string
+		relevant bool
+	)
+	for i, line := range lines {
+		text := strings.TrimRight(line, "\r\n")
+		if matches := setupCfgSectionRegexp.FindStringSubmatch(text); len(matches) > 0 {
+			section, relevant = strings.TrimSpace(matches[1]), false
+			continue
+		}
+
+		// a new key starts at the beginning of a line, and its value can continue on the next indented lines
+		valueStart := 0
+		if matches := setupCfgKeyRegexp.FindStringSubmatch(text); len(matches) > 0 {
+			key := strings.TrimSpace(matches[1])
+			relevant = section == "options.extras_require" || (section == "options" && slices.Contains(setupCfgOptionsKeys, key))
+			valueStart = len(matches[0])
+		} else if len(text) > 0 && !strings.ContainsRune(" \t#;", rune(text[0])) {
+			relevant = false
+		}
+		if !relevant {
+			continue
+		}
+
+		if newValue, c, ok := u.updateRequirement(text[valueStart:], version); ok {
+			lines[i] = text[:valueStart] + newValue + line[len(text):]
+			changes = append(changes, c)
+		}
+	}
+	return []byte(strings.Join(lines, "")), changes
+}
+
+// updatePyproject updates the PEP 621 and PEP 735 requirements and the Poetry dependencies defined in a pyproject.toml file.
+func (u *PipUpdater) updatePyproject(content []byte, version string) ([]byte, []change.Change, error) {
+	doc, err := toml.Parse(content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse TOML: %w", err)
+	}
+
+	var changes []change.Change
+	// the document is parsed again after each change, but its values are the same - only their positions change
+	for i := 0; i < len(doc.Values()); i++ {
+		v := doc.Values()[i]
+		if v.Kind != toml.BasicString && v.Kind != toml.LiteralString {
+			continue
+		}
+		oldValue, err := doc.String(v)
+		if err != nil {
+			continue
+		}
+
+		var (
+			newValue string
+			c        change.Change
+			ok       bool
+		)
+		switch {
+		case matchesAnyPath(v.Path, pyprojectRequirementsPaths):
+			newValue, c, ok = u.updateRequirement(oldValue, version)
+		case u.isPoetryDependency(v.Path):
+			var newConstraint string
+			if newConstraint, ok = updatePoetryConstraint(oldValue, version); ok && newConstraint != oldValue {
+				newValue, c = newConstraint, change.Change{Name: u.Package, Old: oldValue, New: newConstraint}
+			} else {
+				ok = false
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		newContent, changed, err := doc.Set(v.Path, newValue, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !changed {
+			continue
+		}
+		changes = append(changes, c)
+		doc, err = toml.Parse(newContent)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse TOML: %w", err)
+		}
+	}
+	return doc.Bytes(), changes, nil
+}
+
+// updateRequirement updates the version specifier of the given PEP 508 requirement, if it's a requirement for the package.
+func (u *PipUpdater) updateRequirement(str, version string) (string, change.Change, bool) {
+	req, ok := parseRequirement(str)
+	if !ok || normalizeName(req.name) != normalizeName(u.Package) {
+		return "", change.Change{}, false
+	}
+	oldSpec := str[req.specStart:req.specEnd]
+	newSpec, ok := updateSpecifier(oldSpec, version)
+	if !ok || newSpec == oldSpec {
+		return "", change.Change{}, false
+	}
+	return str[:req.specStart] + newSpec + str[req.specEnd:], change.Change{Name: u.Package, Old: oldSpec, New: newSpec}, true
+}
+
+// isPoetryDependency returns true if the given path is the version of the package in a Poetry dependencies table:
+// either `name = "^1.2"` or `name = { version = "^1.2", extras = [...] }`.
+func (u *PipUpdater) isPoetryDependency(path toml.Path) bool {
+	for _, tablePath := range poetryDependenciesPaths {
+		switch {
+		case len(path) == len(tablePath)+1:
+		case len(path) == len(tablePath)+2 && path[len(path)-1].Key == "version" && !path[len(path)-1].IsIndex:
+		default:
+			continue
+		}
+		if matchesPath(path[:len(tablePath)], tablePath) && !path[len(tablePath)].IsIndex &&
+			normalizeName(path[len(tablePath)].Key) == normalizeName(u.Package) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAnyPath(path toml.Path, patterns [][]string) bool {
+	for _, pattern := range patterns {
+		if len(path) == len(pattern) && matchesPath(path, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPath returns true if the given path matches the pattern, where "*" is any key and "[]" any array index.
+func matchesPath(path toml.Path, pattern []string) bool {
+	for i, segment := range pattern {
+		switch {
+		case segment == "[]":
+			if !path[i].IsIndex {
+				return false
+			}
+		case path[i].IsIndex:
+			return false
+		case segment != "*" && segment != path[i].Key:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,359 @@
+package pip
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *PipUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params with default files",
+			params: map[string]string{
+				"package": "my-lib",
+			},
+			expected: &PipUpdater{
+				FilePaths: defaultFilePaths,
+				Package:   "my-lib",
+			},
+		},
+		{
+			name: "valid params with custom file",
+			params: map[string]string{
+				"file":    "requirements/*.txt",
+				"package": "zope.interface",
+			},
+			expected: &PipUpdater{
+				FilePaths: []string{"requirements/*.txt"},
+				Package:   "zope.interface",
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing package parameter",
+		},
+		{
+			name: "invalid package param",
+			params: map[string]string{
+				"package": "requests[security]",
+			},
+			expectedErrorMsg: "invalid package parameter requests[security]: it must be a package name - without extras or version",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params, nil)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		files            map[string]string
+		updater          *PipUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+		expectedChanges  []change.Change
+	}{
+		{
+			name: "update requirements files",
+			files: map[string]string{
+				"requirements/requirements.txt": `# the dependencies
+-r base.txt
+--index-url https://pypi.example.com/simple
+My_Lib[extra1,extra2] == 1.2.3 ; python_version >= "3.8"  # pinned
+my-lib-extra==1.2.3
+other-lib>=1.0 \
+    --hash=sha256:0123456789abcdef
+my.lib~=1.2 --hash=sha256:0123456789abcdef
+my-lib @ https://example.com/my-lib-1.0.0.tar.gz
+my-lib>=1.0,<2.0
+`,
+				"requirements/constraints.txt": "my-lib (>=1.0)\r\n",
+			},
+			updater: &PipUpdater{
+				FilePaths: []string{"requirements/*.txt"},
+				Package:   "my-lib",
+				Valuer:    value.StringValuer("v1.3.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"requirements/requirements.txt": `# the dependencies
+-r base.txt
+--index-url https://pypi.example.com/simple
+My_Lib[extra1,extra2] == 1.3.0 ; python_version >= "3.8"  # pinned
+my-lib-extra==1.2.3
+other-lib>=1.0 \
+    --hash=sha256:0123456789abcdef
+my.lib~=1.3.0 --hash=sha256:0123456789abcdef
+my-lib @ https://example.com/my-lib-1.0.0.tar.gz
+my-lib>=1.0,<2.0
+`,
+				"requirements/constraints.txt": "my-lib (>=1.3.0)\r\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "requirements/constraints.txt", Name: "my-lib", Old: ">=1.0", New: ">=1.3.0"},
+				{File: "requirements/requirements.txt", Name: "my-lib", Old: "== 1.2.3", New: "== 1.3.0"},
+				{File: "requirements/requirements.txt", Name: "my-lib", Old: "~=1.2", New: "~=1.3.0"},
+			},
+		},
+		{
+			name: "update setup.cfg file",
+			files: map[string]string{
+				"setuptools/setup.cfg": `[metadata]
+name = my-app
+version = 1.0.0
+
+[options]
+install_requires =
+    requests>=2.0
+    # our shared library
+    my-lib==1.2.3
+python_requires = >=3.8
+
+[options.extras_require]
+dev = my-lib[dev]==1.2.3
+
+[tool:pytest]
+addopts = my-lib==1.2.3
+`,
+			},
+			updater: &PipUpdater{
+				FilePaths: []string{"setuptools/setup.cfg"},
+				Package:   "my-lib",
+				Valuer:    value.StringValuer("1.3.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"setuptools/setup.cfg": `[metadata]
+name = my-app
+version = 1.0.0
+
+[options]
+install_requires =
+    requests>=2.0
+    # our shared library
+    my-lib==1.3.0
+python_requires = >=3.8
+
+[options.extras_require]
+dev = my-lib[dev]==1.3.0
+
+[tool:pytest]
+addopts = my-lib==1.2.3
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "setuptools/setup.cfg", Name: "my-lib", Old: "==1.2.3", New: "==1.3.0"},
+				{File: "setuptools/setup.cfg", Name: "my-lib", Old: "==1.2.3", New: "==1.3.0"},
+			},
+		},
+		{
+			name: "update PEP 621 pyproject file",
+			files: map[string]string{
+				"pep621/pyproject.toml": `[build-system]
+requires = ["setuptools>=61", "my_lib==1.2.3"]
+
+[project]
+name = "my-app"
+dependencies = [
+    "requests>=2.0",
+    'my-lib[extra]>=1.2; python_version>"3.8"', # pinned
+]
+
+[project.optional-dependencies]
+dev = ["my-lib==1.2.3"]
+
+[dependency-groups]
+test = ["my-lib ~= 1.2"]
+`,
+			},
+			updater: &PipUpdater{
+				FilePaths: []string{"pep621/pyproject.toml"},
+				Package:   "my-lib",
+				Valuer:    value.StringValuer("1.3.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"pep621/pyproject.toml": `[build-system]
+requires = ["setuptools>=61", "my_lib==1.3.0"]
+
+[project]
+name = "my-app"
+dependencies = [
+    "requests>=2.0",
+    'my-lib[extra]>=1.3.0; python_version>"3.8"', # pinned
+]
+
+[project.optional-dependencies]
+dev = ["my-lib==1.3.0"]
+
+[dependency-groups]
+test = ["my-lib ~= 1.3.0"]
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "pep621/pyproject.toml", Name: "my-lib", Old: "==1.2.3", New: "==1.3.0"},
+				{File: "pep621/pyproject.toml", Name: "my-lib", Old: ">=1.2", New: ">=1.3.0"},
+				{File: "pep621/pyproject.toml", Name: "my-lib", Old: "==1.2.3", New: "==1.3.0"},
+				{File: "pep621/pyproject.toml", Name: "my-lib", Old: "~= 1.2", New: "~= 1.3.0"},
+			},
+		},
+		{
+			name: "update Poetry pyproject file",
+			files: map[string]string{
+				"poetry/pyproject.toml": `[tool.poetry]
+name = "my-app"
+
+[tool.poetry.dependencies]
+python = "^3.9"
+my-lib = "^1.2" # our shared library
+other-lib = { version = "~1.0", extras = ["all"] }
+
+[tool.poetry.group.dev.dependencies]
+My_Lib = { version = "1.2.3", extras = ["dev"] }
+
+[tool.poetry.group.test.dependencies.my-lib]
+version = "*"
+`,
+			},
+			updater: &PipUpdater{
+				FilePaths: []string{"poetry/pyproject.toml"},
+				Package:   "my-lib",
+				Valuer:    value.StringValuer("1.3.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"poetry/pyproject.toml": `[tool.poetry]
+name = "my-app"
+
+[tool.poetry.dependencies]
+python = "^3.9"
+my-lib = "^1.3.0" # our shared library
+other-lib = { version = "~1.0", extras = ["all"] }
+
+[tool.poetry.group.dev.dependencies]
+My_Lib = { version = "1.3.0", extras = ["dev"] }
+
+[tool.poetry.group.test.dependencies.my-lib]
+version = "*"
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "poetry/pyproject.toml", Name: "my-lib", Old: "^1.2", New: "^1.3.0"},
+				{File: "poetry/pyproject.toml", Name: "my-lib", Old: "1.2.3", New: "1.3.0"},
+			},
+		},
+		{
+			name: "value with operator",
+			files: map[string]string{
+				"operator/requirements.txt": "my-lib==1.2.3\n",
+			},
+			updater: &PipUpdater{
+				FilePaths: []string{"operator/requirements.txt"},
+				Package:   "my-lib",
+				Valuer:    value.StringValuer(">=1.3,<2"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"operator/requirements.txt": "my-lib>=1.3,<2\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "operator/requirements.txt", Name: "my-lib", Old: "==1.2.3", New: ">=1.3,<2"},
+			},
+		},
+		{
+			name: "no changes",
+			files: map[string]string{
+				"no-changes/requirements.txt": "my-lib==1.3.0\nmy-lib\n",
+			},
+			updater: &PipUpdater{
+				FilePaths: []string{"no-changes/requirements.txt"},
+				Package:   "my-lib",
+				Valuer:    value.StringValuer("1.3.0"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-changes/requirements.txt": "my-lib==1.3.0\nmy-lib\n",
+			},
+		},
+		{
+			name: "invalid value",
+			updater: &PipUpdater{
+				FilePaths: []string{"invalid-value/requirements.txt"},
+				Package:   "my-lib",
+				Valuer:    value.StringValuer("latest"),
+			},
+			expectedErrorMsg: `invalid value "latest": expected a version, such as 1.2.3 or ~=1.2.3`,
+		},
+		{
+			name: "invalid pyproject file",
+			files: map[string]string{
+				"invalid-file/pyproject.toml": "[project\ndependencies = []\n",
+			},
+			updater: &PipUpdater{
+				FilePaths: []string{"invalid-file/pyproject.toml"},
+				Package:   "my-lib",
+				Valuer:    value.StringValuer("1.3.0"),
+			},
+			expectedErrorMsg: `failed to update file invalid-file/pyproject.toml: failed to parse TOML: line 1: expected "]" to close table header project`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(context.Background(), "testdata")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+				for filename, expectedContent := range test.expectedFiles {
+					actualContent, err := os.ReadFile(filepath.Join("testdata", filename))
+					require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+					assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+				}
+				assert.Equal(t, test.expectedChanges, test.updater.Changes("testdata"))
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package pip
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// PEP 508 name, such as requests or zope.interface
+	nameRegexp = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9._-]*[A-Za-z0-9])?`)
+
+	// PEP 503 normalization: runs of -, _ and . are equivalent
+	normalizeRegexp = regexp.MustCompile(`[-_.]+`)
+
+	// a single version clause, such as ==1.2.3, ~= 1.2 or >=1.0
+	clauseRegexp = regexp.MustCompile(`^(===|==|~=|>=|>)(\s*)v?([0-9][0-9A-Za-z.+!*-]*)$`)
+
+	// a Poetry version constraint, such as ^1.2.3, ~1.2, ==1.2.3 or 1.2.3
+	poetryConstraintRegexp = regexp.MustCompile(`^(\^|~=|~|===|==|>=|>)?(\s*)v?([0-9][0-9A-Za-z.+!*-]*)$`)
+
+	// a version, with an optional operator - such as 1.2.3, ==1.2.3 or >=1.2,<2
+	versionRegexp = regexp.MustCompile(`^(?:(?:===|==|~=|!=|>=|<=|>|<|\^|~)\s*)?v?[0-9][0-9A-Za-z.+!*-]*(?:\s*,\s*(?:===|==|~=|!=|>=|<=|>|<)\s*[0-9][0-9A-Za-z.+!*-]*)*$`)
+)
+
+// requirement is a PEP 508 requirement, such as `requests[security] >= 2.8.1 ; python_version < "3.8"`,
+// with the position of its version specifier - which is empty if the requirement has no version or uses a URL.
+type requirement struct {
+	name      string
+	specStart int
+	specEnd   int
+}
+
+// parseRequirement parses the PEP 508 requirement at the beginning of the given string.
+// Everything after the version specifier - such as the markers, the comments or the pip options - is ignored.
+func parseRequirement(str string) (requirement, bool) {
+	pos := len(str) - len(strings.TrimLeft(str, " \t"))
+	name := nameRegexp.FindString(str[pos:])
+	if len(name) == 0 {
+		return requirement{}, false
+	}
+	pos += len(name)
+	pos = skipSpaces(str, pos)
+
+	// extras, such as [security,tests]
+	if pos < len(str) && str[pos] == '[' {
+		end := strings.IndexByte(str[pos:], ']')
+		if end < 0 {
+			return requirement{}, false
+		}
+		pos = skipSpaces(str, pos+end+1)
+	}
+	req := requirement{name: name, specStart: pos, specEnd: pos}
+
+	// a URL requirement, such as name @ https://...
+	if pos < len(str) && str[pos] == '@' {
+		return req, true
+	}
+
+	// the version specifier - optionally in parentheses - ends at the markers, the comment, the pip options or the line continuation
+	if pos < len(str) && str[pos] == '(' {
+		pos++
+		end := strings.IndexByte(str[pos:], ')')
+		if end < 0 {
+			return requirement{}, false
+		}
+		req.specStart, req.specEnd = skipSpaces(str, pos), pos+end
+	} else {
+		end := pos
+		for end < len(str) && !strings.ContainsRune(";#\\\r\n", rune(str[end])) && !strings.HasPrefix(str[end:], " -") {
+			end++
+		}
+		req.specEnd = end
+	}
+	for req.specEnd > req.specStart && (str[req.specEnd-1] == ' ' || str[req.specEnd-1] == '\t') {
+		req.specEnd--
+	}
+	return req, true
+}
+
+// updateSpecifier returns the new PEP 508 version specifier: the new version, with the same operator as the old specifier.
+// If the new version already has an operator, it is used as-is. Unpinned requirements and specifiers made of multiple clauses
+// - such as >=1.0,<2.0 - are not updated.
+func updateSpecifier(oldSpec, version string) (string, bool) {
+	matches := clauseRegexp.FindStringSubmatch(oldSpec)
+	if len(matches) == 0 {
+		return "", false
+	}
+	if hasOperator(version) {
+		return version, true
+	}
+	return matches[1] + matches[2] + strings.TrimPrefix(version, "v"), true
+}
+
+// updatePoetryConstraint returns the new Poetry version constraint: the new version, with the same operator as the old constraint.
+// If the new version already has an operator, it is used as-is. Wildcards and constraints made of multiple clauses are not updated.
+func updatePoetryConstraint(oldConstraint, version string) (string, bool) {
+	matches := poetryConstraintRegexp.FindStringSubmatch(oldConstraint)
+	if len(matches) == 0 {
+		return "", false
+	}
+	if hasOperator(version) {
+		return version, true
+	}
+	return matches[1] + matches[2] + strings.TrimPrefix(version, "v"), true
+}
+
+func hasOperator(version string) bool {
+	return strings.ContainsAny(version[:1], "=~!<>^")
+}
+
+// normalizeName returns the normalized name of a package, as defined by PEP 503.
+func normalizeName(name string) string {
+	return strings.ToLower(normalizeRegexp.ReplaceAllString(name, "-"))
+}
+
+func skipSpaces(str string, pos int) int {
+	for pos < len(str) && (str[pos] == ' ' || str[pos] == '\t') {
+		pos++
+	}
+	return pos
+}
@@ -0,0 +1,312 @@
+// Package properties provides an updater that updates Java properties files - such as gradle.properties - while preserving the formatting and the comments.
+package properties
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+)
+
+// PropertiesUpdater is an updater that updates the value of a property in Java properties files.
+type PropertiesUpdater struct {
+	FilePath   string
+	Key        string
+	AutoCreate bool
+	Valuer     value.Valuer
+
+	change.Recorder
+}
+
+// NewUpdater builds a new properties updater from the given parameters and valuer
+func NewUpdater(params map[string]string, valuer value.Valuer) (*PropertiesUpdater, error) {
+	updater := &PropertiesUpdater{}
+
+	updater.FilePath = params["file"]
+	if len(updater.FilePath) == 0 {
+		return nil, errors.New("missing file parameter")
+	}
+
+	updater.Key = params["key"]
+	if len(updater.Key) == 0 {
+		return nil, errors.New("missing key parameter")
+	}
+
+	updater.AutoCreate, _ = strconv.ParseBool(params["create"])
+
+	updater.Valuer = valuer
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *PropertiesUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	u.Reset(repoPath)
+
+	value, err := u.Valuer.Value(ctx, repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get value: %w", err)
+	}
+
+	filePaths, err := glob.ExpandGlobPattern(repoPath, u.FilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to expand glob pattern %s: %w", u.FilePath, err)
+	}
+
+	var updated bool
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+		}
+
+		newContent, changes := u.updateContent(string(content), value)
+		if newContent == string(content) {
+			continue
+		}
+		for i := range changes {
+			changes[i].File = filepath.ToSlash(relFilePath)
+		}
+
+		if err = os.WriteFile(filePath, []byte(newContent), fileInfo.Mode()); err != nil {
+			return false, fmt.Errorf("failed to write updated content to file %s: %w", relFilePath, err)
+		}
+
+		u.Record(repoPath, changes...)
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *PropertiesUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Update %s", u.Key)
+	body = fmt.Sprintf("Updating property `%s` in file(s) `%s`", u.Key, u.FilePath)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *PropertiesUpdater) String() string {
+	return fmt.Sprintf("Properties[key=%s,file=%s,create=%v]", u.Key, u.FilePath, u.AutoCreate)
+}
+
+// updateContent replaces the value of all the properties matching the key - and only their value, so that the separators,
+// the comments and the other properties are kept as-is. If the property is not defined and the auto-create mode is enabled,
+// it is appended at the end of the content.
+func (u *PropertiesUpdater) updateContent(content, value string) (string, []change.Change) {
+	var (
+		result   strings.Builder
+		position int
+		found    bool
+		changes  []change.Change
+	)
+	for _, p := range parseProperties(content) {
+		if p.key != u.Key {
+			continue
+		}
+		found = true
+		if p.value == value {
+			continue
+		}
+		result.WriteString(content[position:p.valueStart])
+		result.WriteString(escape(value, false))
+		position = p.valueEnd
+		changes = append(changes, change.Change{Name: u.Key, Old: p.value, New: value})
+	}
+	result.WriteString(content[position:])
+
+	if !found && u.AutoCreate {
+		if len(content) > 0 && !strings.HasSuffix(content, "\n") {
+			result.WriteString(lineEnding(content))
+		}
+		result.WriteString(escape(u.Key, true) + "=" + escape(value, false) + lineEnding(content))
+		changes = append(changes, change.Change{Name: u.Key, New: value})
+	}
+	return result.String(), changes
+}
+
+// property is a key/value pair defined in a properties file, with the position of its raw value.
+type property struct {
+	key        string
+	value      string
+	valueStart int
+	valueEnd   int
+}
+
+// parseProperties parses the properties defined in the given content, using the format defined by java.util.Properties:
+// the key and the value are separated by '=', ':' or whitespaces, the lines starting with '#' or '!' are comments,
+// and the lines ending with a backslash are continued on the next line.
+func parseProperties(content string) []property {
+	var (
+		properties []property
+		pos        int
+	)
+	for pos < len(content) {
+		// skip the leading whitespaces and the blank lines
+		for pos < len(content) && strings.ContainsRune(" \t\f\r\n", rune(content[pos])) {
+			pos++
+		}
+		if pos >= len(content) {
+			break
+		}
+		end := logicalLineEnd(content, pos)
+		if content[pos] == '#' || content[pos] == '!' {
+			pos = end
+			continue
+		}
+
+		keyStart := pos
+		for pos < end && !strings.ContainsRune("=: \t\f", rune(content[pos])) {
+			if content[pos] == '\\' {
+				pos++
+			}
+			pos++
+		}
+		pos = min(pos, end)
+		keyEnd := pos
+
+		// the separator: whitespaces, with an optional '=' or ':'
+		for pos < end && strings.ContainsRune(" \t\f", rune(content[pos])) {
+			pos++
+		}
+		if pos < end && (content[pos] == '=' || content[pos] == ':') {
+			pos++
+		}
+		for pos < end && strings.ContainsRune(" \t\f", rune(content[pos])) {
+			pos++
+		}
+
+		valueEnd := end
+		for valueEnd > pos && (content[valueEnd-1] == '\n' || content[valueEnd-1] == '\r') {
+			valueEnd--
+		}
+		properties = append(properties, property{
+			key:        unescape(content[keyStart:keyEnd]),
+			value:      unescape(content[pos:valueEnd]),
+			valueStart: pos,
+			valueEnd:   valueEnd,
+		})
+		pos = end
+	}
+	return properties
+}
+
+// logicalLineEnd returns the position of the end of the logical line starting at the given position - after its line ending.
+// A logical line is continued on the next line when it ends with an odd number of backslashes - except for the comments.
+func logicalLineEnd(content string, pos int) int {
+	comment := content[pos] == '#' || content[pos] == '!'
+	for {
+		eol := strings.IndexByte(content[pos:], '\n')
+		if eol < 0 {
+			return len(content)
+		}
+		eol += pos
+		line := strings.TrimSuffix(content[pos:eol], "\r")
+		backslashes := len(line) - len(strings.TrimRight(line, `\`))
+		if backslashes%2 == 0 || comment {
+			return eol + 1
+		}
+		pos = eol + 1
+	}
+}
+
+// unescape returns the actual value of a raw key or value - without the escape sequences and the line continuations.
+func unescape(raw string) string {
+	var sb strings.Builder
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if c != '\\' || i+1 >= len(raw) {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		switch raw[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+4 < len(raw) {
+				if r, err := strconv.ParseUint(raw[i+1:i+5], 16, 32); err == nil {
+					sb.WriteRune(rune(r))
+					i += 4
+					continue
+				}
+			}
+			sb.WriteByte('u')
+		case '\r', '\n':
+			// line continuation: the leading whitespaces of the next line are ignored
+			if raw[i] == '\r' && i+1 < len(raw) && raw[i+1] == '\n' {
+				i++
+			}
+			for i+1 < len(raw) && strings.ContainsRune(" \t\f", rune(raw[i+1])) {
+				i++
+			}
+		default:
+			sb.WriteByte(raw[i])
+		}
+	}
+	return sb.String()
+}
+
+// escape returns the raw representation of a key or a value.
+func escape(str string, key bool) string {
+	var sb strings.Builder
+	for i, c := range str {
+		switch c {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case ' ':
+			if key || i == 0 {
+				sb.WriteString(`\ `)
+			} else {
+				sb.WriteRune(c)
+			}
+		case '=', ':', '#', '!':
+			if key {
+				sb.WriteRune('\\')
+			}
+			sb.WriteRune(c)
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
+
+func lineEnding(content string) string {
+	if strings.Contains(content, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
@@ -0,0 +1,230 @@
+package properties
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *PropertiesUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params",
+			params: map[string]string{
+				"file":   "gradle.properties",
+				"key":    "kotlinVersion",
+				"create": "true",
+			},
+			expected: &PropertiesUpdater{
+				FilePath:   "gradle.properties",
+				Key:        "kotlinVersion",
+				AutoCreate: true,
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing file parameter",
+		},
+		{
+			name: "missing mandatory key param",
+			params: map[string]string{
+				"file": "gradle.properties",
+			},
+			expectedErrorMsg: "missing key parameter",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params, nil)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		files            map[string]string
+		updater          *PropertiesUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+		expectedChanges  []change.Change
+	}{
+		{
+			name: "update value and keep formatting",
+			files: map[string]string{
+				"gradle/gradle.properties": `# the versions
+org.gradle.jvmargs=-Xmx2g
+kotlinVersion = 1.9.0
+! kotlinVersion=1.8.0
+  springVersion:3.1.0
+`,
+			},
+			updater: &PropertiesUpdater{
+				FilePath: "gradle/gradle.properties",
+				Key:      "kotlinVersion",
+				Valuer:   value.StringValuer("1.9.22"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"gradle/gradle.properties": `# the versions
+org.gradle.jvmargs=-Xmx2g
+kotlinVersion = 1.9.22
+! kotlinVersion=1.8.0
+  springVersion:3.1.0
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "gradle/gradle.properties", Name: "kotlinVersion", Old: "1.9.0", New: "1.9.22"},
+			},
+		},
+		{
+			name: "update value with whitespace separator in multiple files",
+			files: map[string]string{
+				"multiple-files/a.properties": "app.version  1.0.0\r\nname=app\r\n",
+				"multiple-files/b.properties": "app.version:1.0.0",
+			},
+			updater: &PropertiesUpdater{
+				FilePath: "multiple-files/*.properties",
+				Key:      "app.version",
+				Valuer:   value.StringValuer("2.0.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"multiple-files/a.properties": "app.version  2.0.0\r\nname=app\r\n",
+				"multiple-files/b.properties": "app.version:2.0.0",
+			},
+			expectedChanges: []change.Change{
+				{File: "multiple-files/a.properties", Name: "app.version", Old: "1.0.0", New: "2.0.0"},
+				{File: "multiple-files/b.properties", Name: "app.version", Old: "1.0.0", New: "2.0.0"},
+			},
+		},
+		{
+			name: "update multi-line value with escaped key",
+			files: map[string]string{
+				"multi-line/app.properties": `my\ key = first, \
+          second
+other = value \
+`,
+			},
+			updater: &PropertiesUpdater{
+				FilePath: "multi-line/app.properties",
+				Key:      "my key",
+				Valuer:   value.StringValuer(" new value: C:\\path"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"multi-line/app.properties": `my\ key = \ new value: C:\\path
+other = value \
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "multi-line/app.properties", Name: "my key", Old: "first, second", New: " new value: C:\\path"},
+			},
+		},
+		{
+			name: "create missing key",
+			files: map[string]string{
+				"create/gradle.properties": "org.gradle.caching=true",
+			},
+			updater: &PropertiesUpdater{
+				FilePath:   "create/gradle.properties",
+				Key:        "kotlin.code.style",
+				AutoCreate: true,
+				Valuer:     value.StringValuer("official"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"create/gradle.properties": "org.gradle.caching=true\nkotlin.code.style=official\n",
+			},
+			expectedChanges: []change.Change{
+				{File: "create/gradle.properties", Name: "kotlin.code.style", New: "official"},
+			},
+		},
+		{
+			name: "missing key without create",
+			files: map[string]string{
+				"no-create/gradle.properties": "org.gradle.caching=true\n",
+			},
+			updater: &PropertiesUpdater{
+				FilePath: "no-create/gradle.properties",
+				Key:      "kotlin.code.style",
+				Valuer:   value.StringValuer("official"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-create/gradle.properties": "org.gradle.caching=true\n",
+			},
+		},
+		{
+			name: "no changes",
+			files: map[string]string{
+				"no-changes/gradle.properties": "version=1.0.0\n",
+			},
+			updater: &PropertiesUpdater{
+				FilePath:   "no-changes/gradle.properties",
+				Key:        "version",
+				AutoCreate: true,
+				Valuer:     value.StringValuer("1.0.0"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-changes/gradle.properties": "version=1.0.0\n",
+			},
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(context.Background(), "testdata")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+				for filename, expectedContent := range test.expectedFiles {
+					actualContent, err := os.ReadFile(filepath.Join("testdata", filename))
+					require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+					assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+				}
+				assert.Equal(t, test.expectedChanges, test.updater.Changes("testdata"))
+			}
+		})
+	}
+}
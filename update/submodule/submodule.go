@@ -0,0 +1,295 @@
+// Package submodule provides an updater that moves a git submodule to a given tag, branch or commit.
+package submodule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	"github.com/dailymotion-oss/octopilot/internal/ghclient"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+)
+
+const (
+	// the maximum number of commits listed in the details of the update
+	maxLogCommits = 50
+
+	// the length of the abbreviated commit hashes
+	shortHashLength = 7
+)
+
+// SubmoduleUpdater is an updater that moves a git submodule to a given tag, branch or commit.
+// The new commit is checked out in the submodule, and the new gitlink is staged with the other submodules changes.
+type SubmoduleUpdater struct {
+	Path   string
+	Valuer value.Valuer
+
+	change.Recorder
+	change.DetailsRecorder
+}
+
+// NewUpdater builds a new submodule updater from the given parameters and valuer
+func NewUpdater(params map[string]string, valuer value.Valuer) (*SubmoduleUpdater, error) {
+	updater := &SubmoduleUpdater{}
+
+	updater.Path = params["path"]
+	if len(updater.Path) == 0 {
+		return nil, errors.New("missing path parameter")
+	}
+	updater.Path = filepath.ToSlash(filepath.Clean(updater.Path))
+
+	updater.Valuer = valuer
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *SubmoduleUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	u.Reset(repoPath)
+	u.SetDetails(repoPath, "")
+
+	ref, err := u.Valuer.Value(ctx, repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get value: %w", err)
+	}
+	ref = strings.TrimSpace(ref)
+	if len(ref) == 0 {
+		return false, errors.New("invalid empty value: expected a tag, a branch or a commit")
+	}
+
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to open git repository: %w", err)
+	}
+
+	submodule, err := u.findSubmodule(repo)
+	if err != nil {
+		return false, err
+	}
+
+	status, err := submodule.Status()
+	if err != nil {
+		return false, fmt.Errorf("failed to get submodule %s status: %w", u.Path, err)
+	}
+	oldHash := status.Expected
+
+	// the submodule is cloned only if it has not already been initialized - with the --git-recurse-submodules flag for example
+	if status.Current.IsZero() {
+		submodule.Config().URL = rewriteGitHubURL(ctx, submodule.Config().URL)
+		err = submodule.UpdateContext(ctx, &git.SubmoduleUpdateOptions{
+			Init: true,
+			Auth: gitAuth(ctx, submodule.Config().URL),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to initialize submodule %s: %w", u.Path, err)
+		}
+	}
+
+	subRepo, err := submodule.Repository()
+	if err != nil {
+		return false, fmt.Errorf("failed to open submodule %s repository: %w", u.Path, err)
+	}
+
+	newHash, err := resolveRef(ctx, subRepo, ref)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve %s in submodule %s: %w", ref, u.Path, err)
+	}
+
+	subWorktree, err := subRepo.Worktree()
+	if err != nil {
+		return false, fmt.Errorf("failed to get submodule %s worktree: %w", u.Path, err)
+	}
+	err = subWorktree.Checkout(&git.CheckoutOptions{
+		Hash:  newHash,
+		Force: true,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to checkout %s in submodule %s: %w", ref, u.Path, err)
+	}
+
+	if newHash == oldHash {
+		return false, nil
+	}
+
+	newValue := shortHash(newHash)
+	if !strings.HasPrefix(newHash.String(), ref) {
+		newValue = fmt.Sprintf("%s (%s)", ref, shortHash(newHash))
+	}
+	c := change.Change{Name: u.Path, New: newValue}
+	if !oldHash.IsZero() {
+		c.Old = shortHash(oldHash)
+	}
+	u.Record(repoPath, c)
+
+	details, err := u.commitLog(subRepo, oldHash, newHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to get submodule %s commit log: %w", u.Path, err)
+	}
+	u.SetDetails(repoPath, details)
+
+	return true, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *SubmoduleUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Update submodule %s", u.Path)
+	body = fmt.Sprintf("Updating git submodule `%s`", u.Path)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *SubmoduleUpdater) String() string {
+	return fmt.Sprintf("Submodule[path=%s]", u.Path)
+}
+
+func (u *SubmoduleUpdater) findSubmodule(repo *git.Repository) (*git.Submodule, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	submodules, err := worktree.Submodules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submodules: %w", err)
+	}
+
+	for _, submodule := range submodules {
+		if filepath.ToSlash(filepath.Clean(submodule.Config().Path)) == u.Path {
+			return submodule, nil
+		}
+	}
+	return nil, fmt.Errorf("no submodule found at path %s", u.Path)
+}
+
+// commitLog returns a markdown list of the commits between the old and new commits - from the newest to the oldest.
+// If the old commit is not an ancestor of the new one, the list stops at the maximum number of commits.
+func (u *SubmoduleUpdater) commitLog(repo *git.Repository, oldHash, newHash plumbing.Hash) (string, error) {
+	if oldHash.IsZero() {
+		return "", nil
+	}
+
+	commits, err := repo.Log(&git.LogOptions{From: newHash})
+	if err != nil {
+		return "", err
+	}
+	defer commits.Close()
+
+	var (
+		lines     []string
+		truncated bool
+	)
+	err = commits.ForEach(func(commit *object.Commit) error {
+		if commit.Hash == oldHash {
+			return storer.ErrStop
+		}
+		if len(lines) == maxLogCommits {
+			truncated = true
+			return storer.ErrStop
+		}
+		subject, _, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+		lines = append(lines, fmt.Sprintf("- `%s` %s", shortHash(commit.Hash), strings.TrimSpace(subject)))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	if truncated {
+		lines = append(lines, "- ...")
+	}
+
+	return fmt.Sprintf("Commits in `%s` from `%s` to `%s`:\n\n%s", u.Path, shortHash(oldHash), shortHash(newHash), strings.Join(lines, "\n")), nil
+}
+
+// resolveRef fetches the branches and tags of the submodule repository, and returns the commit for the given tag, branch or commit hash.
+func resolveRef(ctx context.Context, repo *git.Repository, ref string) (plumbing.Hash, error) {
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to get remote %s: %w", git.DefaultRemoteName, err)
+	}
+	var auth transport.AuthMethod
+	if urls := remote.Config().URLs; len(urls) > 0 {
+		auth = gitAuth(ctx, urls[0])
+	}
+
+	err = repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{"+refs/heads/*:refs/remotes/origin/*"},
+		Tags:       git.AllTags,
+		Force:      true,
+		Auth:       auth,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return plumbing.ZeroHash, fmt.Errorf("failed to fetch: %w", err)
+	}
+
+	// tags first, then remote branches - because the local branches might be outdated - and finally commit hashes
+	for _, rev := range []string{
+		plumbing.NewTagReferenceName(ref).String(),
+		plumbing.NewRemoteReferenceName(git.DefaultRemoteName, ref).String(),
+		ref,
+	} {
+		if hash, err := repo.ResolveRevision(plumbing.Revision(rev)); err == nil {
+			return *hash, nil
+		}
+	}
+
+	// a commit which is not reachable from the branches or tags might still be fetched by its full hash
+	if plumbing.IsHash(ref) {
+		err = repo.FetchContext(ctx, &git.FetchOptions{
+			RemoteName: git.DefaultRemoteName,
+			RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("+%s:%s", ref, ref))},
+			Auth:       auth,
+		})
+		if err == nil || errors.Is(err, git.NoErrAlreadyUpToDate) {
+			if hash, err := repo.ResolveRevision(plumbing.Revision(ref)); err == nil {
+				return *hash, nil
+			}
+		}
+	}
+
+	return plumbing.ZeroHash, errors.New("no matching tag, branch or commit")
+}
+
+// rewriteGitHubURL rewrites the SSH URL of a repository hosted on GitHub to use HTTPS - because token auth only works with that.
+func rewriteGitHubURL(ctx context.Context, repoURL string) string {
+	credentials, ok := ghclient.CredentialsFromContext(ctx)
+	if !ok {
+		return repoURL
+	}
+	githubURL, err := url.Parse(credentials.URL)
+	if err != nil || len(githubURL.Hostname()) == 0 {
+		return repoURL
+	}
+	return strings.Replace(repoURL, fmt.Sprintf("git@%s:", githubURL.Hostname()), fmt.Sprintf("%s://%s/", githubURL.Scheme, githubURL.Hostname()), 1)
+}
+
+// gitAuth returns the auth method for the given repository URL: basic auth for the repositories hosted on GitHub, and no auth for the others.
+func gitAuth(ctx context.Context, repoURL string) transport.AuthMethod {
+	credentials, ok := ghclient.CredentialsFromContext(ctx)
+	if !ok || len(credentials.URL) == 0 || !strings.HasPrefix(repoURL, credentials.URL) {
+		return nil
+	}
+	return &http.BasicAuth{
+		Username: "x-access-token", // For GitHub Apps, the username must be `x-access-token`. For Personal Tokens, it doesn't matter.
+		Password: credentials.Token,
+	}
+}
+
+func shortHash(hash plumbing.Hash) string {
+	return hash.String()[:shortHashLength]
+}
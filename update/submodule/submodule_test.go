@@ -0,0 +1,262 @@
+package submodule
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *SubmoduleUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params",
+			params: map[string]string{
+				"path": "vendor/protos",
+			},
+			expected: &SubmoduleUpdater{
+				Path: "vendor/protos",
+			},
+		},
+		{
+			name: "path is cleaned",
+			params: map[string]string{
+				"path": "./vendor/protos/",
+			},
+			expected: &SubmoduleUpdater{
+				Path: "vendor/protos",
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing path parameter",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params, nil)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	// the upstream repository of the submodule:
+	// v1.0.0 -> Add foo -> Add bar (v1.1.0, annotated) on main, and Work in progress on develop
+	upstreamPath, err := filepath.Abs(filepath.Join("testdata", "upstream"))
+	require.NoError(t, err)
+	require.NoError(t, os.RemoveAll(upstreamPath))
+	upstream, err := git.PlainInit(upstreamPath, false)
+	require.NoError(t, err)
+	v100 := commitFile(t, upstream, "README.md", "protos", "Initial commit")
+	_, err = upstream.CreateTag("v1.0.0", v100, nil)
+	require.NoError(t, err)
+	foo := commitFile(t, upstream, "foo.proto", "foo", "Add foo")
+	v110 := commitFile(t, upstream, "bar.proto", "bar", "Add bar\n\nWith a long description.")
+	_, err = upstream.CreateTag("v1.1.0", v110, &git.CreateTagOptions{
+		Tagger:  signature(),
+		Message: "Release v1.1.0",
+	})
+	require.NoError(t, err)
+	upstreamWorktree, err := upstream.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, upstreamWorktree.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName("develop"),
+		Create: true,
+	}))
+	develop := commitFile(t, upstream, "wip.proto", "wip", "Work in progress")
+
+	tests := []struct {
+		name             string
+		updater          *SubmoduleUpdater
+		initialized      bool
+		expected         bool
+		expectedErrorMsg string
+		expectedHash     plumbing.Hash
+		expectedChanges  []change.Change
+		expectedDetails  string
+	}{
+		{
+			name: "update to annotated tag",
+			updater: &SubmoduleUpdater{
+				Path:   "vendor/protos",
+				Valuer: value.StringValuer("v1.1.0"),
+			},
+			expected:     true,
+			expectedHash: v110,
+			expectedChanges: []change.Change{
+				{Name: "vendor/protos", Old: shortHash(v100), New: fmt.Sprintf("v1.1.0 (%s)", shortHash(v110))},
+			},
+			expectedDetails: fmt.Sprintf("Commits in `vendor/protos` from `%s` to `%s`:\n\n- `%s` Add bar\n- `%s` Add foo",
+				shortHash(v100), shortHash(v110), shortHash(v110), shortHash(foo)),
+		},
+		{
+			name: "update initialized submodule to branch",
+			updater: &SubmoduleUpdater{
+				Path:   "vendor/protos",
+				Valuer: value.StringValuer("develop"),
+			},
+			initialized:  true,
+			expected:     true,
+			expectedHash: develop,
+			expectedChanges: []change.Change{
+				{Name: "vendor/protos", Old: shortHash(v100), New: fmt.Sprintf("develop (%s)", shortHash(develop))},
+			},
+		},
+		{
+			name: "update to commit",
+			updater: &SubmoduleUpdater{
+				Path:   "vendor/protos",
+				Valuer: value.StringValuer(v110.String()[:10]),
+			},
+			expected:     true,
+			expectedHash: v110,
+			expectedChanges: []change.Change{
+				{Name: "vendor/protos", Old: shortHash(v100), New: shortHash(v110)},
+			},
+		},
+		{
+			name: "no changes",
+			updater: &SubmoduleUpdater{
+				Path:   "vendor/protos",
+				Valuer: value.StringValuer("v1.0.0"),
+			},
+			expected:     false,
+			expectedHash: v100,
+		},
+		{
+			name: "unknown ref",
+			updater: &SubmoduleUpdater{
+				Path:   "vendor/protos",
+				Valuer: value.StringValuer("v2.0.0"),
+			},
+			expectedErrorMsg: "failed to resolve v2.0.0 in submodule vendor/protos: no matching tag, branch or commit",
+		},
+		{
+			name: "unknown submodule",
+			updater: &SubmoduleUpdater{
+				Path:   "vendor/other",
+				Valuer: value.StringValuer("v1.1.0"),
+			},
+			expectedErrorMsg: "no submodule found at path vendor/other",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			repoPath := filepath.Join("testdata", strings.ReplaceAll(test.name, " ", "-"))
+			repo := initRepository(t, repoPath, upstreamPath, v100, test.initialized)
+
+			actual, err := test.updater.Update(context.Background(), repoPath)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+			assert.Equal(t, test.expectedChanges, test.updater.Changes(repoPath))
+			if len(test.expectedDetails) > 0 {
+				assert.Equal(t, test.expectedDetails, test.updater.Details(repoPath))
+			}
+
+			worktree, err := repo.Worktree()
+			require.NoError(t, err)
+			submodule, err := worktree.Submodule("protos")
+			require.NoError(t, err)
+			status, err := submodule.Status()
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedHash, status.Current)
+			assert.Equal(t, !test.expected, status.IsClean())
+
+			// the new gitlink must be detected as a change of the repository, to be staged and committed
+			repoStatus, err := worktree.Status()
+			require.NoError(t, err)
+			assert.Equal(t, !test.expected, repoStatus.IsClean())
+		})
+	}
+}
+
+// initRepository creates a new repository with a submodule at vendor/protos - pointing to the given commit of the upstream repository.
+func initRepository(t *testing.T, repoPath, upstreamPath string, hash plumbing.Hash, initialized bool) *git.Repository {
+	t.Helper()
+
+	require.NoError(t, os.RemoveAll(repoPath))
+	repo, err := git.PlainInit(repoPath, false)
+	require.NoError(t, err)
+
+	gitmodules := fmt.Sprintf("[submodule \"protos\"]\n\tpath = vendor/protos\n\turl = %s\n", upstreamPath)
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, ".gitmodules"), []byte(gitmodules), 0644))
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = worktree.Add(".gitmodules")
+	require.NoError(t, err)
+
+	index, err := repo.Storer.Index()
+	require.NoError(t, err)
+	entry := index.Add("vendor/protos")
+	entry.Hash = hash
+	entry.Mode = filemode.Submodule
+	require.NoError(t, repo.Storer.SetIndex(index))
+
+	_, err = worktree.Commit("Add protos submodule", &git.CommitOptions{Author: signature()})
+	require.NoError(t, err)
+
+	if initialized {
+		submodule, err := worktree.Submodule("protos")
+		require.NoError(t, err)
+		require.NoError(t, submodule.Update(&git.SubmoduleUpdateOptions{Init: true}))
+	}
+
+	return repo
+}
+
+func commitFile(t *testing.T, repo *git.Repository, filename, content, message string) plumbing.Hash {
+	t.Helper()
+
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(worktree.Filesystem.Root(), filename), []byte(content), 0644))
+	_, err = worktree.Add(filename)
+	require.NoError(t, err)
+	hash, err := worktree.Commit(message, &git.CommitOptions{Author: signature()})
+	require.NoError(t, err)
+	return hash
+}
+
+func signature() *object.Signature {
+	return &object.Signature{Name: "Octopilot", Email: "octopilot@example.com", When: time.Now()}
+}
@@ -0,0 +1,108 @@
+// Package template provides an updater that renders a Go template to a file.
+package template
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-github/v57/github"
+
+	"github.com/dailymotion-oss/octopilot/internal/ghclient"
+	"github.com/dailymotion-oss/octopilot/internal/tpl"
+)
+
+// TemplateUpdater is an updater that renders a Go template, and writes the result to a file in the repository.
+type TemplateUpdater struct {
+	SourcePath string
+	DestPath   string
+}
+
+// NewUpdater builds a new template updater from the given parameters
+func NewUpdater(params map[string]string) (*TemplateUpdater, error) {
+	updater := &TemplateUpdater{}
+
+	updater.SourcePath = params["src"]
+	if len(updater.SourcePath) == 0 {
+		return nil, errors.New("missing src parameter")
+	}
+
+	updater.DestPath = params["dest"]
+	if len(updater.DestPath) == 0 {
+		return nil, errors.New("missing dest parameter")
+	}
+	if filepath.IsAbs(updater.DestPath) || !filepath.IsLocal(updater.DestPath) {
+		return nil, fmt.Errorf("invalid dest parameter %s: it must be a relative path inside the repository", updater.DestPath)
+	}
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *TemplateUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	sourcePath := u.SourcePath
+	if !filepath.IsAbs(sourcePath) {
+		sourcePath = filepath.Join(repoPath, sourcePath)
+	}
+	sourceInfo, err := os.Stat(sourcePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to access template %s: %w", u.SourcePath, err)
+	}
+	text, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read template %s: %w", u.SourcePath, err)
+	}
+
+	githubClient := func(_ context.Context) (*github.Client, error) {
+		return ghclient.FromContext(ctx)
+	}
+	t, err := tpl.New(filepath.Base(u.SourcePath), repoPath, githubClient).Parse(string(text))
+	if err != nil {
+		return false, fmt.Errorf("failed to parse template %s: %w", u.SourcePath, err)
+	}
+
+	var buffer bytes.Buffer
+	if err = t.Execute(&buffer, tpl.DataFromContext(ctx)); err != nil {
+		return false, fmt.Errorf("failed to execute template %s: %w", u.SourcePath, err)
+	}
+	content := buffer.Bytes()
+
+	destPath := filepath.Join(repoPath, u.DestPath)
+	mode := sourceInfo.Mode().Perm()
+	if destInfo, err := os.Stat(destPath); err == nil {
+		oldContent, err := os.ReadFile(destPath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", u.DestPath, err)
+		}
+		if bytes.Equal(oldContent, content) {
+			return false, nil
+		}
+		mode = destInfo.Mode()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("failed to access file %s: %w", u.DestPath, err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return false, fmt.Errorf("failed to create directories for file %s: %w", u.DestPath, err)
+	}
+	if err = os.WriteFile(destPath, content, mode); err != nil {
+		return false, fmt.Errorf("failed to write rendered template to file %s: %w", u.DestPath, err)
+	}
+
+	return true, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *TemplateUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Update %s", u.DestPath)
+	body = fmt.Sprintf("Rendering template `%s` to file `%s`", filepath.Base(u.SourcePath), u.DestPath)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *TemplateUpdater) String() string {
+	return fmt.Sprintf("Template[src=%s,dest=%s]", u.SourcePath, u.DestPath)
+}
@@ -0,0 +1,194 @@
+package template
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/internal/tpl"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *TemplateUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params",
+			params: map[string]string{
+				"src":  "/templates/CODEOWNERS.tpl",
+				"dest": ".github/CODEOWNERS",
+			},
+			expected: &TemplateUpdater{
+				SourcePath: "/templates/CODEOWNERS.tpl",
+				DestPath:   ".github/CODEOWNERS",
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing src parameter",
+		},
+		{
+			name: "missing mandatory dest param",
+			params: map[string]string{
+				"src": "/templates/CODEOWNERS.tpl",
+			},
+			expectedErrorMsg: "missing dest parameter",
+		},
+		{
+			name: "dest outside of the repository",
+			params: map[string]string{
+				"src":  "/templates/CODEOWNERS.tpl",
+				"dest": "../CODEOWNERS",
+			},
+			expectedErrorMsg: "invalid dest parameter ../CODEOWNERS: it must be a relative path inside the repository",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		repoPath         string
+		files            map[string]string
+		updater          *TemplateUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+	}{
+		{
+			name:     "render template to new file in new directory",
+			repoPath: "new-file",
+			files: map[string]string{
+				"templates/CODEOWNERS.tpl": "# owned by {{ .repo.Params.team | default \"nobody\" }}\n* @{{ .repo.Owner }}/{{ .repo.Name | upper }} # {{ readFile \"VERSION\" | trim }}\n",
+				"VERSION":                  "1.2.3\n",
+			},
+			updater: &TemplateUpdater{
+				SourcePath: "templates/CODEOWNERS.tpl",
+				DestPath:   ".github/CODEOWNERS",
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				".github/CODEOWNERS": "# owned by platform\n* @dailymotion-oss/OCTOPILOT # 1.2.3\n",
+			},
+		},
+		{
+			name:     "render template to existing file",
+			repoPath: "existing-file",
+			files: map[string]string{
+				"templates/Makefile.tpl": "include common.mk\n\nNAME := {{ .repo.Name }}\n",
+				"Makefile":               "NAME := old\n",
+			},
+			updater: &TemplateUpdater{
+				SourcePath: "templates/Makefile.tpl",
+				DestPath:   "Makefile",
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"Makefile": "include common.mk\n\nNAME := octopilot\n",
+			},
+		},
+		{
+			name:     "no changes",
+			repoPath: "no-changes",
+			files: map[string]string{
+				"templates/Makefile.tpl": "NAME := {{ .repo.Name }}\n",
+				"Makefile":               "NAME := octopilot\n",
+			},
+			updater: &TemplateUpdater{
+				SourcePath: "templates/Makefile.tpl",
+				DestPath:   "Makefile",
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"Makefile": "NAME := octopilot\n",
+			},
+		},
+		{
+			name:     "missing template",
+			repoPath: "missing-template",
+			updater: &TemplateUpdater{
+				SourcePath: "templates/missing.tpl",
+				DestPath:   "Makefile",
+			},
+			expectedErrorMsg: "failed to access template templates/missing.tpl: stat testdata/missing-template/templates/missing.tpl: no such file or directory",
+		},
+		{
+			name:     "invalid template",
+			repoPath: "invalid-template",
+			files: map[string]string{
+				"templates/Makefile.tpl": "NAME := {{ .repo.Name }\n",
+			},
+			updater: &TemplateUpdater{
+				SourcePath: "templates/Makefile.tpl",
+				DestPath:   "Makefile",
+			},
+			expectedErrorMsg: `failed to parse template templates/Makefile.tpl: template: Makefile.tpl:1: unexpected "}" in operand`,
+		},
+	}
+
+	ctx := tpl.NewContext(context.Background(), map[string]interface{}{
+		"repo": map[string]interface{}{
+			"Owner": "dailymotion-oss",
+			"Name":  "octopilot",
+			"Params": map[string]string{
+				"team": "platform",
+			},
+		},
+	})
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			repoPath := filepath.Join("testdata", test.repoPath)
+			{
+				err := os.RemoveAll(repoPath)
+				require.NoErrorf(t, err, "can't remove testdata directory %s", repoPath)
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join(repoPath, filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join(repoPath, filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(ctx, repoPath)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+				for filename, expectedContent := range test.expectedFiles {
+					actualContent, err := os.ReadFile(filepath.Join(repoPath, filename))
+					require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+					assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Package toml provides an updater that updates TOML files, while preserving their formatting and comments.
+package toml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	"github.com/dailymotion-oss/octopilot/internal/toml"
+	"github.com/dailymotion-oss/octopilot/update/value"
+)
+
+// TomlUpdater is an updater that updates TOML files, while preserving their formatting and comments.
+type TomlUpdater struct {
+	FilePath   string
+	Path       string
+	ParsedPath toml.Path
+	AutoCreate bool
+	Valuer     value.Valuer
+}
+
+// NewUpdater builds a new TOML updater from the given parameters and valuer
+func NewUpdater(params map[string]string, valuer value.Valuer) (*TomlUpdater, error) {
+	updater := &TomlUpdater{}
+
+	updater.FilePath = params["file"]
+	if len(updater.FilePath) == 0 {
+		return nil, errors.New("missing file parameter")
+	}
+
+	updater.Path = params["path"]
+	if len(updater.Path) == 0 {
+		return nil, errors.New("missing path parameter")
+	}
+
+	var err error
+	updater.ParsedPath, err = toml.ParsePath(updater.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	updater.AutoCreate, _ = strconv.ParseBool(params["create"])
+
+	updater.Valuer = valuer
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *TomlUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	value, err := u.Valuer.Value(ctx, repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get value: %w", err)
+	}
+
+	filePaths, err := glob.ExpandGlobPattern(repoPath, u.FilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to expand glob pattern %s: %w", u.FilePath, err)
+	}
+
+	var updated bool
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+		}
+
+		doc, err := toml.Parse(content)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse TOML file %s: %w", relFilePath, err)
+		}
+
+		updatedContent, changed, err := doc.Set(u.ParsedPath, value, u.AutoCreate)
+		if err != nil {
+			return false, fmt.Errorf("failed to update TOML file %s: %w", relFilePath, err)
+		}
+		if !changed {
+			continue
+		}
+
+		if err = os.WriteFile(filePath, updatedContent, fileInfo.Mode()); err != nil {
+			return false, fmt.Errorf("failed to write updated content to file %s: %w", relFilePath, err)
+		}
+
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *TomlUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Update %s", u.FilePath)
+	body = fmt.Sprintf("Updating path `%s` in file(s) `%s`", u.Path, u.FilePath)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *TomlUpdater) String() string {
+	return fmt.Sprintf("TOML[path=%s,file=%s,create=%v]", u.Path, u.FilePath, u.AutoCreate)
+}
@@ -0,0 +1,308 @@
+package toml
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/internal/toml"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *TomlUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params with single file",
+			params: map[string]string{
+				"file":   "Cargo.toml",
+				"path":   "package.version",
+				"create": "true",
+			},
+			expected: &TomlUpdater{
+				FilePath:   "Cargo.toml",
+				Path:       "package.version",
+				ParsedPath: toml.Path{{Key: "package"}, {Key: "version"}},
+				AutoCreate: true,
+			},
+		},
+		{
+			name: "valid params with multiple files using a glob pattern",
+			params: map[string]string{
+				"file":   "**/Cargo.toml",
+				"path":   `dependencies."my.lib".version`,
+				"create": "maybe",
+			},
+			expected: &TomlUpdater{
+				FilePath:   "**/Cargo.toml",
+				Path:       `dependencies."my.lib".version`,
+				ParsedPath: toml.Path{{Key: "dependencies"}, {Key: "my.lib"}, {Key: "version"}},
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing file parameter",
+		},
+		{
+			name: "missing mandatory path param",
+			params: map[string]string{
+				"file": "Cargo.toml",
+			},
+			expectedErrorMsg: "missing path parameter",
+		},
+		{
+			name: "invalid path",
+			params: map[string]string{
+				"file": "Cargo.toml",
+				"path": "bin[first].name",
+			},
+			expectedErrorMsg: `invalid path "bin[first].name": invalid array index "first"`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params, nil)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		files            map[string]string
+		updater          *TomlUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+	}{
+		{
+			name: "update value in a table",
+			files: map[string]string{
+				"table.toml": `# top level comment
+[package]
+name = "my-app"
+version = "1.0.0" # the version
+
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+`,
+			},
+			updater: &TomlUpdater{
+				FilePath:   "table.toml",
+				Path:       "package.version",
+				ParsedPath: toml.Path{{Key: "package"}, {Key: "version"}},
+				Valuer:     value.StringValuer("1.1.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"table.toml": `# top level comment
+[package]
+name = "my-app"
+version = "1.1.0" # the version
+
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+`,
+			},
+		},
+		{
+			name: "update value in an inline table",
+			files: map[string]string{
+				"inline-table.toml": `[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+`,
+			},
+			updater: &TomlUpdater{
+				FilePath:   "inline-table.toml",
+				Path:       "dependencies.serde.version",
+				ParsedPath: toml.Path{{Key: "dependencies"}, {Key: "serde"}, {Key: "version"}},
+				Valuer:     value.StringValuer("1.0.190"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"inline-table.toml": `[dependencies]
+serde = { version = "1.0.190", features = ["derive"] }
+`,
+			},
+		},
+		{
+			name: "update value in an array of tables",
+			files: map[string]string{
+				"array-of-tables.toml": `[[bin]]
+name = "first"
+path = "src/first.rs"
+
+[[bin]]
+name = "second"
+path = "src/second.rs"
+`,
+			},
+			updater: &TomlUpdater{
+				FilePath:   "array-of-tables.toml",
+				Path:       "bin[1].path",
+				ParsedPath: toml.Path{{Key: "bin"}, {Index: 1, IsIndex: true}, {Key: "path"}},
+				Valuer:     value.StringValuer("src/bin/second.rs"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"array-of-tables.toml": `[[bin]]
+name = "first"
+path = "src/first.rs"
+
+[[bin]]
+name = "second"
+path = "src/bin/second.rs"
+`,
+			},
+		},
+		{
+			name: "update multiple files",
+			files: map[string]string{
+				"multiple-files-1.toml": `version = "1.0.0"`,
+				"multiple-files-2.toml": `version = "1.0.0"`,
+			},
+			updater: &TomlUpdater{
+				FilePath:   "multiple-files-*.toml",
+				Path:       "version",
+				ParsedPath: toml.Path{{Key: "version"}},
+				Valuer:     value.StringValuer("2.0.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"multiple-files-1.toml": `version = "2.0.0"`,
+				"multiple-files-2.toml": `version = "2.0.0"`,
+			},
+		},
+		{
+			name: "create missing key/value",
+			files: map[string]string{
+				"missing-key.toml": `[package]
+name = "my-app"
+
+[dependencies]
+`,
+			},
+			updater: &TomlUpdater{
+				FilePath:   "missing-key.toml",
+				Path:       "package.version",
+				ParsedPath: toml.Path{{Key: "package"}, {Key: "version"}},
+				AutoCreate: true,
+				Valuer:     value.StringValuer("1.0.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"missing-key.toml": `[package]
+name = "my-app"
+version = "1.0.0"
+
+[dependencies]
+`,
+			},
+		},
+		{
+			name: "no changes if new key but no auto-create",
+			files: map[string]string{
+				"no-changes-without-auto-create.toml": `[package]
+name = "my-app"
+`,
+			},
+			updater: &TomlUpdater{
+				FilePath:   "no-changes-without-auto-create.toml",
+				Path:       "package.version",
+				ParsedPath: toml.Path{{Key: "package"}, {Key: "version"}},
+				Valuer:     value.StringValuer("1.0.0"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-changes-without-auto-create.toml": `[package]
+name = "my-app"
+`,
+			},
+		},
+		{
+			name: "no changes",
+			files: map[string]string{
+				"no-changes.toml": `version = '1.0.0'`,
+			},
+			updater: &TomlUpdater{
+				FilePath:   "no-changes.toml",
+				Path:       "version",
+				ParsedPath: toml.Path{{Key: "version"}},
+				Valuer:     value.StringValuer("1.0.0"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-changes.toml": `version = '1.0.0'`,
+			},
+		},
+		{
+			name: "invalid TOML file",
+			files: map[string]string{
+				"invalid.toml": `[package]
+version = 1.0.0
+`,
+			},
+			updater: &TomlUpdater{
+				FilePath:   "invalid.toml",
+				Path:       "package.version",
+				ParsedPath: toml.Path{{Key: "package"}, {Key: "version"}},
+				Valuer:     value.StringValuer("1.1.0"),
+			},
+			expectedErrorMsg: `failed to parse TOML file invalid.toml: line 2: invalid value "1.0.0" for key package.version`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(context.Background(), "testdata")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+				actualFilePaths, err := filepath.Glob(filepath.Join("testdata", test.updater.FilePath))
+				require.NoError(t, err, "can't expand glob pattern for actual testdata file")
+				for _, actualFilePath := range actualFilePaths {
+					actualRelFilePath, err := filepath.Rel("testdata", actualFilePath)
+					require.NoErrorf(t, err, "can't get relative path for actual testdata file %s", actualFilePath)
+					actualFileContent, err := os.ReadFile(actualFilePath)
+					require.NoErrorf(t, err, "can't read actual testdata file %s", actualFilePath)
+					expectedFileContent := test.expectedFiles[actualRelFilePath]
+					assert.Equalf(t, expectedFileContent, string(actualFileContent), "testdata file %s doesn't match", actualFilePath)
+				}
+			}
+		})
+	}
+}
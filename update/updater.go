@@ -7,11 +7,32 @@ import (
 	"strings"
 
 	"github.com/dailymotion-oss/octopilot/internal/parameters"
+	"github.com/dailymotion-oss/octopilot/update/actions"
+	"github.com/dailymotion-oss/octopilot/update/bump"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/changelog"
+	"github.com/dailymotion-oss/octopilot/update/dockerfile"
 	"github.com/dailymotion-oss/octopilot/update/exec"
+	"github.com/dailymotion-oss/octopilot/update/files"
+	"github.com/dailymotion-oss/octopilot/update/gitops"
+	"github.com/dailymotion-oss/octopilot/update/gomod"
+	"github.com/dailymotion-oss/octopilot/update/hcl"
 	"github.com/dailymotion-oss/octopilot/update/helm"
+	"github.com/dailymotion-oss/octopilot/update/image"
+	"github.com/dailymotion-oss/octopilot/update/json"
+	"github.com/dailymotion-oss/octopilot/update/keyvalue"
+	"github.com/dailymotion-oss/octopilot/update/lineinfile"
+	"github.com/dailymotion-oss/octopilot/update/npm"
+	"github.com/dailymotion-oss/octopilot/update/patch"
+	"github.com/dailymotion-oss/octopilot/update/pip"
+	"github.com/dailymotion-oss/octopilot/update/properties"
 	"github.com/dailymotion-oss/octopilot/update/regex"
 	"github.com/dailymotion-oss/octopilot/update/sops"
+	"github.com/dailymotion-oss/octopilot/update/submodule"
+	"github.com/dailymotion-oss/octopilot/update/template"
+	"github.com/dailymotion-oss/octopilot/update/toml"
 	"github.com/dailymotion-oss/octopilot/update/value"
+	"github.com/dailymotion-oss/octopilot/update/xml"
 	"github.com/dailymotion-oss/octopilot/update/yaml"
 	"github.com/dailymotion-oss/octopilot/update/yq"
 )
@@ -34,6 +55,54 @@ type Updater interface {
 	String() string
 }
 
+// ChangeReporter is an optional interface that can be implemented by the updaters
+// which can report the changes they made to a repository - such as the old and new versions of a dependency.
+type ChangeReporter interface {
+	// Changes returns the changes made by the last run of the updater on the repository cloned at the given path
+	Changes(repoPath string) []change.Change
+}
+
+// DetailsReporter is an optional interface that can be implemented by the updaters
+// which can report more details about the changes they made to a repository - such as the commits between the old and new versions.
+type DetailsReporter interface {
+	// Details returns a markdown description of the changes made by the last run of the updater on the repository cloned at the given path
+	Details(repoPath string) string
+}
+
+// Report returns a markdown report of the changes made by the given updater to the repository cloned at the given path
+// - the list of its changes followed by their details, if any - or an empty string if the updater doesn't report its changes.
+// The report holds values read from the repository, so it must not be executed as a template.
+func Report(updater Updater, repoPath string) string {
+	var parts []string
+	if reporter, ok := updater.(ChangeReporter); ok {
+		if changes := reporter.Changes(repoPath); len(changes) > 0 {
+			lines := make([]string, 0, len(changes))
+			for _, c := range changes {
+				lines = append(lines, "- "+c.String())
+			}
+			parts = append(parts, strings.Join(lines, "\n"))
+		}
+	}
+	if reporter, ok := updater.(DetailsReporter); ok {
+		if details := reporter.Details(repoPath); len(details) > 0 {
+			parts = append(parts, details)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Changes returns the changes made by the given updaters to the repository cloned at the given path
+// - for the updaters which report their changes.
+func Changes(updaters []Updater, repoPath string) []change.Change {
+	var changes []change.Change
+	for _, updater := range updaters {
+		if reporter, ok := updater.(ChangeReporter); ok {
+			changes = append(changes, reporter.Changes(repoPath)...)
+		}
+	}
+	return changes
+}
+
 // Parse parses a set of updates defined as string - from the CLI for example - and returns properly formatted Updaters.
 // expected syntax is documented in the user documentation: docs/current-version/content/updaters/
 func Parse(updates []string) ([]Updater, error) {
@@ -52,7 +121,7 @@ func Parse(updates []string) ([]Updater, error) {
 		var paramsStr, valueStr string
 
 		switch updaterName {
-		case "exec", "yq":
+		case "exec", "yq", "template", "files", "patch", "lineinfile", "bump":
 			if len(matches) < 3 {
 				return nil, fmt.Errorf("invalid syntax for %s: found %d matches instead of 3: %v", update, len(matches), matches)
 			}
@@ -78,25 +147,9 @@ func Parse(updates []string) ([]Updater, error) {
 			return nil, fmt.Errorf("failed to parse value %s for %s: %w", valueStr, updaterName, err)
 		}
 
-		var updater Updater
-		switch updaterName {
-		case "regex":
-			updater, err = regex.NewUpdater(params, valuer)
-		case "sops":
-			updater, err = sops.NewUpdater(params, valuer)
-		case "helm":
-			updater, err = helm.NewUpdater(params, valuer)
-		case "yaml":
-			updater, err = yaml.NewUpdater(params, valuer)
-		case "yq":
-			updater, err = yq.NewUpdater(params)
-		case "exec":
-			updater, err = exec.NewUpdater(params)
-		default:
-			return nil, fmt.Errorf("unknown updater %s", updaterName)
-		}
+		updater, err := New(updaterName, params, valuer)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create an updater instance for %s: %w", updaterName, err)
+			return nil, err
 		}
 
 		updaters = append(updaters, updater)
@@ -104,3 +157,73 @@ func Parse(updates []string) ([]Updater, error) {
 
 	return updaters, nil
 }
+
+// New builds a new updater instance from its name, parameters and valuer.
+// The valuer is ignored by the updaters that don't accept a value - such as "exec" or "yq".
+func New(name string, params map[string]string, valuer value.Valuer) (Updater, error) {
+	var (
+		updater Updater
+		err     error
+	)
+	switch name {
+	case "regex":
+		updater, err = regex.NewUpdater(params, valuer)
+	case "changelog":
+		updater, err = changelog.NewUpdater(params, valuer)
+	case "gitops":
+		updater, err = gitops.NewUpdater(params, valuer)
+	case "sops":
+		updater, err = sops.NewUpdater(params, valuer)
+	case "helm":
+		updater, err = helm.NewUpdater(params, valuer)
+	case "yaml":
+		updater, err = yaml.NewUpdater(params, valuer)
+	case "json":
+		updater, err = json.NewUpdater(params, valuer)
+	case "toml":
+		updater, err = toml.NewUpdater(params, valuer)
+	case "gomod":
+		updater, err = gomod.NewUpdater(params, valuer)
+	case "dockerfile":
+		updater, err = dockerfile.NewUpdater(params, valuer)
+	case "image":
+		updater, err = image.NewUpdater(params, valuer)
+	case "actions":
+		updater, err = actions.NewUpdater(params, valuer)
+	case "hcl":
+		updater, err = hcl.NewUpdater(params, valuer)
+	case "npm":
+		updater, err = npm.NewUpdater(params, valuer)
+	case "pip":
+		updater, err = pip.NewUpdater(params, valuer)
+	case "xml":
+		updater, err = xml.NewUpdater(params, valuer)
+	case "properties":
+		updater, err = properties.NewUpdater(params, valuer)
+	case "keyvalue":
+		updater, err = keyvalue.NewUpdater(params, valuer)
+	case "submodule":
+		updater, err = submodule.NewUpdater(params, valuer)
+	case "yq":
+		updater, err = yq.NewUpdater(params)
+	case "exec":
+		updater, err = exec.NewUpdater(params)
+	case "template":
+		updater, err = template.NewUpdater(params)
+	case "files":
+		updater, err = files.NewUpdater(params)
+	case "patch":
+		updater, err = patch.NewUpdater(params)
+	case "lineinfile":
+		updater, err = lineinfile.NewUpdater(params)
+	case "bump":
+		updater, err = bump.NewUpdater(params)
+	default:
+		return nil, fmt.Errorf("unknown updater %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create an updater instance for %s: %w", name, err)
+	}
+
+	return updater, nil
+}
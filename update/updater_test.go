@@ -4,14 +4,39 @@ import (
 	"regexp"
 	"testing"
 
+	"github.com/dailymotion-oss/octopilot/update/actions"
+	"github.com/dailymotion-oss/octopilot/update/bump"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/changelog"
+	"github.com/dailymotion-oss/octopilot/update/dockerfile"
 	"github.com/dailymotion-oss/octopilot/update/exec"
+	"github.com/dailymotion-oss/octopilot/update/files"
+	"github.com/dailymotion-oss/octopilot/update/gitops"
+	"github.com/dailymotion-oss/octopilot/update/gomod"
+	"github.com/dailymotion-oss/octopilot/update/hcl"
 	"github.com/dailymotion-oss/octopilot/update/helm"
+	"github.com/dailymotion-oss/octopilot/update/image"
+	"github.com/dailymotion-oss/octopilot/update/json"
+	"github.com/dailymotion-oss/octopilot/update/keyvalue"
+	"github.com/dailymotion-oss/octopilot/update/lineinfile"
+	"github.com/dailymotion-oss/octopilot/update/npm"
+	"github.com/dailymotion-oss/octopilot/update/patch"
+	"github.com/dailymotion-oss/octopilot/update/pip"
+	"github.com/dailymotion-oss/octopilot/update/properties"
 	"github.com/dailymotion-oss/octopilot/update/regex"
 	"github.com/dailymotion-oss/octopilot/update/sops"
+	"github.com/dailymotion-oss/octopilot/update/submodule"
+	"github.com/dailymotion-oss/octopilot/update/template"
+	"github.com/dailymotion-oss/octopilot/update/toml"
 	"github.com/dailymotion-oss/octopilot/update/value"
+	"github.com/dailymotion-oss/octopilot/update/xml"
 	"github.com/dailymotion-oss/octopilot/update/yaml"
 	"github.com/dailymotion-oss/octopilot/update/yq"
 
+	internaljson "github.com/dailymotion-oss/octopilot/internal/json"
+	internaltoml "github.com/dailymotion-oss/octopilot/internal/toml"
+	internalxml "github.com/dailymotion-oss/octopilot/internal/xml"
+
 	"github.com/mikefarah/yq/v4/pkg/yqlib"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -19,6 +44,11 @@ import (
 
 func TestParse(t *testing.T) {
 	t.Parallel()
+	jsonSelector, err := internaljson.ParseSelector("$.dependencies['@org/ui']")
+	require.NoError(t, err)
+	jsonNumber := internaljson.Number
+	xpath, err := internalxml.ParseXPath("//dependency[artifactId='my-lib']/version")
+	require.NoError(t, err)
 	tests := []struct {
 		name             string
 		updates          []string
@@ -88,6 +118,64 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:    "single files updater",
+			updates: []string{"files(op=copy,repo=org/templates,ref=v1,src=.github/workflows/*.yml,dest=.github/workflows)"},
+			expected: []Updater{
+				&files.FilesUpdater{
+					Operation: files.OperationCopy,
+					Source:    ".github/workflows/*.yml",
+					Dest:      ".github/workflows",
+					Repo:      "org/templates",
+					Ref:       "v1",
+				},
+			},
+		},
+		{
+			name:    "single patch updater",
+			updates: []string{"patch(file=/tmp/changes.patch,fuzz=2,strip=1)"},
+			expected: []Updater{
+				&patch.PatchUpdater{
+					FilePath: "/tmp/changes.patch",
+					Fuzz:     2,
+					Strip:    1,
+				},
+			},
+		},
+		{
+			name:    "single lineinfile updater",
+			updates: []string{"lineinfile(file=.gitignore,line=*.bak,after=^/bin/$)"},
+			expected: []Updater{
+				&lineinfile.LineInFileUpdater{
+					FilePath:    ".gitignore",
+					Line:        "*.bak",
+					After:       "^/bin/$",
+					AfterRegexp: regexp.MustCompile(`^/bin/$`),
+					State:       lineinfile.StatePresent,
+				},
+			},
+		},
+		{
+			name:    "single bump updater",
+			updates: []string{"bump(file=charts/*/Chart.yaml,path=version,part=minor)"},
+			expected: []Updater{
+				&bump.BumpUpdater{
+					FilePath: "charts/*/Chart.yaml",
+					Path:     "version",
+					Part:     bump.PartMinor,
+				},
+			},
+		},
+		{
+			name:    "single template updater",
+			updates: []string{"template(src=/templates/CODEOWNERS.tpl,dest=.github/CODEOWNERS)"},
+			expected: []Updater{
+				&template.TemplateUpdater{
+					SourcePath: "/templates/CODEOWNERS.tpl",
+					DestPath:   ".github/CODEOWNERS",
+				},
+			},
+		},
 		{
 			name:    "single yq updater",
 			updates: []string{`yq(file=values.yaml,expression='.path.to.subkey = "value"')`},
@@ -115,6 +203,30 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:    "single changelog updater",
+			updates: []string{`changelog(file=CHANGELOG.md,section=Changed)=Bump lib to 1.2.3`},
+			expected: []Updater{
+				&changelog.ChangelogUpdater{
+					FilePath: "CHANGELOG.md",
+					Section:  "Changed",
+					Valuer:   value.StringValuer("Bump lib to 1.2.3"),
+				},
+			},
+		},
+		{
+			name:    "single gitops updater",
+			updates: []string{`gitops(file=apps/*.yaml,kind=Application,name=my-app)=1.2.3`},
+			expected: []Updater{
+				&gitops.GitOpsUpdater{
+					FilePath: "apps/*.yaml",
+					Kind:     "Application",
+					Name:     "my-app",
+					Indent:   2,
+					Valuer:   value.StringValuer("1.2.3"),
+				},
+			},
+		},
 		{
 			name:    "single regex deleter",
 			updates: []string{`regex(file=helmfile.yaml,pattern='chart: example/my-chart\s+version: \"(.*)\"')=`},
@@ -140,6 +252,160 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:    "single toml updater",
+			updates: []string{`toml(file=Cargo.toml,path=package.version,create=true)=1.2.3`},
+			expected: []Updater{
+				&toml.TomlUpdater{
+					FilePath:   "Cargo.toml",
+					Path:       "package.version",
+					ParsedPath: internaltoml.Path{{Key: "package"}, {Key: "version"}},
+					AutoCreate: true,
+					Valuer:     value.StringValuer("1.2.3"),
+				},
+			},
+		},
+		{
+			name:    "single json updater",
+			updates: []string{`json(file=package.json,path=$.dependencies['@org/ui'],type=number)=2`},
+			expected: []Updater{
+				&json.JsonUpdater{
+					FilePath: "package.json",
+					Path:     "$.dependencies['@org/ui']",
+					Selector: jsonSelector,
+					Type:     &jsonNumber,
+					Valuer:   value.StringValuer("2"),
+				},
+			},
+		},
+		{
+			name:    "single gomod updater",
+			updates: []string{`gomod(module=github.com/org/lib,file=**/go.mod,sum=drop)=v1.2.3`},
+			expected: []Updater{
+				&gomod.GoModUpdater{
+					Module:   "github.com/org/lib",
+					FilePath: "**/go.mod",
+					Sum:      gomod.SumDrop,
+					Valuer:   value.StringValuer("v1.2.3"),
+				},
+			},
+		},
+		{
+			name:    "single dockerfile updater",
+			updates: []string{`dockerfile(file=**/Dockerfile,image=registry.example.com/org/base)=1.2.3`},
+			expected: []Updater{
+				&dockerfile.DockerfileUpdater{
+					FilePath: "**/Dockerfile",
+					Image:    "registry.example.com/org/base",
+					Valuer:   value.StringValuer("1.2.3"),
+				},
+			},
+		},
+		{
+			name:    "single image updater",
+			updates: []string{`image(file=k8s/**/*.yaml,name=registry.example.com/org/app)=1.2.3`},
+			expected: []Updater{
+				&image.ImageUpdater{
+					FilePath: "k8s/**/*.yaml",
+					Name:     "registry.example.com/org/app",
+					Indent:   2,
+					Valuer:   value.StringValuer("1.2.3"),
+				},
+			},
+		},
+		{
+			name:    "single actions updater",
+			updates: []string{`actions(uses=actions/checkout,file=.github/workflows/ci.yml,pin=always)=v4.1.1`},
+			expected: []Updater{
+				&actions.ActionsUpdater{
+					FilePaths: []string{".github/workflows/ci.yml"},
+					Uses:      "actions/checkout",
+					Pin:       actions.PinAlways,
+					Valuer:    value.StringValuer("v4.1.1"),
+				},
+			},
+		},
+		{
+			name:    "single hcl updater",
+			updates: []string{`hcl(file=**/*.tf,block=module.vpc,attribute=version)=5.2.0`},
+			expected: []Updater{
+				&hcl.HclUpdater{
+					FilePath:  "**/*.tf",
+					Block:     "module.vpc",
+					Attribute: "version",
+					Valuer:    value.StringValuer("5.2.0"),
+				},
+			},
+		},
+		{
+			name:    "single npm updater",
+			updates: []string{`npm(file=**/package.json,package=@org/ui)=2.3.0`},
+			expected: []Updater{
+				&npm.NpmUpdater{
+					FilePath: "**/package.json",
+					Package:  "@org/ui",
+					Valuer:   value.StringValuer("2.3.0"),
+				},
+			},
+		},
+		{
+			name:    "single pip updater",
+			updates: []string{`pip(file=**/requirements*.txt,package=my-lib)=1.3.0`},
+			expected: []Updater{
+				&pip.PipUpdater{
+					FilePaths: []string{"**/requirements*.txt"},
+					Package:   "my-lib",
+					Valuer:    value.StringValuer("1.3.0"),
+				},
+			},
+		},
+		{
+			name:    "single xml updater",
+			updates: []string{`xml(file=**/pom.xml,xpath=//dependency[artifactId='my-lib']/version)=1.1.0`},
+			expected: []Updater{
+				&xml.XmlUpdater{
+					FilePath: "**/pom.xml",
+					Path:     "//dependency[artifactId='my-lib']/version",
+					XPath:    xpath,
+					Valuer:   value.StringValuer("1.1.0"),
+				},
+			},
+		},
+		{
+			name:    "single properties updater",
+			updates: []string{`properties(file=gradle.properties,key=kotlinVersion,create=true)=1.9.22`},
+			expected: []Updater{
+				&properties.PropertiesUpdater{
+					FilePath:   "gradle.properties",
+					Key:        "kotlinVersion",
+					AutoCreate: true,
+					Valuer:     value.StringValuer("1.9.22"),
+				},
+			},
+		},
+		{
+			name:    "single submodule updater",
+			updates: []string{`submodule(path=vendor/protos)=v1.4.0`},
+			expected: []Updater{
+				&submodule.SubmoduleUpdater{
+					Path:   "vendor/protos",
+					Valuer: value.StringValuer("v1.4.0"),
+				},
+			},
+		},
+		{
+			name:    "single keyvalue updater",
+			updates: []string{`keyvalue(file=setup.cfg,key=version,section=metadata,format=ini)=1.2.3`},
+			expected: []Updater{
+				&keyvalue.KeyValueUpdater{
+					FilePath: "setup.cfg",
+					Key:      "version",
+					Section:  "metadata",
+					Format:   keyvalue.FormatIni,
+					Valuer:   value.StringValuer("1.2.3"),
+				},
+			},
+		},
 		{
 			name: "regex and sops updaters",
 			updates: []string{
@@ -179,3 +445,86 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	goModUpdater := &gomod.GoModUpdater{
+		Module:   "github.com/org/lib",
+		FilePath: "go.mod",
+	}
+	goModUpdater.Record("/tmp/repo", change.Change{File: "go.mod", Name: "github.com/org/lib", Old: "v1.0.0", New: "v1.1.0"})
+
+	tests := []struct {
+		name     string
+		updater  Updater
+		repoPath string
+		expected string
+	}{
+		{
+			name:     "updater without changes reporting",
+			updater:  &exec.ExecUpdater{Command: "make"},
+			repoPath: "/tmp/repo",
+		},
+		{
+			name:     "updater with changes",
+			updater:  goModUpdater,
+			repoPath: "/tmp/repo",
+			expected: "- `go.mod`: `github.com/org/lib` from `v1.0.0` to `v1.1.0`",
+		},
+		{
+			name:     "updater without changes for the repository",
+			updater:  goModUpdater,
+			repoPath: "/tmp/another-repo",
+		},
+		{
+			name: "updater with details",
+			updater: detailsUpdater{
+				ExecUpdater: &exec.ExecUpdater{Command: "make"},
+				details:     "Commits:\n\n- `abcdef1` Fix bug",
+			},
+			repoPath: "/tmp/repo",
+			expected: "Commits:\n\n- `abcdef1` Fix bug",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected, Report(test.updater, test.repoPath))
+		})
+	}
+}
+
+func TestChanges(t *testing.T) {
+	t.Parallel()
+
+	goModUpdater := &gomod.GoModUpdater{
+		Module:   "github.com/org/lib",
+		FilePath: "go.mod",
+	}
+	goModUpdater.Record("/tmp/repo", change.Change{File: "go.mod", Name: "github.com/org/lib", Old: "v1.0.0", New: "v1.1.0"})
+	bumpUpdater := &bump.BumpUpdater{
+		FilePath: "VERSION",
+		Part:     bump.PartMinor,
+	}
+	bumpUpdater.Record("/tmp/repo", change.Change{File: "VERSION", Name: "version", Old: "1.0.0", New: "1.1.0"})
+	updaters := []Updater{goModUpdater, &exec.ExecUpdater{Command: "make"}, bumpUpdater}
+
+	assert.Equal(t, []change.Change{
+		{File: "go.mod", Name: "github.com/org/lib", Old: "v1.0.0", New: "v1.1.0"},
+		{File: "VERSION", Name: "version", Old: "1.0.0", New: "1.1.0"},
+	}, Changes(updaters, "/tmp/repo"))
+	assert.Empty(t, Changes(updaters, "/tmp/another-repo"))
+}
+
+// detailsUpdater is an updater which reports details about its changes
+type detailsUpdater struct {
+	*exec.ExecUpdater
+	details string
+}
+
+func (u detailsUpdater) Details(_ string) string {
+	return u.details
+}
@@ -0,0 +1,47 @@
+package value
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// EnvValuer is a valuer that returns the value of an environment variable.
+// The variable is read when the value is retrieved - and not when the valuer is built.
+type EnvValuer struct {
+	Name    string
+	Default string
+	// Required makes the valuer fail if the environment variable is not set - instead of returning the default value
+	Required bool
+}
+
+func newEnvValuer(params map[string]string) (*EnvValuer, error) {
+	valuer := &EnvValuer{}
+
+	valuer.Name = params["name"]
+	if len(valuer.Name) == 0 {
+		return nil, errors.New("missing name parameter")
+	}
+
+	valuer.Default = params["default"]
+	valuer.Required, _ = strconv.ParseBool(params["required"])
+	if valuer.Required && len(valuer.Default) > 0 {
+		return nil, errors.New("invalid parameters: required and default can't be used together")
+	}
+
+	return valuer, nil
+}
+
+// Value returns the value to replace while updating files in the given repository.
+func (v EnvValuer) Value(_ context.Context, _ string) (string, error) {
+	value, found := os.LookupEnv(v.Name)
+	if !found {
+		if v.Required {
+			return "", fmt.Errorf("environment variable %s is not set", v.Name)
+		}
+		return v.Default, nil
+	}
+	return value, nil
+}
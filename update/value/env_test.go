@@ -0,0 +1,62 @@
+package value
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvValuerValue(t *testing.T) {
+	// not parallel, because it changes the environment
+	t.Setenv("OCTOPILOT_TEST_VERSION", "1.2.3,rc(1)")
+	t.Setenv("OCTOPILOT_TEST_EMPTY", "")
+
+	tests := []struct {
+		name             string
+		valuer           EnvValuer
+		expected         string
+		expectedErrorMsg string
+	}{
+		{
+			name:     "variable set",
+			valuer:   EnvValuer{Name: "OCTOPILOT_TEST_VERSION", Default: "0.0.0"},
+			expected: "1.2.3,rc(1)",
+		},
+		{
+			name:     "variable set to an empty value",
+			valuer:   EnvValuer{Name: "OCTOPILOT_TEST_EMPTY", Required: true},
+			expected: "",
+		},
+		{
+			name:     "variable not set with default",
+			valuer:   EnvValuer{Name: "OCTOPILOT_TEST_MISSING", Default: "0.0.0"},
+			expected: "0.0.0",
+		},
+		{
+			name:     "variable not set without default",
+			valuer:   EnvValuer{Name: "OCTOPILOT_TEST_MISSING"},
+			expected: "",
+		},
+		{
+			name:             "required variable not set",
+			valuer:           EnvValuer{Name: "OCTOPILOT_TEST_MISSING", Required: true},
+			expectedErrorMsg: "environment variable OCTOPILOT_TEST_MISSING is not set",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := test.valuer.Value(context.Background(), ".")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Empty(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package value
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cosiner/argv"
+)
+
+// ExecValuer is a valuer that returns the output of an external command, executed in the cloned repository.
+type ExecValuer struct {
+	Command string
+	Path    string
+	Args    []string
+	Timeout time.Duration
+}
+
+func newExecValuer(params map[string]string) (*ExecValuer, error) {
+	valuer := &ExecValuer{}
+
+	valuer.Command = params["cmd"]
+	if len(valuer.Command) == 0 {
+		return nil, errors.New("missing cmd parameter")
+	}
+
+	valuer.Path = params["path"]
+
+	if args, ok := params["args"]; ok {
+		argv, err := argv.Argv(args, func(backquoted string) (string, error) {
+			return backquoted, nil
+		}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert args '%s' with argv: %w", args, err)
+		}
+		if len(argv) > 0 {
+			valuer.Args = argv[0]
+		}
+	}
+
+	timeout := params["timeout"]
+	if len(timeout) > 0 {
+		var err error
+		valuer.Timeout, err = time.ParseDuration(timeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse duration for cmd timeout '%s': %w", timeout, err)
+		}
+	}
+
+	return valuer, nil
+}
+
+// Value returns the value to replace while updating files in the given repository.
+func (v ExecValuer) Value(ctx context.Context, repoPath string) (string, error) {
+	if v.Timeout > 0 {
+		var cancelFunc context.CancelFunc
+		ctx, cancelFunc = context.WithTimeout(ctx, v.Timeout)
+		defer cancelFunc()
+	}
+
+	var (
+		stdout bytes.Buffer
+		stderr bytes.Buffer
+	)
+	cmd := exec.CommandContext(ctx, v.Command, v.Args...)
+	cmd.Dir = filepath.Join(repoPath, v.Path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to run cmd '%s' with args %v in path: %s - got stdout [%s] and stderr [%s]: %w", v.Command, v.Args, v.Path, strings.TrimSpace(stdout.String()), strings.TrimSpace(stderr.String()), err)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
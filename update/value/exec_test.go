@@ -0,0 +1,70 @@
+package value
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecValuerValue(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		valuer           ExecValuer
+		expected         string
+		expectedErrorMsg string
+	}{
+		{
+			name: "trimmed stdout",
+			valuer: ExecValuer{
+				Command: "sh",
+				Args:    []string{"-c", "echo '  1.2.3  '; echo 'some warning' >&2"},
+			},
+			expected: "1.2.3",
+		},
+		{
+			name: "run in the repository",
+			valuer: ExecValuer{
+				Command: "cat",
+				Args:    []string{"test.txt"},
+			},
+			expected: "some content",
+		},
+		{
+			name: "failing command",
+			valuer: ExecValuer{
+				Command: "sh",
+				Args:    []string{"-c", "echo 'partial'; echo 'something went wrong' >&2; exit 3"},
+			},
+			expectedErrorMsg: "failed to run cmd 'sh' with args [-c echo 'partial'; echo 'something went wrong' >&2; exit 3] in path:  - got stdout [partial] and stderr [something went wrong]: exit status 3",
+		},
+		{
+			name: "timeout",
+			valuer: ExecValuer{
+				Command: "sleep",
+				Args:    []string{"5"},
+				Timeout: 10 * time.Millisecond,
+			},
+			expectedErrorMsg: "failed to run cmd 'sleep' with args [5] in path:  - got stdout [] and stderr []: signal: killed",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := test.valuer.Value(context.Background(), filepath.Join(".", "testdata"))
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Empty(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package value
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/go-github/v57/github"
+
+	"github.com/dailymotion-oss/octopilot/internal/ghclient"
+)
+
+// GitHubReleaseValuer is a valuer that returns the latest release of a GitHub repository - the one with the highest semantic version.
+// The release is retrieved once, and then shared by all the repositories to update.
+type GitHubReleaseValuer struct {
+	Repository  string
+	Prefix      string
+	Constraint  string
+	Constraints *semver.Constraints
+	Prerelease  bool
+
+	mutex sync.Mutex
+	value string
+}
+
+// GitHubTagValuer is a valuer that returns the latest tag of a GitHub repository - the one with the highest semantic version.
+// The tag is retrieved once, and then shared by all the repositories to update.
+type GitHubTagValuer struct {
+	Repository string
+	// Pattern is the regexp matching the tags - if it has a capturing group, the value is the captured text
+	Pattern     string
+	Regexp      *regexp.Regexp
+	Prefix      string
+	Constraint  string
+	Constraints *semver.Constraints
+	Prerelease  bool
+
+	mutex sync.Mutex
+	value string
+}
+
+// versionFilter filters and sorts the tags of a repository, to find the latest version.
+type versionFilter struct {
+	regexp      *regexp.Regexp
+	prefix      string
+	constraints *semver.Constraints
+	prerelease  bool
+}
+
+func newGitHubReleaseValuer(params map[string]string) (*GitHubReleaseValuer, error) {
+	valuer := &GitHubReleaseValuer{}
+
+	var err error
+	valuer.Repository, err = repositoryParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	valuer.Prefix = params["prefix"]
+	valuer.Constraint = params["constraint"]
+	valuer.Constraints, err = constraintParam(valuer.Constraint)
+	if err != nil {
+		return nil, err
+	}
+	valuer.Prerelease, _ = strconv.ParseBool(params["prerelease"])
+
+	return valuer, nil
+}
+
+func newGitHubTagValuer(params map[string]string) (*GitHubTagValuer, error) {
+	valuer := &GitHubTagValuer{}
+
+	var err error
+	valuer.Repository, err = repositoryParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	valuer.Pattern = params["pattern"]
+	if len(valuer.Pattern) > 0 {
+		valuer.Regexp, err = regexp.Compile(valuer.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", valuer.Pattern, err)
+		}
+		if subexp := valuer.Regexp.NumSubexp(); subexp > 1 {
+			return nil, fmt.Errorf("invalid pattern %s: it must have at most one parenthesized subexpression, but it has %d", valuer.Pattern, subexp)
+		}
+	}
+
+	valuer.Prefix = params["prefix"]
+	valuer.Constraint = params["constraint"]
+	valuer.Constraints, err = constraintParam(valuer.Constraint)
+	if err != nil {
+		return nil, err
+	}
+	valuer.Prerelease, _ = strconv.ParseBool(params["prerelease"])
+
+	return valuer, nil
+}
+
+// Value returns the value to replace while updating files in the given repository.
+func (v *GitHubReleaseValuer) Value(ctx context.Context, _ string) (string, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if len(v.value) > 0 {
+		return v.value, nil
+	}
+
+	client, err := ghclient.FromContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve the releases of %s: %w", v.Repository, err)
+	}
+	owner, repo, _ := strings.Cut(v.Repository, "/")
+
+	var tags []string
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		releases, resp, err := client.Repositories.ListReleases(ctx, owner, repo, opts)
+		if err != nil {
+			return "", fmt.Errorf("failed to retrieve the releases of %s: %w", v.Repository, err)
+		}
+		for _, release := range releases {
+			if release.GetDraft() || (release.GetPrerelease() && !v.Prerelease) {
+				continue
+			}
+			tags = append(tags, release.GetTagName())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	filter := versionFilter{prefix: v.Prefix, constraints: v.Constraints, prerelease: v.Prerelease}
+	value, found := filter.latest(tags)
+	if !found {
+		return "", fmt.Errorf("no release of %s matching %s", v.Repository, filter)
+	}
+	v.value = value
+	return v.value, nil
+}
+
+// Value returns the value to replace while updating files in the given repository.
+func (v *GitHubTagValuer) Value(ctx context.Context, _ string) (string, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if len(v.value) > 0 {
+		return v.value, nil
+	}
+
+	client, err := ghclient.FromContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve the tags of %s: %w", v.Repository, err)
+	}
+	owner, repo, _ := strings.Cut(v.Repository, "/")
+
+	var tags []string
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		repoTags, resp, err := client.Repositories.ListTags(ctx, owner, repo, opts)
+		if err != nil {
+			return "", fmt.Errorf("failed to retrieve the tags of %s: %w", v.Repository, err)
+		}
+		for _, tag := range repoTags {
+			tags = append(tags, tag.GetName())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	filter := versionFilter{regexp: v.Regexp, prefix: v.Prefix, constraints: v.Constraints, prerelease: v.Prerelease}
+	value, found := filter.latest(tags)
+	if !found {
+		return "", fmt.Errorf("no tag of %s matching %s", v.Repository, filter)
+	}
+	v.value = value
+	return v.value, nil
+}
+
+// latest returns the version with the highest semantic version among the given tags - without the prefix.
+// The tags which are not semantic versions are ignored.
+func (f versionFilter) latest(tags []string) (string, bool) {
+	var (
+		latestValue   string
+		latestVersion *semver.Version
+	)
+	for _, tag := range tags {
+		value := tag
+		if f.regexp != nil {
+			matches := f.regexp.FindStringSubmatch(value)
+			if matches == nil {
+				continue
+			}
+			if len(matches) > 1 {
+				value = matches[1]
+			}
+		}
+		if !strings.HasPrefix(value, f.prefix) {
+			continue
+		}
+		value = strings.TrimPrefix(value, f.prefix)
+
+		version, err := semver.NewVersion(value)
+		if err != nil {
+			continue
+		}
+		if f.constraints != nil {
+			if !f.constraints.Check(version) {
+				continue
+			}
+		} else if len(version.Prerelease()) > 0 && !f.prerelease {
+			continue
+		}
+		if latestVersion == nil || version.GreaterThan(latestVersion) {
+			latestValue, latestVersion = value, version
+		}
+	}
+	return latestValue, latestVersion != nil
+}
+
+// String returns a human-readable description of the filter - for the error messages.
+func (f versionFilter) String() string {
+	var criteria []string
+	if f.regexp != nil {
+		criteria = append(criteria, fmt.Sprintf("pattern %s", f.regexp))
+	}
+	if len(f.prefix) > 0 {
+		criteria = append(criteria, fmt.Sprintf("prefix %s", f.prefix))
+	}
+	if f.constraints != nil {
+		criteria = append(criteria, fmt.Sprintf("constraint %s", f.constraints))
+	}
+	if len(criteria) == 0 {
+		return "a semantic version"
+	}
+	return strings.Join(criteria, " and ")
+}
+
+func repositoryParam(params map[string]string) (string, error) {
+	repository := params["repo"]
+	if len(repository) == 0 {
+		return "", errors.New("missing repo parameter")
+	}
+	if owner, repo, found := strings.Cut(repository, "/"); !found || len(owner) == 0 || len(repo) == 0 || strings.Contains(repo, "/") {
+		return "", fmt.Errorf("invalid repo parameter %s: must be in the owner/repo format", repository)
+	}
+	return repository, nil
+}
+
+func constraintParam(constraint string) (*semver.Constraints, error) {
+	if len(constraint) == 0 {
+		return nil, nil
+	}
+	constraints, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid constraint parameter %s: %w", constraint, err)
+	}
+	return constraints, nil
+}
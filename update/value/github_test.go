@@ -0,0 +1,210 @@
+package value
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dailymotion-oss/octopilot/internal/ghclient"
+)
+
+func TestGitHubReleaseValuerValue(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/org/lib/releases" && r.URL.Query().Get("page") == "2":
+			fmt.Fprint(w, `[
+				{"tag_name": "v1.4.2"},
+				{"tag_name": "v1.3.0"}
+			]`)
+		case r.URL.Path == "/repos/org/lib/releases":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/org/lib/releases?page=2>; rel="next"`, "http://"+r.Host))
+			fmt.Fprint(w, `[
+				{"tag_name": "v2.0.0", "draft": true},
+				{"tag_name": "v1.5.0-rc.1", "prerelease": true},
+				{"tag_name": "nightly"},
+				{"tag_name": "v1.4.10"}
+			]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	ctx := ghclient.NewContext(context.Background(), client)
+
+	tests := []struct {
+		name             string
+		valuer           *GitHubReleaseValuer
+		ctx              context.Context
+		expected         string
+		expectedErrorMsg string
+	}{
+		{
+			name:     "latest release",
+			valuer:   &GitHubReleaseValuer{Repository: "org/lib"},
+			ctx:      ctx,
+			expected: "v1.4.10",
+		},
+		{
+			name:     "latest prerelease",
+			valuer:   &GitHubReleaseValuer{Repository: "org/lib", Prerelease: true},
+			ctx:      ctx,
+			expected: "v1.5.0-rc.1",
+		},
+		{
+			name:     "latest release with prefix",
+			valuer:   &GitHubReleaseValuer{Repository: "org/lib", Prefix: "v"},
+			ctx:      ctx,
+			expected: "1.4.10",
+		},
+		{
+			name:     "latest release matching constraint",
+			valuer:   &GitHubReleaseValuer{Repository: "org/lib", Constraint: "~1.3", Constraints: mustConstraint("~1.3")},
+			ctx:      ctx,
+			expected: "v1.3.0",
+		},
+		{
+			name:             "no release matching constraint",
+			valuer:           &GitHubReleaseValuer{Repository: "org/lib", Constraint: "^3", Constraints: mustConstraint("^3")},
+			ctx:              ctx,
+			expectedErrorMsg: "no release of org/lib matching constraint ^3",
+		},
+		{
+			name:             "unknown repository",
+			valuer:           &GitHubReleaseValuer{Repository: "org/unknown"},
+			ctx:              ctx,
+			expectedErrorMsg: fmt.Sprintf("failed to retrieve the releases of org/unknown: GET %s/repos/org/unknown/releases?per_page=100: 404 Not Found []", server.URL),
+		},
+		{
+			name:             "no github client",
+			valuer:           &GitHubReleaseValuer{Repository: "org/lib"},
+			ctx:              context.Background(),
+			expectedErrorMsg: "failed to retrieve the releases of org/lib: no GitHub client available",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := test.valuer.Value(test.ctx, ".")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Empty(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+
+	t.Run("cached release", func(t *testing.T) {
+		t.Parallel()
+		valuer := &GitHubReleaseValuer{Repository: "org/lib"}
+		for i := 0; i < 3; i++ {
+			actual, err := valuer.Value(ctx, fmt.Sprintf("/tmp/repo-%d", i))
+			require.NoError(t, err)
+			assert.Equal(t, "v1.4.10", actual)
+		}
+		assert.Equal(t, "v1.4.10", valuer.value)
+	})
+}
+
+func TestGitHubTagValuerValue(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/monorepo/tags":
+			requests.Add(1)
+			fmt.Fprint(w, `[
+				{"name": "lib/v1.2.0"},
+				{"name": "lib/v1.10.0"},
+				{"name": "lib/v2.0.0-beta.1"},
+				{"name": "cli/v3.0.0"},
+				{"name": "latest"}
+			]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	ctx := ghclient.NewContext(context.Background(), client)
+
+	tests := []struct {
+		name             string
+		valuer           *GitHubTagValuer
+		expected         string
+		expectedErrorMsg string
+	}{
+		{
+			name:             "tags which are not semantic versions",
+			valuer:           &GitHubTagValuer{Repository: "org/monorepo"},
+			expectedErrorMsg: "no tag of org/monorepo matching a semantic version",
+		},
+		{
+			name:     "latest tag with prefix",
+			valuer:   &GitHubTagValuer{Repository: "org/monorepo", Prefix: "lib/v"},
+			expected: "1.10.0",
+		},
+		{
+			name:     "latest prerelease tag matching pattern",
+			valuer:   &GitHubTagValuer{Repository: "org/monorepo", Pattern: "^lib/(.*)$", Regexp: regexp.MustCompile("^lib/(.*)$"), Prerelease: true},
+			expected: "v2.0.0-beta.1",
+		},
+		{
+			name:     "latest tag matching pattern and constraint",
+			valuer:   &GitHubTagValuer{Repository: "org/monorepo", Pattern: "^lib/", Regexp: regexp.MustCompile("^lib/"), Prefix: "lib/", Constraint: "<1.5", Constraints: mustConstraint("<1.5")},
+			expected: "v1.2.0",
+		},
+		{
+			name:             "no tag matching pattern",
+			valuer:           &GitHubTagValuer{Repository: "org/monorepo", Pattern: "^api/", Regexp: regexp.MustCompile("^api/"), Prefix: "api/v"},
+			expectedErrorMsg: "no tag of org/monorepo matching pattern ^api/ and prefix api/v",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := test.valuer.Value(ctx, ".")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Empty(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+
+	t.Run("cached tag", func(t *testing.T) {
+		valuer := &GitHubTagValuer{Repository: "org/monorepo", Prefix: "lib/"}
+		before := requests.Load()
+		for i := 0; i < 3; i++ {
+			actual, err := valuer.Value(ctx, fmt.Sprintf("/tmp/repo-%d", i))
+			require.NoError(t, err)
+			assert.Equal(t, "v1.10.0", actual)
+		}
+		assert.Equal(t, before+1, requests.Load())
+	})
+}
@@ -0,0 +1,202 @@
+package value
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/ybbus/httpretry"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dailymotion-oss/octopilot/internal/registry"
+)
+
+// helmIndexes are the indexes of the HTTP Helm repositories - by URL - downloaded once,
+// and then shared by all the valuers using the same repository.
+var helmIndexes = struct {
+	sync.Mutex
+	indexes map[string]*helmIndexEntry
+}{}
+
+// helmIndexEntry holds the index of a single repository, with its own lock - so that the indexes of different repositories
+// are downloaded concurrently, while the index of the same repository is downloaded only once.
+type helmIndexEntry struct {
+	sync.Mutex
+	index *helmIndex
+}
+
+// helmIndex is the index.yaml file of a Helm repository.
+type helmIndex struct {
+	Entries map[string][]struct {
+		Version    string `yaml:"version"`
+		Deprecated bool   `yaml:"deprecated"`
+	} `yaml:"entries"`
+}
+
+// HelmRepoValuer is a valuer that returns the latest version of a chart hosted in a Helm repository - the one with the highest semantic version.
+// Both the HTTP repositories - with an index.yaml file - and the OCI-based repositories are supported.
+// The version is retrieved once, and then shared by all the repositories to update.
+type HelmRepoValuer struct {
+	// URL is the URL of the repository - such as https://charts.example.com or oci://registry.example.com/charts
+	URL         string
+	Chart       string
+	Constraint  string
+	Constraints *semver.Constraints
+	Prerelease  bool
+
+	httpClient     *http.Client
+	registryClient *registry.Client
+	mutex          sync.Mutex
+	value          string
+}
+
+func newHelmRepoValuer(params map[string]string) (*HelmRepoValuer, error) {
+	valuer := &HelmRepoValuer{}
+
+	valuer.URL = strings.TrimSuffix(params["url"], "/")
+	if len(valuer.URL) == 0 {
+		return nil, errors.New("missing url parameter")
+	}
+	repoURL, err := url.Parse(valuer.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url parameter %s: %w", valuer.URL, err)
+	}
+	switch repoURL.Scheme {
+	case "http", "https", "oci":
+	default:
+		return nil, fmt.Errorf("invalid url parameter %s: must be an http, https or oci URL", valuer.URL)
+	}
+
+	valuer.Chart = params["chart"]
+	if len(valuer.Chart) == 0 {
+		return nil, errors.New("missing chart parameter")
+	}
+
+	valuer.Constraint = params["constraint"]
+	valuer.Constraints, err = constraintParam(valuer.Constraint)
+	if err != nil {
+		return nil, err
+	}
+	valuer.Prerelease, _ = strconv.ParseBool(params["prerelease"])
+
+	return valuer, nil
+}
+
+// Value returns the value to replace while updating files in the given repository.
+func (v *HelmRepoValuer) Value(ctx context.Context, _ string) (string, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if len(v.value) > 0 {
+		return v.value, nil
+	}
+
+	var (
+		versions []string
+		err      error
+	)
+	if strings.HasPrefix(v.URL, "oci://") {
+		versions, err = v.ociVersions(ctx)
+	} else {
+		versions, err = v.indexVersions(ctx)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve the versions of chart %s from %s: %w", v.Chart, v.URL, err)
+	}
+
+	filter := versionFilter{constraints: v.Constraints, prerelease: v.Prerelease}
+	value, found := filter.latest(versions)
+	if !found {
+		return "", fmt.Errorf("no version of chart %s in %s matching %s", v.Chart, v.URL, filter)
+	}
+	v.value = value
+	return v.value, nil
+}
+
+// indexVersions returns the versions of the chart - except the deprecated ones - listed in the index of the HTTP repository.
+func (v *HelmRepoValuer) indexVersions(ctx context.Context) ([]string, error) {
+	index, err := v.index(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries, found := index.Entries[v.Chart]
+	if !found {
+		return nil, fmt.Errorf("chart %s not found in the repository index", v.Chart)
+	}
+	versions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Deprecated {
+			versions = append(versions, entry.Version)
+		}
+	}
+	return versions, nil
+}
+
+// ociVersions returns the versions of the chart stored in the OCI-based repository.
+func (v *HelmRepoValuer) ociVersions(ctx context.Context) ([]string, error) {
+	if v.registryClient == nil {
+		v.registryClient = registry.NewClient()
+	}
+	host, repository := registry.ParseRepository(v.URL + "/" + v.Chart)
+	tags, err := v.registryClient.Tags(ctx, host, repository)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		// helm replaces the + of the build metadata by an _, which is not allowed in tags
+		versions = append(versions, strings.ReplaceAll(tag, "_", "+"))
+	}
+	return versions, nil
+}
+
+// index returns the index of the HTTP repository - downloading it only if no other valuer already did.
+func (v *HelmRepoValuer) index(ctx context.Context) (*helmIndex, error) {
+	helmIndexes.Lock()
+	entry, found := helmIndexes.indexes[v.URL]
+	if !found {
+		if helmIndexes.indexes == nil {
+			helmIndexes.indexes = make(map[string]*helmIndexEntry)
+		}
+		entry = &helmIndexEntry{}
+		helmIndexes.indexes[v.URL] = entry
+	}
+	helmIndexes.Unlock()
+
+	entry.Lock()
+	defer entry.Unlock()
+	// the index is not set if a previous download failed - so that it can be retried
+	if entry.index != nil {
+		return entry.index, nil
+	}
+
+	if v.httpClient == nil {
+		v.httpClient = httpretry.NewDefaultClient()
+	}
+	indexURL := v.URL + "/index.yaml"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, indexURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %s: %w", indexURL, err)
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", indexURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", indexURL, resp.Status)
+	}
+
+	index := &helmIndex{}
+	if err = yaml.NewDecoder(resp.Body).Decode(index); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", indexURL, err)
+	}
+
+	entry.index = index
+	return index, nil
+}
@@ -0,0 +1,246 @@
+package value
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dailymotion-oss/octopilot/internal/registry"
+	"github.com/dailymotion-oss/octopilot/internal/registry/registrytest"
+)
+
+func TestHelmRepoValuerValueWithHTTPRepository(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/charts/index.yaml":
+			requests.Add(1)
+			fmt.Fprint(w, `apiVersion: v1
+entries:
+  app:
+  - name: app
+    version: 2.0.0
+    deprecated: true
+  - name: app
+    version: 1.10.0
+  - name: app
+    version: 1.5.0-rc.1
+  - name: app
+    version: 1.4.2
+  other:
+  - name: other
+    version: 3.0.0
+`)
+		case "/invalid/index.yaml":
+			fmt.Fprint(w, `entries: [`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name             string
+		valuer           *HelmRepoValuer
+		expected         string
+		expectedErrorMsg string
+	}{
+		{
+			name:     "latest version",
+			valuer:   &HelmRepoValuer{URL: server.URL + "/charts", Chart: "app"},
+			expected: "1.10.0",
+		},
+		{
+			name:     "latest version matching constraint",
+			valuer:   &HelmRepoValuer{URL: server.URL + "/charts", Chart: "app", Constraint: "~1.4", Constraints: mustConstraint("~1.4")},
+			expected: "1.4.2",
+		},
+		{
+			name:     "latest prerelease version matching constraint",
+			valuer:   &HelmRepoValuer{URL: server.URL + "/charts", Chart: "app", Constraint: "~1.5.0-0", Constraints: mustConstraint("~1.5.0-0"), Prerelease: true},
+			expected: "1.5.0-rc.1",
+		},
+		{
+			name:             "no version matching constraint",
+			valuer:           &HelmRepoValuer{URL: server.URL + "/charts", Chart: "app", Constraint: "^2", Constraints: mustConstraint("^2")},
+			expectedErrorMsg: fmt.Sprintf("no version of chart app in %s/charts matching constraint ^2", server.URL),
+		},
+		{
+			name:             "unknown chart",
+			valuer:           &HelmRepoValuer{URL: server.URL + "/charts", Chart: "unknown"},
+			expectedErrorMsg: fmt.Sprintf("failed to retrieve the versions of chart unknown from %s/charts: chart unknown not found in the repository index", server.URL),
+		},
+		{
+			name:             "unknown repository",
+			valuer:           &HelmRepoValuer{URL: server.URL + "/unknown", Chart: "app"},
+			expectedErrorMsg: fmt.Sprintf("failed to retrieve the versions of chart app from %[1]s/unknown: failed to download %[1]s/unknown/index.yaml: 404 Not Found", server.URL),
+		},
+		{
+			name:             "invalid index",
+			valuer:           &HelmRepoValuer{URL: server.URL + "/invalid", Chart: "app"},
+			expectedErrorMsg: fmt.Sprintf("failed to retrieve the versions of chart app from %[1]s/invalid: failed to parse %[1]s/invalid/index.yaml: yaml: line 1: did not find expected node content", server.URL),
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := test.valuer.Value(context.Background(), ".")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Empty(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+
+	t.Run("cached index", func(t *testing.T) {
+		valuer := &HelmRepoValuer{URL: server.URL + "/charts", Chart: "other"}
+		for i := 0; i < 3; i++ {
+			actual, err := valuer.Value(context.Background(), fmt.Sprintf("/tmp/repo-%d", i))
+			require.NoError(t, err)
+			assert.Equal(t, "3.0.0", actual)
+		}
+		assert.Equal(t, "3.0.0", valuer.value)
+		// the index has been downloaded only once, for all the valuers
+		assert.Equal(t, int32(1), requests.Load())
+	})
+}
+
+func TestHelmRepoValuerIndexConcurrently(t *testing.T) {
+	t.Parallel()
+
+	var (
+		requests      atomic.Int32
+		slowReceived  = make(chan struct{})
+		fastReceived  = make(chan struct{})
+		flakyRequests atomic.Int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow/index.yaml":
+			requests.Add(1)
+			close(slowReceived)
+			// the slow index is returned only once the fast index is being downloaded too - which can't happen if they are downloaded one at a time
+			select {
+			case <-fastReceived:
+				fmt.Fprint(w, "entries:\n  app:\n  - version: 1.0.0\n")
+			case <-time.After(5 * time.Second):
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case "/fast/index.yaml":
+			requests.Add(1)
+			close(fastReceived)
+			fmt.Fprint(w, "entries:\n  app:\n  - version: 2.0.0\n")
+		case "/flaky/index.yaml":
+			if flakyRequests.Add(1) == 1 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, "entries:\n  app:\n  - version: 3.0.0\n")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			valuer := &HelmRepoValuer{URL: server.URL + "/slow", Chart: "app"}
+			actual, err := valuer.Value(context.Background(), ".")
+			assert.NoError(t, err)
+			assert.Equal(t, "1.0.0", actual)
+		}()
+	}
+
+	<-slowReceived
+	valuer := &HelmRepoValuer{URL: server.URL + "/fast", Chart: "app"}
+	actual, err := valuer.Value(context.Background(), ".")
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", actual)
+
+	wg.Wait()
+	// each index has been downloaded only once
+	assert.Equal(t, int32(2), requests.Load())
+
+	t.Run("failed download is retried", func(t *testing.T) {
+		_, err := (&HelmRepoValuer{URL: server.URL + "/flaky", Chart: "app"}).Value(context.Background(), ".")
+		require.Error(t, err)
+
+		actual, err := (&HelmRepoValuer{URL: server.URL + "/flaky", Chart: "app"}).Value(context.Background(), ".")
+		require.NoError(t, err)
+		assert.Equal(t, "3.0.0", actual)
+	})
+}
+
+func TestHelmRepoValuerValueWithOCIRepository(t *testing.T) {
+	t.Parallel()
+
+	server := registrytest.NewServer(map[string]map[string]string{
+		"charts/app": {
+			"1.0.0":         "{}",
+			"1.1.0_build.1": "{}",
+			"2.0.0-rc.1":    "{}",
+		},
+	}, "", "", 0)
+	t.Cleanup(server.Close)
+	client := &registry.Client{HTTPClient: http.DefaultClient}
+
+	tests := []struct {
+		name             string
+		valuer           *HelmRepoValuer
+		expected         string
+		expectedErrorMsg string
+	}{
+		{
+			name:     "latest version",
+			valuer:   &HelmRepoValuer{URL: "oci://" + server.Host + "/charts", Chart: "app", registryClient: client},
+			expected: "1.1.0+build.1",
+		},
+		{
+			name:     "latest prerelease version",
+			valuer:   &HelmRepoValuer{URL: "oci://" + server.Host + "/charts", Chart: "app", Prerelease: true, registryClient: client},
+			expected: "2.0.0-rc.1",
+		},
+		{
+			name:     "latest version matching constraint",
+			valuer:   &HelmRepoValuer{URL: "oci://" + server.Host + "/charts", Chart: "app", Constraint: "~1.0.0", Constraints: mustConstraint("~1.0.0"), registryClient: client},
+			expected: "1.0.0",
+		},
+		{
+			name:             "unknown chart",
+			valuer:           &HelmRepoValuer{URL: "oci://" + server.Host + "/charts", Chart: "unknown", registryClient: client},
+			expectedErrorMsg: fmt.Sprintf("failed to retrieve the versions of chart unknown from oci://%[1]s/charts: failed to GET %[1]s/v2/charts/unknown/tags/list?n=1000: 404 Not Found", server.Host),
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := test.valuer.Value(context.Background(), ".")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Empty(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package value
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/dailymotion-oss/octopilot/internal/image"
+	"github.com/dailymotion-oss/octopilot/internal/registry"
+)
+
+// OCIValuer is a valuer that returns the latest tag of an image hosted in an OCI registry - the one with the highest semantic version -
+// or its digest. The value is retrieved once, and then shared by all the repositories to update.
+type OCIValuer struct {
+	// Image is the name of the image - such as registry.example.com/org/app
+	Image string
+	// Tag is the tag of the image to get the digest of - if empty, the latest tag is used
+	Tag         string
+	Prefix      string
+	Constraint  string
+	Constraints *semver.Constraints
+	Prerelease  bool
+	// Digest is true to return the digest of the image - such as sha256:... - instead of its tag
+	Digest bool
+
+	client *registry.Client
+	mutex  sync.Mutex
+	value  string
+}
+
+func newOCIValuer(params map[string]string) (*OCIValuer, error) {
+	valuer := &OCIValuer{}
+
+	ref := params["image"]
+	if len(ref) == 0 {
+		return nil, errors.New("missing image parameter")
+	}
+	var digest string
+	valuer.Image, valuer.Tag, digest = image.ParseReference(ref)
+	if len(digest) > 0 {
+		return nil, fmt.Errorf("invalid image parameter %s: it can't have a digest", ref)
+	}
+
+	valuer.Digest, _ = strconv.ParseBool(params["digest"])
+	if len(valuer.Tag) > 0 && !valuer.Digest {
+		return nil, fmt.Errorf("invalid image parameter %s: it can only have a tag to retrieve its digest", ref)
+	}
+
+	var err error
+	valuer.Prefix = params["prefix"]
+	valuer.Constraint = params["constraint"]
+	valuer.Constraints, err = constraintParam(valuer.Constraint)
+	if err != nil {
+		return nil, err
+	}
+	valuer.Prerelease, _ = strconv.ParseBool(params["prerelease"])
+
+	return valuer, nil
+}
+
+// Value returns the value to replace while updating files in the given repository.
+func (v *OCIValuer) Value(ctx context.Context, _ string) (string, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if len(v.value) > 0 {
+		return v.value, nil
+	}
+
+	if v.client == nil {
+		v.client = registry.NewClient()
+	}
+	host, repository := registry.ParseRepository(v.Image)
+
+	tag := v.Tag
+	if len(tag) == 0 {
+		tags, err := v.client.Tags(ctx, host, repository)
+		if err != nil {
+			return "", fmt.Errorf("failed to retrieve the tags of %s: %w", v.Image, err)
+		}
+		filter := versionFilter{prefix: v.Prefix, constraints: v.Constraints, prerelease: v.Prerelease}
+		value, found := filter.latest(tags)
+		if !found {
+			return "", fmt.Errorf("no tag of %s matching %s", v.Image, filter)
+		}
+		if !v.Digest {
+			v.value = value
+			return v.value, nil
+		}
+		tag = v.Prefix + value
+	}
+
+	digest, err := v.client.Digest(ctx, host, repository, tag)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve the digest of %s:%s: %w", v.Image, tag, err)
+	}
+	v.value = digest
+	return v.value, nil
+}
@@ -0,0 +1,124 @@
+package value
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dailymotion-oss/octopilot/internal/registry"
+	"github.com/dailymotion-oss/octopilot/internal/registry/registrytest"
+)
+
+func TestOCIValuerValue(t *testing.T) {
+	t.Parallel()
+
+	server := registrytest.NewServer(map[string]map[string]string{
+		"org/app": {
+			"v1.9.0":      `{"schemaVersion": 2, "tag": "v1.9.0"}`,
+			"v2.1.0":      `{"schemaVersion": 2, "tag": "v2.1.0"}`,
+			"v2.10.0":     `{"schemaVersion": 2, "tag": "v2.10.0"}`,
+			"v3.0.0-rc.1": `{"schemaVersion": 2, "tag": "v3.0.0-rc.1"}`,
+			"latest":      `{"schemaVersion": 2, "tag": "latest"}`,
+		},
+	}, "user", "secret", 2)
+	t.Cleanup(server.Close)
+
+	newClient := func(username, password string) *registry.Client {
+		return &registry.Client{
+			HTTPClient: http.DefaultClient,
+			Credentials: func(host string) (string, string) {
+				if host != server.Host {
+					return "", ""
+				}
+				return username, password
+			},
+		}
+	}
+	image := server.Host + "/org/app"
+
+	tests := []struct {
+		name             string
+		valuer           *OCIValuer
+		expected         string
+		expectedErrorMsg string
+	}{
+		{
+			name:     "latest tag",
+			valuer:   &OCIValuer{Image: image},
+			expected: "v2.10.0",
+		},
+		{
+			name:     "latest prerelease tag with prefix",
+			valuer:   &OCIValuer{Image: image, Prefix: "v", Prerelease: true},
+			expected: "3.0.0-rc.1",
+		},
+		{
+			name:     "latest tag matching constraint",
+			valuer:   &OCIValuer{Image: image, Constraint: "^1", Constraints: mustConstraint("^1")},
+			expected: "v1.9.0",
+		},
+		{
+			name:     "digest of latest tag with prefix",
+			valuer:   &OCIValuer{Image: image, Prefix: "v", Digest: true},
+			expected: registrytest.Digest(`{"schemaVersion": 2, "tag": "v2.10.0"}`),
+		},
+		{
+			name:     "digest of tag",
+			valuer:   &OCIValuer{Image: image, Tag: "latest", Digest: true},
+			expected: registrytest.Digest(`{"schemaVersion": 2, "tag": "latest"}`),
+		},
+		{
+			name:             "no tag matching constraint",
+			valuer:           &OCIValuer{Image: image, Constraint: "^4", Constraints: mustConstraint("^4")},
+			expectedErrorMsg: fmt.Sprintf("no tag of %s matching constraint ^4", image),
+		},
+		{
+			name:             "unknown tag",
+			valuer:           &OCIValuer{Image: image, Tag: "unknown", Digest: true},
+			expectedErrorMsg: fmt.Sprintf("failed to retrieve the digest of %[1]s:unknown: failed to HEAD %[2]s/v2/org/app/manifests/unknown: 404 Not Found", image, server.Host),
+		},
+		{
+			name:             "unknown image",
+			valuer:           &OCIValuer{Image: server.Host + "/org/unknown"},
+			expectedErrorMsg: fmt.Sprintf("failed to retrieve the tags of %[1]s/org/unknown: failed to GET %[1]s/v2/org/unknown/tags/list?n=1000: 404 Not Found", server.Host),
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			test.valuer.client = newClient("user", "secret")
+			actual, err := test.valuer.Value(context.Background(), ".")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Empty(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		t.Parallel()
+		valuer := &OCIValuer{Image: image, client: newClient("user", "wrong")}
+		_, err := valuer.Value(context.Background(), ".")
+		require.EqualError(t, err, fmt.Sprintf("failed to retrieve the tags of %[1]s: failed to authenticate to %[2]s: failed to request token: 401 Unauthorized", image, server.Host))
+	})
+
+	t.Run("cached value", func(t *testing.T) {
+		t.Parallel()
+		valuer := &OCIValuer{Image: image, client: newClient("user", "secret")}
+		for i := 0; i < 3; i++ {
+			actual, err := valuer.Value(context.Background(), fmt.Sprintf("/tmp/repo-%d", i))
+			require.NoError(t, err)
+			assert.Equal(t, "v2.10.0", actual)
+		}
+		assert.Equal(t, "v2.10.0", valuer.value)
+	})
+}
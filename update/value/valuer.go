@@ -34,18 +34,35 @@ func ParseValuer(valueStr string) (Valuer, error) {
 
 	params := parameters.Parse(paramsStr)
 
+	return New(valuerName, params)
+}
+
+// New builds a new valuer instance from its name and parameters.
+func New(name string, params map[string]string) (Valuer, error) {
 	var (
 		valuer Valuer
 		err    error
 	)
-	switch valuerName {
+	switch name {
 	case "file":
 		valuer, err = newFileValuer(params)
+	case "env":
+		valuer, err = newEnvValuer(params)
+	case "exec":
+		valuer, err = newExecValuer(params)
+	case "githubrelease":
+		valuer, err = newGitHubReleaseValuer(params)
+	case "githubtag":
+		valuer, err = newGitHubTagValuer(params)
+	case "oci":
+		valuer, err = newOCIValuer(params)
+	case "helmrepo":
+		valuer, err = newHelmRepoValuer(params)
 	default:
-		return nil, fmt.Errorf("unknown valuer %s", valuerName)
+		return nil, fmt.Errorf("unknown valuer %s", name)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create a valuer instance for %s: %w", valuerName, err)
+		return nil, fmt.Errorf("failed to create a valuer instance for %s: %w", name, err)
 	}
 
 	return valuer, nil
@@ -1,8 +1,11 @@
 package value
 
 import (
+	"regexp"
 	"testing"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -46,6 +49,144 @@ func TestParse(t *testing.T) {
 			value:            "file(path=)",
 			expectedErrorMsg: "failed to create a valuer instance for file: missing path parameter",
 		},
+		{
+			name:  "env value",
+			value: "env(name=VERSION,default=0.0.0)",
+			expected: &EnvValuer{
+				Name:    "VERSION",
+				Default: "0.0.0",
+			},
+		},
+		{
+			name:  "required env value",
+			value: "env(name=VERSION,required=true)",
+			expected: &EnvValuer{
+				Name:     "VERSION",
+				Required: true,
+			},
+		},
+		{
+			name:             "env value without name",
+			value:            "env(default=0.0.0)",
+			expectedErrorMsg: "failed to create a valuer instance for env: missing name parameter",
+		},
+		{
+			name:             "required env value with default",
+			value:            "env(name=VERSION,required=true,default=0.0.0)",
+			expectedErrorMsg: "failed to create a valuer instance for env: invalid parameters: required and default can't be used together",
+		},
+		{
+			name:  "exec value",
+			value: "exec(cmd=git,args='describe --tags --abbrev=0',timeout=30s)",
+			expected: &ExecValuer{
+				Command: "git",
+				Args:    []string{"describe", "--tags", "--abbrev=0"},
+				Timeout: 30 * time.Second,
+			},
+		},
+		{
+			name:             "exec value without cmd",
+			value:            "exec(args=--version)",
+			expectedErrorMsg: "failed to create a valuer instance for exec: missing cmd parameter",
+		},
+		{
+			name:  "githubrelease value",
+			value: "githubrelease(repo=org/lib,prerelease=true,constraint=~1.4)",
+			expected: &GitHubReleaseValuer{
+				Repository:  "org/lib",
+				Constraint:  "~1.4",
+				Constraints: mustConstraint("~1.4"),
+				Prerelease:  true,
+			},
+		},
+		{
+			name:             "githubrelease value with invalid repo",
+			value:            "githubrelease(repo=lib)",
+			expectedErrorMsg: "failed to create a valuer instance for githubrelease: invalid repo parameter lib: must be in the owner/repo format",
+		},
+		{
+			name:  "githubtag value",
+			value: "githubtag(repo=org/monorepo,pattern=^lib/(.*)$,prefix=v)",
+			expected: &GitHubTagValuer{
+				Repository: "org/monorepo",
+				Pattern:    "^lib/(.*)$",
+				Regexp:     regexp.MustCompile("^lib/(.*)$"),
+				Prefix:     "v",
+			},
+		},
+		{
+			name:             "githubtag value with invalid constraint",
+			value:            "githubtag(repo=org/lib,constraint=latest)",
+			expectedErrorMsg: "failed to create a valuer instance for githubtag: invalid constraint parameter latest: improper constraint: latest",
+		},
+		{
+			name:  "oci value",
+			value: "oci(image=registry.example.com/org/app,constraint=^2,prefix=v)",
+			expected: &OCIValuer{
+				Image:       "registry.example.com/org/app",
+				Prefix:      "v",
+				Constraint:  "^2",
+				Constraints: mustConstraint("^2"),
+			},
+		},
+		{
+			name:  "oci digest value",
+			value: "oci(image=localhost:5000/org/app:1.2.3,digest=true)",
+			expected: &OCIValuer{
+				Image:  "localhost:5000/org/app",
+				Tag:    "1.2.3",
+				Digest: true,
+			},
+		},
+		{
+			name:             "oci value without image",
+			value:            "oci(constraint=^2)",
+			expectedErrorMsg: "failed to create a valuer instance for oci: missing image parameter",
+		},
+		{
+			name:             "oci value with tag",
+			value:            "oci(image=org/app:1.2.3)",
+			expectedErrorMsg: "failed to create a valuer instance for oci: invalid image parameter org/app:1.2.3: it can only have a tag to retrieve its digest",
+		},
+		{
+			name:             "oci value with digest",
+			value:            "oci(image=org/app@sha256:abcd,digest=true)",
+			expectedErrorMsg: "failed to create a valuer instance for oci: invalid image parameter org/app@sha256:abcd: it can't have a digest",
+		},
+		{
+			name:  "helmrepo value",
+			value: "helmrepo(url=https://charts.example.com/,chart=app,constraint=~1.4,prerelease=true)",
+			expected: &HelmRepoValuer{
+				URL:         "https://charts.example.com",
+				Chart:       "app",
+				Constraint:  "~1.4",
+				Constraints: mustConstraint("~1.4"),
+				Prerelease:  true,
+			},
+		},
+		{
+			name:  "oci helmrepo value",
+			value: "helmrepo(url=oci://registry.example.com/charts,chart=app)",
+			expected: &HelmRepoValuer{
+				URL:   "oci://registry.example.com/charts",
+				Chart: "app",
+			},
+		},
+		{
+			name:             "helmrepo value without url",
+			value:            "helmrepo(chart=app)",
+			expectedErrorMsg: "failed to create a valuer instance for helmrepo: missing url parameter",
+		},
+		{
+			name:             "helmrepo value with invalid url",
+			value:            "helmrepo(url=charts.example.com,chart=app)",
+			expectedErrorMsg: "failed to create a valuer instance for helmrepo: invalid url parameter charts.example.com: must be an http, https or oci URL",
+		},
+		{
+			name:             "helmrepo value without chart",
+			value:            "helmrepo(url=https://charts.example.com)",
+			expectedErrorMsg: "failed to create a valuer instance for helmrepo: missing chart parameter",
+		},
 	}
 
 	for i := range tests {
@@ -63,3 +204,11 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func mustConstraint(constraint string) *semver.Constraints {
+	constraints, err := semver.NewConstraint(constraint)
+	if err != nil {
+		panic(err)
+	}
+	return constraints
+}
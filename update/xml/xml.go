@@ -0,0 +1,129 @@
+// Package xml provides an updater that updates XML files - such as Maven POM files - while preserving the formatting and the comments.
+package xml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dailymotion-oss/octopilot/internal/glob"
+	"github.com/dailymotion-oss/octopilot/internal/xml"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+)
+
+// XmlUpdater is an updater that updates the elements or attributes selected by an XPath expression, in XML files.
+type XmlUpdater struct {
+	FilePath string
+	Path     string
+	XPath    xml.XPath
+	Valuer   value.Valuer
+
+	change.Recorder
+}
+
+// NewUpdater builds a new XML updater from the given parameters and valuer
+func NewUpdater(params map[string]string, valuer value.Valuer) (*XmlUpdater, error) {
+	updater := &XmlUpdater{}
+
+	updater.FilePath = params["file"]
+	if len(updater.FilePath) == 0 {
+		return nil, errors.New("missing file parameter")
+	}
+
+	updater.Path = params["xpath"]
+	if len(updater.Path) == 0 {
+		return nil, errors.New("missing xpath parameter")
+	}
+
+	var err error
+	updater.XPath, err = xml.ParseXPath(updater.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	updater.Valuer = valuer
+
+	return updater, nil
+}
+
+// Update updates the repository cloned at the given path, and returns true if changes have been made
+func (u *XmlUpdater) Update(ctx context.Context, repoPath string) (bool, error) {
+	u.Reset(repoPath)
+
+	value, err := u.Valuer.Value(ctx, repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get value: %w", err)
+	}
+
+	filePaths, err := glob.ExpandGlobPattern(repoPath, u.FilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to expand glob pattern %s: %w", u.FilePath, err)
+	}
+
+	var updated bool
+	for _, filePath := range filePaths {
+		relFilePath, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			relFilePath = filePath
+		}
+
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to access file %s: %w", relFilePath, err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", relFilePath, err)
+		}
+
+		doc, err := xml.Parse(content)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse XML file %s: %w", relFilePath, err)
+		}
+
+		var (
+			replacements []xml.Replacement
+			changes      []change.Change
+		)
+		for _, node := range u.XPath.Select(doc) {
+			oldValue := doc.Text(node)
+			if oldValue == value {
+				continue
+			}
+			replacement, err := doc.SetValue(node, value)
+			if err != nil {
+				return false, fmt.Errorf("failed to update XML file %s: %w", relFilePath, err)
+			}
+			replacements = append(replacements, replacement)
+			changes = append(changes, change.Change{File: filepath.ToSlash(relFilePath), Name: node.Path(), Old: oldValue, New: value})
+		}
+		if len(replacements) == 0 {
+			continue
+		}
+
+		if err = os.WriteFile(filePath, doc.Replace(replacements...), fileInfo.Mode()); err != nil {
+			return false, fmt.Errorf("failed to write updated content to file %s: %w", relFilePath, err)
+		}
+
+		u.Record(repoPath, changes...)
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// Message returns the default title and body that should be used in the commits / pull requests
+func (u *XmlUpdater) Message() (title, body string) {
+	title = fmt.Sprintf("Update %s", u.FilePath)
+	body = fmt.Sprintf("Updating xpath `%s` in file(s) `%s`", u.Path, u.FilePath)
+	return title, body
+}
+
+// String returns a string representation of the updater
+func (u *XmlUpdater) String() string {
+	return fmt.Sprintf("XML[xpath=%s,file=%s]", u.Path, u.FilePath)
+}
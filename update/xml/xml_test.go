@@ -0,0 +1,259 @@
+package xml
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dailymotion-oss/octopilot/internal/xml"
+	"github.com/dailymotion-oss/octopilot/update/change"
+	"github.com/dailymotion-oss/octopilot/update/value"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdater(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		params           map[string]string
+		expected         *XmlUpdater
+		expectedErrorMsg string
+	}{
+		{
+			name: "valid params",
+			params: map[string]string{
+				"file":  "**/pom.xml",
+				"xpath": "//dependency[artifactId='my-lib']/version",
+			},
+			expected: &XmlUpdater{
+				FilePath: "**/pom.xml",
+				Path:     "//dependency[artifactId='my-lib']/version",
+				XPath:    mustParseXPath(t, "//dependency[artifactId='my-lib']/version"),
+			},
+		},
+		{
+			name:             "nil params",
+			expectedErrorMsg: "missing file parameter",
+		},
+		{
+			name: "missing mandatory xpath param",
+			params: map[string]string{
+				"file": "pom.xml",
+			},
+			expectedErrorMsg: "missing xpath parameter",
+		},
+		{
+			name: "invalid xpath",
+			params: map[string]string{
+				"file":  "pom.xml",
+				"xpath": "project/version",
+			},
+			expectedErrorMsg: `invalid xpath "project/version": expected an absolute path, starting with '/'`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewUpdater(test.params, nil)
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		files            map[string]string
+		updater          *XmlUpdater
+		expected         bool
+		expectedErrorMsg string
+		expectedFiles    map[string]string
+		expectedChanges  []change.Change
+	}{
+		{
+			name: "update dependency version in a maven pom",
+			files: map[string]string{
+				"maven/pom.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+    <!-- the dependencies -->
+    <dependencies>
+        <dependency>
+            <groupId>com.example</groupId>
+            <artifactId>my-lib</artifactId>
+            <version>1.0.0</version> <!-- managed by octopilot -->
+        </dependency>
+        <dependency>
+            <groupId>com.example</groupId>
+            <artifactId>other-lib</artifactId>
+            <version>1.0.0</version>
+        </dependency>
+    </dependencies>
+</project>
+`,
+			},
+			updater: &XmlUpdater{
+				FilePath: "maven/pom.xml",
+				Path:     "//dependency[artifactId='my-lib']/version",
+				XPath:    mustParseXPath(t, "//dependency[artifactId='my-lib']/version"),
+				Valuer:   value.StringValuer("1.1.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"maven/pom.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+    <!-- the dependencies -->
+    <dependencies>
+        <dependency>
+            <groupId>com.example</groupId>
+            <artifactId>my-lib</artifactId>
+            <version>1.1.0</version> <!-- managed by octopilot -->
+        </dependency>
+        <dependency>
+            <groupId>com.example</groupId>
+            <artifactId>other-lib</artifactId>
+            <version>1.0.0</version>
+        </dependency>
+    </dependencies>
+</project>
+`,
+			},
+			expectedChanges: []change.Change{
+				{File: "maven/pom.xml", Name: "/project/dependencies/dependency[1]/version", Old: "1.0.0", New: "1.1.0"},
+			},
+		},
+		{
+			name: "update attributes in multiple files",
+			files: map[string]string{
+				"multiple-files/a.xml": `<config><plugin id="compiler" version='1.0'/></config>`,
+				"multiple-files/b.xml": `<config>
+  <plugin id="compiler" version="1.0" />
+  <plugin id="surefire" version="1.0" />
+</config>`,
+			},
+			updater: &XmlUpdater{
+				FilePath: "multiple-files/*.xml",
+				Path:     "//plugin[@id='compiler']/@version",
+				XPath:    mustParseXPath(t, "//plugin[@id='compiler']/@version"),
+				Valuer:   value.StringValuer("2.0"),
+			},
+			expected: true,
+			expectedFiles: map[string]string{
+				"multiple-files/a.xml": `<config><plugin id="compiler" version='2.0'/></config>`,
+				"multiple-files/b.xml": `<config>
+  <plugin id="compiler" version="2.0" />
+  <plugin id="surefire" version="1.0" />
+</config>`,
+			},
+			expectedChanges: []change.Change{
+				{File: "multiple-files/a.xml", Name: "/config/plugin/@version", Old: "1.0", New: "2.0"},
+				{File: "multiple-files/b.xml", Name: "/config/plugin[1]/@version", Old: "1.0", New: "2.0"},
+			},
+		},
+		{
+			name: "no changes",
+			files: map[string]string{
+				"no-changes/pom.xml": `<project><version>1.0.0</version></project>`,
+			},
+			updater: &XmlUpdater{
+				FilePath: "no-changes/pom.xml",
+				Path:     "/project/version",
+				XPath:    mustParseXPath(t, "/project/version"),
+				Valuer:   value.StringValuer("1.0.0"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-changes/pom.xml": `<project><version>1.0.0</version></project>`,
+			},
+		},
+		{
+			name: "no matching node",
+			files: map[string]string{
+				"no-match/pom.xml": `<project><version>1.0.0</version></project>`,
+			},
+			updater: &XmlUpdater{
+				FilePath: "no-match/pom.xml",
+				Path:     "/project/parent/version",
+				XPath:    mustParseXPath(t, "/project/parent/version"),
+				Valuer:   value.StringValuer("2.0.0"),
+			},
+			expected: false,
+			expectedFiles: map[string]string{
+				"no-match/pom.xml": `<project><version>1.0.0</version></project>`,
+			},
+		},
+		{
+			name: "element with child elements",
+			files: map[string]string{
+				"element-with-children/pom.xml": `<project><parent><version>1.0.0</version></parent></project>`,
+			},
+			updater: &XmlUpdater{
+				FilePath: "element-with-children/pom.xml",
+				Path:     "/project/parent",
+				XPath:    mustParseXPath(t, "/project/parent"),
+				Valuer:   value.StringValuer("2.0.0"),
+			},
+			expectedErrorMsg: "failed to update XML file element-with-children/pom.xml: can't set the value of element /project/parent: it has child elements",
+		},
+		{
+			name: "invalid file",
+			files: map[string]string{
+				"invalid-file/pom.xml": `<project><version>1.0.0</project>`,
+			},
+			updater: &XmlUpdater{
+				FilePath: "invalid-file/pom.xml",
+				Path:     "/project/version",
+				XPath:    mustParseXPath(t, "/project/version"),
+				Valuer:   value.StringValuer("2.0.0"),
+			},
+			expectedErrorMsg: "failed to parse XML file invalid-file/pom.xml: element version closed by project",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			{
+				for filename, content := range test.files {
+					err := os.MkdirAll(filepath.Dir(filepath.Join("testdata", filename)), 0755)
+					require.NoErrorf(t, err, "can't create testdata directories for %s", filename)
+					err = os.WriteFile(filepath.Join("testdata", filename), []byte(content), 0644)
+					require.NoErrorf(t, err, "can't write testdata file %s", filename)
+				}
+			}
+
+			actual, err := test.updater.Update(context.Background(), "testdata")
+			if len(test.expectedErrorMsg) > 0 {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.False(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+				for filename, expectedContent := range test.expectedFiles {
+					actualContent, err := os.ReadFile(filepath.Join("testdata", filename))
+					require.NoErrorf(t, err, "can't read actual testdata file %s", filename)
+					assert.Equalf(t, expectedContent, string(actualContent), "testdata file %s doesn't match", filename)
+				}
+				assert.Equal(t, test.expectedChanges, test.updater.Changes("testdata"))
+			}
+		})
+	}
+}
+
+func mustParseXPath(t *testing.T, str string) xml.XPath {
+	xpath, err := xml.ParseXPath(str)
+	require.NoError(t, err)
+	return xpath
+}
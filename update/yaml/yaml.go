@@ -153,17 +153,9 @@ func (u *YamlUpdater) String() string {
 func (u *YamlUpdater) yqExpression(value string) (string, *yqlib.ExpressionNode, error) {
 	var (
 		parser        = yqlib.ExpressionParser
-		rawExpression string
+		rawExpression = PathExpression(u.Path)
 	)
 
-	if _, err := parser.ParseExpression(u.Path); err == nil && strings.HasPrefix(u.Path, ".") {
-		// we have a valid yq v4 expression - that starts with a dot
-		rawExpression = u.Path
-	} else {
-		// most likely an old v3 path format, let's convert it to a valid v4 path
-		rawExpression = convertYqExpressionToV4(u.Path)
-	}
-
 	// add the assignment operator to set the new value
 	expression := fmt.Sprintf(`(%s) ref $x | $x = %q`, rawExpression, value)
 
@@ -181,6 +173,16 @@ func (u *YamlUpdater) yqExpression(value string) (string, *yqlib.ExpressionNode,
 	return expression, expressionNode, err
 }
 
+// PathExpression returns the yq v4 expression for the given path - which can be either a yq v4 expression, or an old yq v3 path.
+func PathExpression(path string) string {
+	if _, err := yqlib.ExpressionParser.ParseExpression(path); err == nil && strings.HasPrefix(path, ".") {
+		// we have a valid yq v4 expression - that starts with a dot
+		return path
+	}
+	// most likely an old v3 path format, let's convert it to a valid v4 path
+	return convertYqExpressionToV4(path)
+}
+
 // convertYqExpressionToV4 converts from the old yq v3 format to the new yq v4 format
 func convertYqExpressionToV4(v3Format string) string {
 	if !strings.ContainsAny(v3Format, "()=") {